@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"backendT/internal/database"
+)
+
+func runBackup(args []string) error {
+	cfg := database.BackupConfigFromEnv()
+
+	fs := flag.NewFlagSet("backup", flag.ExitOnError)
+	fs.StringVar(&cfg.Dir, "dir", cfg.Dir, "directory to write the snapshot to")
+	fs.IntVar(&cfg.Keep, "keep", cfg.Keep, "number of snapshots to keep, 0 keeps all")
+	fs.BoolVar(&cfg.Gzip, "gzip", cfg.Gzip, "gzip the snapshot")
+	fs.Parse(args)
+
+	db := database.New()
+	defer db.Close()
+
+	path, err := db.Backup(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Backup written to %s", path)
+	return nil
+}
+
+func runRestore(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ExitOnError)
+	target := fs.String("db", os.Getenv("BLUEPRINT_DB_URL"), "database file to replace")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: main restore [-db path] <snapshot>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("restore needs exactly one snapshot file")
+	}
+	if *target == "" {
+		return fmt.Errorf("no database to restore into, set BLUEPRINT_DB_URL or pass -db")
+	}
+
+	if err := database.Restore(context.Background(), fs.Arg(0), *target); err != nil {
+		return err
+	}
+
+	log.Printf("Restored %s from %s", *target, fs.Arg(0))
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Subcommands of the api binary, running it without one starts the http server.
+var commands = map[string]func(args []string) error{
+	"backup":  runBackup,
+	"restore": runRestore,
+	"migrate": runMigrate,
+	"seed":    runSeed,
+}
+
+func runCommand(name string, args []string) error {
+	cmd, ok := commands[name]
+	if !ok {
+		printUsage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return cmd(args)
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, `Usage: main [command] [flags]
+
+Without a command the http server is started.
+
+Server flags:
+  -auto-migrate  apply pending migrations on startup, otherwise refuse to start while
+                 the schema is behind (default true, false when APP_ENV=production)
+
+Commands:
+  backup   take a snapshot of the database
+  restore  replace the database with a snapshot (server must be stopped)
+  migrate  manage the schema migrations (up, down, status, redo, version, create)
+  seed     fill the database with generated data (profiles: empty, demo, load-test)
+
+Run "main <command> -h" for the flags of a command.`)
+}
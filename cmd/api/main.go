@@ -2,18 +2,29 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"backendT/internal/database"
+	"backendT/internal/jobs"
 	"backendT/internal/server"
 )
 
-func gracefulShutdown(apiServer *http.Server, dbInstance database.Service, done chan bool) {
+// httpShutdownTimeout is how long the http server gets to finish the requests it is handling on shutdown.
+var httpShutdownTimeout = 5 * time.Second
+
+// workersShutdownMargin is added to JOBS_SHUTDOWN_TIMEOUT for the workers to release the jobs that didn't
+// finish in time and for the analytics sinks to flush.
+const workersShutdownMargin = time.Second
+
+func gracefulShutdown(apiServer *http.Server, dbInstance database.Service, waitWorkers func(ctx context.Context) error, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -24,31 +35,71 @@ func gracefulShutdown(apiServer *http.Server, dbInstance database.Service, done
 	log.Println("shutting down gracefully, press Ctrl+C again to force")
 	stop() // Allow Ctrl+C to force shutdown
 
-	// The context is used to inform the server it has 5 seconds to finish
-	// the request it is currently handling
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	shutdown(apiServer, dbInstance, waitWorkers)
+
+	log.Println("Server exiting")
+
+	// Notify the main goroutine that the shutdown is complete
+	done <- true
+}
+
+// shutdown stops the http server, then the background workers, then closes the database.
+func shutdown(apiServer *http.Server, dbInstance database.Service, waitWorkers func(ctx context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
 	defer cancel()
 	if err := apiServer.Shutdown(ctx); err != nil {
 		log.Printf("Server forced to shutdown with error: %v", err)
 	}
 
+	// Running jobs finish or are released before the database closes, the leases of those that
+	// don't make it in time expire and they run again on the next start. They get their own deadline,
+	// however long the http server took
+	ctx, cancel = context.WithTimeout(context.Background(), jobs.ConfigFromEnv().ShutdownTimeout+workersShutdownMargin)
+	defer cancel()
+	if err := waitWorkers(ctx); err != nil {
+		log.Printf("Background workers didn't stop in time: %v", err)
+	}
+
 	dbInstance.Close()
+}
 
-	log.Println("Server exiting")
+func main() {
+	// Run a maintenance command instead of the server, e.g. "main backup"
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		if err := runCommand(os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	// Notify the main goroutine that the shutdown is complete
-	done <- true
+	if err := runServer(os.Args[1:]); err != nil {
+		log.Fatal(err)
+	}
 }
 
-func main() {
+// runServer starts the http server and blocks until it shut down gracefully.
+func runServer(args []string) error {
+	fs := flag.NewFlagSet("main", flag.ExitOnError)
+	autoMigrate := fs.Bool("auto-migrate", os.Getenv("APP_ENV") != "production",
+		"apply pending migrations on startup, otherwise refuse to start while the schema is behind (default false when APP_ENV=production)")
+	fs.Usage = printUsage
+	fs.Parse(args)
+
+	// The schema is checked either way, the migrations applied on startup may have failed
+	database.SetAutoMigrate(*autoMigrate)
+	db := database.New()
+	if err := database.CheckSchema(context.Background(), db.GetReadWriteDB()); err != nil {
+		db.Close()
+		return fmt.Errorf("refusing to start: %w", err)
+	}
 
-	server, dbInstance := server.NewServer()
+	server, dbInstance, waitWorkers := server.NewServer()
 
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
 
 	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(server, dbInstance, done)
+	go gracefulShutdown(server, dbInstance, waitWorkers, done)
 
 	err := server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
@@ -58,4 +109,5 @@ func main() {
 	// Wait for the graceful shutdown to complete
 	<-done
 	log.Println("Graceful shutdown complete.")
+	return nil
 }
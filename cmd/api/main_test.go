@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"backendT/internal/database"
+)
+
+type closeRecorder struct {
+	database.Service
+	closed func()
+}
+
+func (c closeRecorder) Close() error {
+	c.closed()
+	return nil
+}
+
+// The workers get JOBS_SHUTDOWN_TIMEOUT after the http server stopped, whatever time that took, and the
+// database only closes once the running jobs are recorded.
+func TestShutdownWaitsForWorkers(t *testing.T) {
+	httpShutdownTimeout = 50 * time.Millisecond
+	defer func() { httpShutdownTimeout = 5 * time.Second }()
+	t.Setenv("JOBS_SHUTDOWN_TIMEOUT", "200ms")
+
+	recorded := false
+	waitWorkers := func(ctx context.Context) error {
+		// A job finishing later than the http shutdown timeout, but within the jobs one
+		select {
+		case <-time.After(100 * time.Millisecond):
+			recorded = true
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	closedAfterRecord := false
+	db := closeRecorder{closed: func() { closedAfterRecord = recorded }}
+
+	shutdown(&http.Server{}, db, waitWorkers)
+	assert.True(t, recorded)
+	assert.True(t, closedAfterRecord)
+}
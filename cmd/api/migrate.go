@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"backendT/internal/database"
+)
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dbURL := fs.String("db", "file:"+os.Getenv("BLUEPRINT_DB_URL"), "database url")
+	dir := fs.String("dir", database.MigrationsSourceDir, "directory new migrations are created in")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), `Usage: main migrate [flags] <command> [args]
+
+Commands:
+  up               apply all pending migrations
+  up-to VERSION    apply migrations up to VERSION
+  down             roll back the last migration
+  down-to VERSION  roll back migrations down to VERSION
+  redo             roll back and re-apply the last migration
+  status           list migrations and whether they are applied
+  version          print the current schema version
+  create NAME      create a new sql migration in -dir (needs a rebuild to be embedded)
+
+Flags:`)
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("missing migrate command")
+	}
+
+	command, rest := fs.Arg(0), fs.Args()[1:]
+	if command == "create" {
+		if len(rest) != 1 {
+			return fmt.Errorf("create needs a migration name")
+		}
+		return database.CreateMigration(*dir, rest[0])
+	}
+
+	return database.Migrate(context.Background(), *dbURL, command, rest...)
+}
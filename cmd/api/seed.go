@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"backendT/internal/database"
+	"backendT/internal/database/seed"
+)
+
+func runSeed(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	profile := fs.String("profile", "demo", "data set to generate: "+strings.Join(seed.ProfileNames(), ", "))
+	randomSeed := fs.Int64("seed", seed.DefaultSeed, "random seed, the same seed generates the same data")
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return fmt.Errorf("seed takes no arguments")
+	}
+
+	db := database.New()
+	defer db.Close()
+	if err := database.CheckSchema(context.Background(), db.GetReadWriteDB()); err != nil {
+		return err
+	}
+
+	if err := seed.Run(context.Background(), db, *profile, *randomSeed); err != nil {
+		return err
+	}
+
+	log.Printf("Seeded database with the %s profile (seed %d)", *profile, *randomSeed)
+	return nil
+}
@@ -4,11 +4,13 @@ go 1.25.1
 
 require (
 	github.com/Treblle/treblle-go/v2 v2.0.0
+	github.com/andybalholm/brotli v1.2.0
 	github.com/joho/godotenv v1.5.1
 	github.com/labstack/echo/v4 v4.13.4
 	github.com/pressly/goose/v3 v3.26.0
 	github.com/stretchr/testify v1.11.1
 	github.com/swaggo/swag v1.16.6
+	golang.org/x/image v0.25.0
 	modernc.org/sqlite v1.38.2
 )
 
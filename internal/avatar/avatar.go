@@ -0,0 +1,263 @@
+// Package avatar stores the profile pictures of users on the local filesystem. Uploads are checked for
+// their size and type, and saved next to a square thumbnail. Files no user refers to are swept away.
+package avatar
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Upload errors, anything else is a failure to write the files.
+var (
+	ErrTooLarge    = errors.New("avatar is too large")
+	ErrUnsupported = errors.New("avatar is not a png, jpeg, gif or webp image")
+	ErrInvalid     = errors.New("avatar is not a valid image")
+)
+
+// Accepted content types (sniffed from the file, the one sent by the client is ignored) and their extension.
+var extensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// maxDimension caps the width and height of uploads, small files can still decode into huge images.
+const maxDimension = 4096
+
+const thumbnailSuffix = "_thumb"
+
+type Config struct {
+	Dir           string
+	MaxBytes      int64
+	ThumbnailSize int
+	// How often files no user refers to are removed, 0 disables the sweeper
+	SweepInterval time.Duration
+}
+
+// ConfigFromEnv reads AVATAR_DIR (./db/avatars), AVATAR_MAX_BYTES (2 MiB), AVATAR_THUMBNAIL_SIZE (128 pixels)
+// and AVATAR_SWEEP_INTERVAL (1h).
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Dir:           os.Getenv("AVATAR_DIR"),
+		MaxBytes:      2 << 20,
+		ThumbnailSize: 128,
+		SweepInterval: time.Hour,
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "./db/avatars"
+	}
+	if maxBytes, err := strconv.ParseInt(os.Getenv("AVATAR_MAX_BYTES"), 10, 64); err == nil && maxBytes > 0 {
+		cfg.MaxBytes = maxBytes
+	}
+	if size, err := strconv.Atoi(os.Getenv("AVATAR_THUMBNAIL_SIZE")); err == nil && size > 0 {
+		cfg.ThumbnailSize = size
+	}
+	if interval, err := time.ParseDuration(os.Getenv("AVATAR_SWEEP_INTERVAL")); err == nil {
+		cfg.SweepInterval = interval
+	}
+	return cfg
+}
+
+type Store struct {
+	cfg Config
+}
+
+func NewStore(cfg Config) *Store {
+	return &Store{cfg: cfg}
+}
+
+// Dir is where the avatars are stored, served as they are.
+func (s *Store) Dir() string {
+	return s.cfg.Dir
+}
+
+// MaxBytes is the largest accepted upload.
+func (s *Store) MaxBytes() int64 {
+	return s.cfg.MaxBytes
+}
+
+// Save checks the uploaded image and writes it with its thumbnail. It returns the name of the avatar,
+// the file name of the original image under Dir, see ThumbnailName for the thumbnail.
+func (s *Store) Save(r io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.cfg.MaxBytes+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > s.cfg.MaxBytes {
+		return "", ErrTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return "", ErrUnsupported
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= 0 || config.Height <= 0 {
+		return "", ErrInvalid
+	}
+	if config.Width > maxDimension || config.Height > maxDimension {
+		return "", fmt.Errorf("%w, at most %dx%d pixels", ErrTooLarge, maxDimension, maxDimension)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", ErrInvalid
+	}
+
+	var thumb bytes.Buffer
+	thumbnail := s.thumbnail(img)
+	if contentType == "image/jpeg" {
+		err = jpeg.Encode(&thumb, thumbnail, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&thumb, thumbnail)
+	}
+	if err != nil {
+		return "", fmt.Errorf("encode thumbnail: %w", err)
+	}
+
+	if err := os.MkdirAll(s.cfg.Dir, 0755); err != nil {
+		return "", fmt.Errorf("create avatar directory: %w", err)
+	}
+	id := make([]byte, 16)
+	rand.Read(id)
+	name := hex.EncodeToString(id) + ext
+
+	if err := writeFile(filepath.Join(s.cfg.Dir, ThumbnailName(name)), thumb.Bytes()); err != nil {
+		return "", err
+	}
+	if err := writeFile(filepath.Join(s.cfg.Dir, name), data); err != nil {
+		os.Remove(filepath.Join(s.cfg.Dir, ThumbnailName(name)))
+		return "", err
+	}
+	return name, nil
+}
+
+// thumbnail crops the center square of img and scales it down to ThumbnailSize.
+func (s *Store) thumbnail(img image.Image) image.Image {
+	b := img.Bounds()
+	side := min(b.Dx(), b.Dy())
+	crop := image.Rect(0, 0, side, side).Add(b.Min).Add(image.Pt((b.Dx()-side)/2, (b.Dy()-side)/2))
+
+	size := min(s.cfg.ThumbnailSize, side)
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Src, nil)
+	return dst
+}
+
+// writeFile writes through a temporary file so a half written avatar is never served.
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write avatar: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("write avatar: %w", err)
+	}
+	return nil
+}
+
+// ThumbnailName returns the file name of the thumbnail of an avatar.
+// Thumbnails of jpeg avatars are jpeg as well, the others are png.
+func ThumbnailName(name string) string {
+	ext := filepath.Ext(name)
+	thumbExt := ".png"
+	if ext == ".jpg" {
+		thumbExt = ".jpg"
+	}
+	return strings.TrimSuffix(name, ext) + thumbnailSuffix + thumbExt
+}
+
+// Remove deletes an avatar and its thumbnail, missing files are not an error.
+func (s *Store) Remove(name string) error {
+	if name == "" || name != filepath.Base(name) {
+		return nil
+	}
+	var errs []error
+	for _, file := range []string{name, ThumbnailName(name)} {
+		if err := os.Remove(filepath.Join(s.cfg.Dir, file)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Sweep removes the files of avatars that are not in use, last modified before the given time so
+// uploads not yet recorded on their user are spared. It returns the number of removed files.
+func (s *Store) Sweep(inUse map[string]bool, before time.Time) (int, error) {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	thumbnails := map[string]bool{}
+	for name := range inUse {
+		thumbnails[ThumbnailName(name)] = true
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || inUse[name] || thumbnails[name] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().Before(before) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.cfg.Dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// RunSweeper sweeps the files no user refers to every cfg.SweepInterval until ctx is cancelled,
+// inUse lists the avatars users have.
+func (s *Store) RunSweeper(ctx context.Context, inUse func(ctx context.Context) (map[string]bool, error), onError func(error)) {
+	if s.cfg.SweepInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.cfg.SweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			names, err := inUse(ctx)
+			if err == nil {
+				_, err = s.Sweep(names, now.Add(-s.cfg.SweepInterval))
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package avatar
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, x%height, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestSave(t *testing.T) {
+	store := NewStore(Config{Dir: t.TempDir(), MaxBytes: 1 << 20, ThumbnailSize: 32})
+
+	name, err := store.Save(bytes.NewReader(testPNG(t, 300, 200)))
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(name, ".png"))
+
+	f, err := os.Open(filepath.Join(store.Dir(), ThumbnailName(name)))
+	assert.NoError(t, err)
+	thumb, _, err := image.DecodeConfig(f)
+	f.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, 32, thumb.Width)
+	assert.Equal(t, 32, thumb.Height)
+
+	_, err = store.Save(strings.NewReader("just some text, not an image"))
+	assert.ErrorIs(t, err, ErrUnsupported)
+
+	_, err = store.Save(bytes.NewReader(testPNG(t, 300, 200)[:100]))
+	assert.ErrorIs(t, err, ErrInvalid)
+
+	small := NewStore(Config{Dir: t.TempDir(), MaxBytes: 64, ThumbnailSize: 32})
+	_, err = small.Save(bytes.NewReader(testPNG(t, 300, 200)))
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	assert.NoError(t, store.Remove(name))
+	assert.NoFileExists(t, filepath.Join(store.Dir(), name))
+	assert.NoFileExists(t, filepath.Join(store.Dir(), ThumbnailName(name)))
+	assert.NoError(t, store.Remove(name))
+}
+
+func TestSweep(t *testing.T) {
+	store := NewStore(Config{Dir: t.TempDir(), MaxBytes: 1 << 20, ThumbnailSize: 32})
+	kept, err := store.Save(bytes.NewReader(testPNG(t, 64, 64)))
+	assert.NoError(t, err)
+	orphan, err := store.Save(bytes.NewReader(testPNG(t, 64, 64)))
+	assert.NoError(t, err)
+
+	// Recent files may belong to an upload in progress
+	removed, err := store.Sweep(map[string]bool{kept: true}, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Zero(t, removed)
+
+	removed, err = store.Sweep(map[string]bool{kept: true}, time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	assert.FileExists(t, filepath.Join(store.Dir(), kept))
+	assert.FileExists(t, filepath.Join(store.Dir(), ThumbnailName(kept)))
+	assert.NoFileExists(t, filepath.Join(store.Dir(), orphan))
+
+	removed, err = NewStore(Config{Dir: filepath.Join(store.Dir(), "missing")}).Sweep(nil, time.Now())
+	assert.NoError(t, err)
+	assert.Zero(t, removed)
+}
+
+func TestThumbnailName(t *testing.T) {
+	assert.Equal(t, "abc_thumb.jpg", ThumbnailName("abc.jpg"))
+	assert.Equal(t, "abc_thumb.png", ThumbnailName("abc.png"))
+	assert.Equal(t, "abc_thumb.png", ThumbnailName("abc.webp"))
+}
@@ -0,0 +1,300 @@
+package database
+
+import (
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const backupPrefix = "backup-"
+
+// BackupConfig describes where snapshots are written and how many of them are kept.
+type BackupConfig struct {
+	// Directory the snapshots are written to, created if missing
+	Dir string
+	// Number of newest snapshots to keep, older ones are deleted (0 keeps everything)
+	Keep int
+	// Compress snapshots with gzip
+	Gzip bool
+	// How often the scheduler takes a snapshot (0 disables scheduled backups)
+	Interval time.Duration
+}
+
+// BackupConfigFromEnv reads the backup configuration from BACKUP_DIR, BACKUP_KEEP, BACKUP_GZIP and BACKUP_INTERVAL.
+func BackupConfigFromEnv() BackupConfig {
+	cfg := BackupConfig{
+		Dir:  os.Getenv("BACKUP_DIR"),
+		Keep: 7,
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "./db/backups"
+	}
+	if keep, err := strconv.Atoi(os.Getenv("BACKUP_KEEP")); err == nil {
+		cfg.Keep = keep
+	}
+	cfg.Gzip, _ = strconv.ParseBool(os.Getenv("BACKUP_GZIP"))
+	if interval, err := time.ParseDuration(os.Getenv("BACKUP_INTERVAL")); err == nil {
+		cfg.Interval = interval
+	}
+	return cfg
+}
+
+// Backup takes a consistent snapshot of the running database with VACUUM INTO,
+// optionally gzips it and rotates old snapshots. It returns the path of the new snapshot.
+func (s *service) Backup(ctx context.Context, cfg BackupConfig) (string, error) {
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return "", fmt.Errorf("create backup directory: %w", err)
+	}
+
+	path := filepath.Join(cfg.Dir, backupPrefix+time.Now().UTC().Format("20060102T150405.000Z")+".db")
+
+	// VACUUM INTO only reads the source, so the ro pool is used and writers are not blocked
+	if _, err := s.dbro.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		os.Remove(path)
+		return "", fmt.Errorf("vacuum into %s: %w", path, err)
+	}
+
+	if cfg.Gzip {
+		gzPath, err := gzipFile(path)
+		if err != nil {
+			os.Remove(path)
+			return "", err
+		}
+		path = gzPath
+	}
+
+	if err := rotateBackups(cfg.Dir, cfg.Keep); err != nil {
+		log.Printf("Error rotating backups: %v", err)
+	}
+
+	return path, nil
+}
+
+// RunBackupScheduler takes a snapshot every cfg.Interval until ctx is cancelled.
+func RunBackupScheduler(ctx context.Context, s Service, cfg BackupConfig) {
+	if cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			path, err := s.Backup(ctx, cfg)
+			if err != nil {
+				log.Printf("Scheduled backup failed: %v", err)
+				continue
+			}
+			log.Printf("Scheduled backup written to %s", path)
+		}
+	}
+}
+
+// Restore replaces the database file at dbPath with the snapshot.
+// The snapshot is validated first (integrity check and goose version known to this binary),
+// the replaced database is kept next to it with a .pre-restore suffix.
+// The server must not be running while restoring.
+func Restore(ctx context.Context, snapshot, dbPath string) error {
+	dbPath = filePathFromURL(dbPath)
+
+	// Copy the snapshot next to the database so the final swap is a rename on the same filesystem
+	tmpPath := dbPath + ".restore"
+	if err := copySnapshot(snapshot, tmpPath); err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	if err := validateSnapshot(ctx, tmpPath); err != nil {
+		return err
+	}
+
+	// The current database and its wal and shm files are moved away together, and back when the snapshot
+	// can't be swapped in, so a failed restore leaves the database as it was
+	oldPath := dbPath + ".pre-restore-" + time.Now().UTC().Format("20060102T150405Z")
+	moved, err := moveDatabase(dbPath, oldPath)
+	if err != nil {
+		return fmt.Errorf("move current database away: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, dbPath); err != nil {
+		if moved {
+			if _, rollbackErr := moveDatabase(oldPath, dbPath); rollbackErr != nil {
+				return fmt.Errorf("swap in snapshot: %w (previous database left at %s: %v)", err, oldPath, rollbackErr)
+			}
+		}
+		return fmt.Errorf("swap in snapshot: %w", err)
+	}
+	if moved {
+		log.Printf("Previous database kept at %s", oldPath)
+	}
+	return nil
+}
+
+// moveDatabase renames the database file at from and its wal and shm files, when they exist, to to. When one of
+// the renames fails the files already moved are moved back. It reports whether there was a database to move.
+func moveDatabase(from, to string) (bool, error) {
+	if _, err := os.Stat(from); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	var moved []string
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if suffix != "" {
+			if _, err := os.Stat(from + suffix); os.IsNotExist(err) {
+				continue
+			}
+		}
+		if err := os.Rename(from+suffix, to+suffix); err != nil {
+			for _, done := range moved {
+				if rollbackErr := os.Rename(to+done, from+done); rollbackErr != nil {
+					log.Printf("Error moving %s back: %v", to+done, rollbackErr)
+				}
+			}
+			return false, fmt.Errorf("rename %s: %w", from+suffix, err)
+		}
+		moved = append(moved, suffix)
+	}
+	return true, nil
+}
+
+// validateSnapshot checks that the file is a healthy sqlite database migrated by a version of goose this binary knows.
+func validateSnapshot(ctx context.Context, path string) error {
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		return fmt.Errorf("open snapshot: %w", err)
+	}
+	defer db.Close()
+
+	var integrity string
+	if err := db.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&integrity); err != nil {
+		return fmt.Errorf("snapshot integrity check: %w", err)
+	}
+	if integrity != "ok" {
+		return fmt.Errorf("snapshot integrity check failed: %s", integrity)
+	}
+
+	version, latest, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	if version == 0 {
+		return fmt.Errorf("snapshot has no goose migrations applied")
+	}
+	if version > latest {
+		return fmt.Errorf("snapshot goose version %d is newer than the latest known migration %d", version, latest)
+	}
+	return nil
+}
+
+func copySnapshot(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("open snapshot: %w", err)
+	}
+	defer in.Close()
+
+	var r io.Reader = in
+	if strings.HasSuffix(src, ".gz") {
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return fmt.Errorf("open gzipped snapshot: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return fmt.Errorf("copy snapshot: %w", err)
+	}
+	return out.Close()
+}
+
+func gzipFile(path string) (string, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	gzPath := path + ".gz"
+	out, err := os.Create(gzPath)
+	if err != nil {
+		return "", fmt.Errorf("create %s: %w", gzPath, err)
+	}
+
+	gz := gzip.NewWriter(out)
+	_, err = io.Copy(gz, in)
+	if closeErr := gz.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(gzPath)
+		return "", fmt.Errorf("gzip snapshot: %w", err)
+	}
+
+	os.Remove(path)
+	return gzPath, nil
+}
+
+// rotateBackups deletes all but the newest keep snapshots, the timestamp in the name makes them sort chronologically.
+func rotateBackups(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, backupPrefix) && (strings.HasSuffix(name, ".db") || strings.HasSuffix(name, ".db.gz")) {
+			backups = append(backups, name)
+		}
+	}
+	sort.Strings(backups)
+
+	for len(backups) > keep {
+		if err := os.Remove(filepath.Join(dir, backups[0])); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// filePathFromURL turns a sqlite url like file:./db/data.db?mode=rw into a plain file path.
+func filePathFromURL(url string) string {
+	path := strings.TrimPrefix(url, "file:")
+	if i := strings.Index(path, "?"); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
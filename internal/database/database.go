@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"backendT/internal/database/repository"
+	"backendT/internal/health"
 
 	_ "github.com/joho/godotenv/autoload"
 	_ "modernc.org/sqlite"
@@ -28,6 +29,9 @@ type Service interface {
 	// The keys and values in the map are service-specific.
 	Health() map[string]string
 
+	// RegisterHealthChecks adds the readiness checks of the database to r, see health.go
+	RegisterHealthChecks(r *health.Registry)
+
 	// get repository of rw db
 	GetRepositoryRW() *repository.Queries
 
@@ -40,6 +44,15 @@ type Service interface {
 	// get ro db
 	GetReadOnlyDB() *sql.DB
 
+	// WithTx runs fn atomically in a transaction on the rw db, see tx.go
+	WithTx(ctx context.Context, fn func(q *repository.Queries) error) error
+
+	// WithTxContext is WithTx that also passes the transaction context, needed for nested transactions
+	WithTxContext(ctx context.Context, fn func(ctx context.Context, q *repository.Queries) error) error
+
+	// Backup writes a consistent snapshot of the live database, see backup.go
+	Backup(ctx context.Context, cfg BackupConfig) (string, error)
+
 	// Close terminates the database connection.
 	// It returns an error if the connection cannot be closed.
 	Close() error
@@ -58,8 +71,6 @@ var (
 )
 
 func New(dburlOverride ...string) Service {
-	var doesExist bool = false
-
 	if dburl == "" && len(dburlOverride) == 0 {
 		log.Fatal("BLUEPRINT_DB_URL is not set, check your .env file")
 	}
@@ -76,11 +87,6 @@ func New(dburlOverride ...string) Service {
 		dburl = "file:" + os.Getenv("BLUEPRINT_DB_URL")
 	}
 
-	// check if file exists
-	if _, err := os.Stat(dburl); err != nil {
-		doesExist = true
-	}
-
 	dbro, err := sql.Open("sqlite", dburl)
 	if err != nil {
 		log.Fatal(err)
@@ -124,16 +130,16 @@ func New(dburlOverride ...string) Service {
 		dburl = "./data/sqlite.db"
 	}
 
-	goose.SetBaseFS(embedMigrations)
-
-	if err := goose.SetDialect("sqlite"); err != nil {
-		log.Fatalf("goose set dialect failed: %v", err)
-	}
-
-	// Run migrations
+	setupGoose()
 
-	if err := goose.Up(dbrw, "migrations"); err != nil {
-		log.Fatalf("goose up failed: %v", err)
+	// Run migrations, unless the caller checks the schema itself, see SetAutoMigrate. A failure is
+	// reported by CheckSchema
+	migrateErr = nil
+	if autoMigrate {
+		if err := goose.Up(dbrw, "migrations"); err != nil {
+			log.Printf("goose up failed: %v", err)
+			migrateErr = fmt.Errorf("apply migrations: %w", err)
+		}
 	}
 
 	queriesro := repository.New(dbro)
@@ -145,43 +151,19 @@ func New(dburlOverride ...string) Service {
 		reporw: queriesrw,
 	}
 
-	if !doesExist {
-		FillWithData(dbInstance)
-	}
-
 	return dbInstance
 }
 
-func FillWithData(s Service) {
-	repo := s.GetRepositoryRW()
-	ctx := context.Background()
-
-	_, err := repo.UsersCreate(ctx, repository.UsersCreateParams{
-		Username: "test",
-		Email:    "test@test.com",
-	})
-	if err != nil {
-		log.Printf("Error creating test user: %v", err)
-	}
-
-	//fmt.Println("Created test user")
-	//fmt.Println(repo.UsersGetAll(ctx))
-
-	userID, _ := repo.UsersGetByUsername(ctx, "test")
-	//fmt.Printf("Fetched test user: %+v\n", userID)
+// setupGoose points goose at the embedded migrations.
+func setupGoose() {
+	goose.SetBaseFS(embedMigrations)
 
-	_, err = repo.PostsCreate(ctx, repository.PostsCreateParams{
-		Title:   "Hello World",
-		Content: "This is the first post.",
-		UserID:  userID.ID,
-	})
-	if err != nil {
-		log.Printf("Error creating test post: %v", err)
+	if err := goose.SetDialect("sqlite"); err != nil {
+		log.Fatalf("goose set dialect failed: %v", err)
 	}
-
 }
 
-// Health checks the health of the database connection by pinging the database.
+// Health checks the health of the database connection by pinging both pools.
 // It returns a map with keys indicating various health statistics.
 func (s *service) Health() map[string]string {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
@@ -190,11 +172,16 @@ func (s *service) Health() map[string]string {
 	stats := make(map[string]string)
 
 	// Ping the database
-	err := s.dbro.PingContext(ctx)
-	if err != nil {
+	if err := s.dbro.PingContext(ctx); err != nil {
 		stats["status"] = "down"
 		stats["error"] = fmt.Sprintf("db down: %v", err)
-		log.Fatalf("db down: %v", err) // Log the error and terminate the program
+		log.Printf("db down: %v", err)
+		return stats
+	}
+	if err := s.dbrw.PingContext(ctx); err != nil {
+		stats["status"] = "down"
+		stats["error"] = fmt.Sprintf("rw db down: %v", err)
+		log.Printf("rw db down: %v", err)
 		return stats
 	}
 
@@ -257,6 +244,10 @@ func (s *service) Close() error {
 	log.Printf("Disconnected from database: %s", dburl)
 	errRO := s.dbro.Close()
 	errRw := s.dbrw.Close()
+	// Allow New to open a fresh connection after this one is closed
+	if dbInstance == s {
+		dbInstance = nil
+	}
 	if errRO != nil || errRw != nil {
 		return fmt.Errorf("failed to close database connection: ro=%v rw=%v", errRO, errRw)
 	}
@@ -2,7 +2,13 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"backendT/internal/database/repository"
 
@@ -89,4 +95,801 @@ func TestDatabaseIntegration(t *testing.T) {
 		}
 		assert.True(t, found, "Integration test user should be in the users list")
 	})
+
+	t.Run("Profile and delete user", func(t *testing.T) {
+		user, err := repo.UsersCreate(ctx, repository.UsersCreateParams{Username: "profile_test", Email: "profile@test.com"})
+		assert.NoError(t, err)
+		assert.False(t, user.DisplayName.Valid)
+
+		user, err = repo.UsersUpdateProfileByID(ctx, repository.UsersUpdateProfileByIDParams{
+			DisplayName: sql.NullString{String: "Profile Test", Valid: true},
+			ID:          user.ID,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "Profile Test", user.DisplayName.String)
+		assert.False(t, user.Bio.Valid)
+
+		_, err = repo.UsersUpdateAvatarByID(ctx, repository.UsersUpdateAvatarByIDParams{
+			Avatar: sql.NullString{String: "profile.png", Valid: true},
+			ID:     user.ID,
+		})
+		assert.NoError(t, err)
+		avatars, err := repo.UsersGetAvatars(ctx)
+		assert.NoError(t, err)
+		assert.Contains(t, avatars, sql.NullString{String: "profile.png", Valid: true})
+
+		post, err := repo.PostsCreate(ctx, repository.PostsCreateParams{UserID: user.ID, Title: "Bye", Content: "Soon deleted"})
+		assert.NoError(t, err)
+		_, err = repo.CommentsCreate(ctx, repository.CommentsCreateParams{PostID: post.ID, UserID: user.ID, Comment: "Me too"})
+		assert.NoError(t, err)
+
+		assert.NoError(t, repo.CommentsDeleteByUserID(ctx, user.ID))
+		assert.NoError(t, repo.PostsDeleteByUserID(ctx, user.ID))
+		deleted, err := repo.UsersDeleteByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+
+		comments, err := repo.CommentsGetByPostID(ctx, post.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, comments)
+		_, err = repo.UsersGetByID(ctx, user.ID)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Scheduled posts", func(t *testing.T) {
+		user, err := repo.UsersCreate(ctx, repository.UsersCreateParams{Username: "status_test", Email: "status@test.com"})
+		assert.NoError(t, err)
+
+		publishAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+		post, err := repo.PostsCreateWithStatus(ctx, repository.PostsCreateWithStatusParams{
+			UserID:      user.ID,
+			Title:       "Later",
+			Content:     "Scheduled",
+			Status:      "scheduled",
+			PublishedAt: sql.NullTime{Time: publishAt, Valid: true},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "scheduled", post.Status)
+
+		visible, err := repo.PostsGetVisibleByUserID(ctx, repository.PostsGetVisibleByUserIDParams{UserID: user.ID})
+		assert.NoError(t, err)
+		assert.Empty(t, visible)
+		visible, err = repo.PostsGetVisibleByUserID(ctx, repository.PostsGetVisibleByUserIDParams{UserID: user.ID, ViewerID: user.ID})
+		assert.NoError(t, err)
+		assert.Len(t, visible, 1)
+
+		due, err := repo.PostsPublishDue(ctx, sql.NullTime{Time: time.Now().UTC(), Valid: true})
+		assert.NoError(t, err)
+		assert.Empty(t, due)
+		due, err = repo.PostsPublishDue(ctx, sql.NullTime{Time: publishAt, Valid: true})
+		assert.NoError(t, err)
+		if assert.Len(t, due, 1) {
+			assert.Equal(t, post.ID, due[0].ID)
+			assert.Equal(t, "published", due[0].Status)
+		}
+
+		_, err = repo.PostsUpdateStatusByID(ctx, repository.PostsUpdateStatusByIDParams{Status: "unknown", ID: post.ID})
+		assert.Error(t, err, "the status is checked by the table")
+	})
+
+	t.Run("Post revisions", func(t *testing.T) {
+		user, err := repo.UsersGetByUsername(ctx, "integration_test")
+		assert.NoError(t, err)
+		post, err := repo.PostsCreate(ctx, repository.PostsCreateParams{UserID: user.ID, Title: "v1", Content: "First"})
+		assert.NoError(t, err)
+
+		update := repository.PostsUpdateByIDParams{Title: "v2", Content: "Second", ID: post.ID, OldTitle: "v1", OldContent: "First"}
+		_, err = repo.PostsUpdateByID(ctx, update)
+		assert.NoError(t, err)
+		// Status changes and updates changing nothing are no revisions
+		update.OldTitle, update.OldContent = update.Title, update.Content
+		_, err = repo.PostsUpdateByID(ctx, update)
+		assert.NoError(t, err)
+		_, err = repo.PostsUpdateStatusByID(ctx, repository.PostsUpdateStatusByIDParams{Status: "draft", ID: post.ID})
+		assert.NoError(t, err)
+
+		revisions, err := repo.PostRevisionsGetByPostID(ctx, post.ID)
+		assert.NoError(t, err)
+		if assert.Len(t, revisions, 2) {
+			assert.Equal(t, int64(1), revisions[0].Revision)
+			assert.Equal(t, "First", revisions[0].Content)
+			assert.Equal(t, int64(2), revisions[1].Revision)
+			assert.Equal(t, "v2", revisions[1].Title)
+		}
+		latest, err := repo.PostRevisionsGetLatest(ctx, post.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), latest.Revision)
+
+		assert.NoError(t, repo.PostsDeleteByUserID(ctx, user.ID))
+		revisions, err = repo.PostRevisionsGetByPostID(ctx, post.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, revisions)
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		user, err := repo.UsersCreate(ctx, repository.UsersCreateParams{Username: "tags_test", Email: "tags@test.com"})
+		assert.NoError(t, err)
+		tag := func(postID int64, names ...string) {
+			for _, name := range names {
+				tag, err := repo.TagsUpsert(ctx, name)
+				assert.NoError(t, err)
+				assert.NoError(t, repo.PostTagsAdd(ctx, repository.PostTagsAddParams{PostID: postID, TagID: tag.ID}))
+			}
+		}
+		both, err := repo.PostsCreate(ctx, repository.PostsCreateParams{UserID: user.ID, Title: "Both", Content: "x"})
+		assert.NoError(t, err)
+		tag(both.ID, "db-go", "db-sql", "db-go")
+		goOnly, err := repo.PostsCreate(ctx, repository.PostsCreateParams{UserID: user.ID, Title: "Go", Content: "x"})
+		assert.NoError(t, err)
+		tag(goOnly.ID, "db-go")
+		draft, err := repo.PostsCreateWithStatus(ctx, repository.PostsCreateWithStatusParams{UserID: user.ID, Title: "Draft", Content: "x", Status: "draft"})
+		assert.NoError(t, err)
+		tag(draft.ID, "db-go")
+
+		tags, err := repo.TagsGetByPostID(ctx, both.ID)
+		assert.NoError(t, err)
+		assert.Len(t, tags, 2)
+
+		titles := func(posts []repository.Post, err error) []string {
+			assert.NoError(t, err)
+			var titles []string
+			for _, p := range posts {
+				titles = append(titles, p.Title)
+			}
+			return titles
+		}
+		assert.Equal(t, []string{"Both", "Go"}, titles(repo.PostsGetVisibleByAnyTag(ctx, repository.PostsGetVisibleByAnyTagParams{Names: []string{"db-go", "db-sql"}})))
+		assert.Equal(t, []string{"Both"}, titles(repo.PostsGetVisibleByAllTags(ctx, repository.PostsGetVisibleByAllTagsParams{TagCount: 2, Names: []string{"db-go", "db-sql"}})))
+		assert.Equal(t, []string{"Both", "Go", "Draft"}, titles(repo.PostsGetVisibleByAnyTag(ctx, repository.PostsGetVisibleByAnyTagParams{ViewerID: user.ID, Names: []string{"db-go"}})))
+		assert.Empty(t, titles(repo.PostsGetVisibleByAnyTag(ctx, repository.PostsGetVisibleByAnyTagParams{})))
+
+		// The draft isn't counted
+		matches, err := repo.TagsAutocomplete(ctx, repository.TagsAutocompleteParams{Pattern: "db-%", Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, []repository.TagsAutocompleteRow{
+			{ID: matches[0].ID, Name: "db-go", PostCount: 2},
+			{ID: matches[1].ID, Name: "db-sql", PostCount: 1},
+		}, matches)
+
+		removed, err := repo.PostTagsRemove(ctx, repository.PostTagsRemoveParams{PostID: both.ID, Name: "db-sql"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), removed)
+		counts, err := repo.TagsGetCounts(ctx, 100)
+		assert.NoError(t, err)
+		assert.Contains(t, counts, repository.TagsGetCountsRow{ID: matches[1].ID, Name: "db-sql", PostCount: 0})
+	})
+
+	t.Run("Reactions", func(t *testing.T) {
+		author, err := repo.UsersCreate(ctx, repository.UsersCreateParams{Username: "reactions_author", Email: "reactions_author@test.com"})
+		assert.NoError(t, err)
+		reader, err := repo.UsersCreate(ctx, repository.UsersCreateParams{Username: "reactions_reader", Email: "reactions_reader@test.com"})
+		assert.NoError(t, err)
+		post, err := repo.PostsCreate(ctx, repository.PostsCreateParams{UserID: author.ID, Title: "Reacted", Content: "x"})
+		assert.NoError(t, err)
+
+		// Adding a reaction twice is a no-op
+		for _, params := range []repository.PostReactionsAddParams{
+			{PostID: post.ID, UserID: author.ID, Type: "like"},
+			{PostID: post.ID, UserID: reader.ID, Type: "like"},
+			{PostID: post.ID, UserID: reader.ID, Type: "like"},
+			{PostID: post.ID, UserID: reader.ID, Type: "wow"},
+		} {
+			assert.NoError(t, repo.PostReactionsAdd(ctx, params))
+		}
+		assert.Error(t, repo.PostReactionsAdd(ctx, repository.PostReactionsAddParams{PostID: post.ID, UserID: reader.ID, Type: "meh"}))
+
+		summary, err := repo.PostReactionsSummary(ctx, repository.PostReactionsSummaryParams{ViewerID: author.ID, PostID: post.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, []repository.PostReactionsSummaryRow{
+			{Type: "like", Count: 2, Mine: true},
+			{Type: "wow", Count: 1, Mine: false},
+		}, summary)
+
+		assert.NoError(t, repo.PostReactionsRemove(ctx, repository.PostReactionsRemoveParams{PostID: post.ID, UserID: reader.ID, Type: "wow"}))
+		assert.NoError(t, repo.PostReactionsRemove(ctx, repository.PostReactionsRemoveParams{PostID: post.ID, UserID: reader.ID, Type: "wow"}))
+		counts, err := repo.PostReactionsCountByPostIDs(ctx, []int64{post.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, []repository.PostReactionsCountByPostIDsRow{{PostID: post.ID, Type: "like", Count: 2}}, counts)
+
+		comment, err := repo.CommentsCreate(ctx, repository.CommentsCreateParams{PostID: post.ID, UserID: reader.ID, Comment: "Nice"})
+		assert.NoError(t, err)
+		assert.NoError(t, repo.CommentReactionsAdd(ctx, repository.CommentReactionsAddParams{CommentID: comment.ID, UserID: author.ID, Type: "love"}))
+
+		engagement, err := repo.PostsGetEngagementSince(ctx, sql.NullTime{Time: time.Now().Add(-time.Hour).UTC(), Valid: true})
+		assert.NoError(t, err)
+		var found bool
+		for _, row := range engagement {
+			if row.ID == post.ID {
+				found = true
+				assert.Equal(t, int64(2), row.ReactionCount)
+				assert.Equal(t, int64(1), row.CommentCount)
+			}
+		}
+		assert.True(t, found)
+
+		// Deleting the reader takes their reactions along
+		assert.NoError(t, repo.CommentsDeleteByUserID(ctx, reader.ID))
+		_, err = repo.UsersDeleteByID(ctx, reader.ID)
+		assert.NoError(t, err)
+		summary, err = repo.PostReactionsSummary(ctx, repository.PostReactionsSummaryParams{ViewerID: author.ID, PostID: post.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, []repository.PostReactionsSummaryRow{{Type: "like", Count: 1, Mine: true}}, summary)
+	})
+
+	t.Run("Comment threads", func(t *testing.T) {
+		user, err := repo.UsersCreate(ctx, repository.UsersCreateParams{Username: "threads_test", Email: "threads@test.com"})
+		assert.NoError(t, err)
+		other, err := repo.UsersCreate(ctx, repository.UsersCreateParams{Username: "threads_other", Email: "threads_other@test.com"})
+		assert.NoError(t, err)
+		post, err := repo.PostsCreate(ctx, repository.PostsCreateParams{UserID: other.ID, Title: "Threads", Content: "x"})
+		assert.NoError(t, err)
+		root, err := repo.CommentsCreate(ctx, repository.CommentsCreateParams{PostID: post.ID, UserID: user.ID, Comment: "Root"})
+		assert.NoError(t, err)
+		reply, err := repo.CommentsCreate(ctx, repository.CommentsCreateParams{
+			PostID:   post.ID,
+			UserID:   other.ID,
+			Comment:  "Reply",
+			ParentID: sql.NullInt64{Int64: root.ID, Valid: true},
+			Depth:    1,
+		})
+		assert.NoError(t, err)
+		nested, err := repo.CommentsCreate(ctx, repository.CommentsCreateParams{
+			PostID:   post.ID,
+			UserID:   other.ID,
+			Comment:  "Nested",
+			ParentID: sql.NullInt64{Int64: reply.ID, Valid: true},
+			Depth:    2,
+		})
+		assert.NoError(t, err)
+
+		assert.NoError(t, repo.CommentReportsAdd(ctx, repository.CommentReportsAddParams{CommentID: root.ID, UserID: user.ID, Reason: "spam"}))
+		assert.NoError(t, repo.CommentReportsAdd(ctx, repository.CommentReportsAddParams{CommentID: root.ID, UserID: user.ID, Reason: "twice"}))
+		queue, err := repo.CommentReportsGetQueue(ctx, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, queue, 1) {
+			assert.Equal(t, root.ID, queue[0].ID)
+			assert.Equal(t, int64(1), queue[0].ReportCount)
+			assert.Equal(t, "spam", queue[0].Reasons)
+		}
+
+		// Hiding settles the reports
+		hidden, err := repo.CommentsHide(ctx, root.ID)
+		assert.NoError(t, err)
+		assert.True(t, hidden.HiddenAt.Valid)
+		queue, err = repo.CommentReportsGetQueue(ctx, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, queue)
+		visible, err := repo.CommentsGetVisibleByPostID(ctx, post.ID)
+		assert.NoError(t, err)
+		assert.Len(t, visible, 2)
+
+		// Deleting the root takes the replies of the other user along, however deep, with their reports
+		assert.NoError(t, repo.CommentReportsAdd(ctx, repository.CommentReportsAddParams{CommentID: nested.ID, UserID: user.ID, Reason: "rude"}))
+		assert.NoError(t, repo.CommentsDeleteByUserID(ctx, user.ID))
+		all, err := repo.CommentsGetByPostID(ctx, post.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, all)
+		var reports int
+		assert.NoError(t, db.GetReadWriteDB().QueryRowContext(ctx, "SELECT COUNT(*) FROM comment_reports WHERE comment_id IN (?, ?, ?)", root.ID, reply.ID, nested.ID).Scan(&reports))
+		assert.Zero(t, reports)
+	})
+
+	t.Run("Follows", func(t *testing.T) {
+		var users []repository.User
+		for _, name := range []string{"follows_reader", "follows_a", "follows_b"} {
+			user, err := repo.UsersCreate(ctx, repository.UsersCreateParams{Username: name, Email: name + "@test.com"})
+			assert.NoError(t, err)
+			users = append(users, user)
+		}
+		reader, a, b := users[0], users[1], users[2]
+		for _, followee := range []repository.User{a, b, a} {
+			assert.NoError(t, repo.FollowsAdd(ctx, repository.FollowsAddParams{FollowerID: reader.ID, FolloweeID: followee.ID}))
+		}
+		assert.Error(t, repo.FollowsAdd(ctx, repository.FollowsAddParams{FollowerID: reader.ID, FolloweeID: reader.ID}))
+
+		following, err := repo.FollowsGetFollowing(ctx, repository.FollowsGetFollowingParams{UserID: reader.ID, Limit: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []repository.User{a}, following)
+		following, err = repo.FollowsGetFollowing(ctx, repository.FollowsGetFollowingParams{UserID: reader.ID, After: a.ID, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, []repository.User{b}, following)
+		followers, err := repo.FollowsGetFollowers(ctx, repository.FollowsGetFollowersParams{UserID: b.ID, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, []repository.User{reader}, followers)
+
+		// CURRENT_TIMESTAMP and the driver store published_at differently, the feed has to order both
+		old, err := repo.PostsCreate(ctx, repository.PostsCreateParams{UserID: a.ID, Title: "Old", Content: "x"})
+		assert.NoError(t, err)
+		_, err = repo.PostsCreateWithStatus(ctx, repository.PostsCreateWithStatusParams{UserID: b.ID, Title: "Draft", Content: "x", Status: "draft"})
+		assert.NoError(t, err)
+		_, err = repo.PostsCreate(ctx, repository.PostsCreateParams{UserID: reader.ID, Title: "Own", Content: "x"})
+		assert.NoError(t, err)
+		_, err = repo.PostsCreateWithStatus(ctx, repository.PostsCreateWithStatusParams{
+			UserID:      b.ID,
+			Title:       "New",
+			Content:     "x",
+			Status:      "published",
+			PublishedAt: sql.NullTime{Time: time.Now().Add(time.Minute).UTC().Truncate(time.Second), Valid: true},
+		})
+		assert.NoError(t, err)
+
+		feed, err := repo.PostsGetFeed(ctx, repository.PostsGetFeedParams{Before: "9999-12-31", BeforeID: 1 << 62, FollowerID: reader.ID, Limit: 1})
+		assert.NoError(t, err)
+		if assert.Len(t, feed, 1) {
+			assert.Equal(t, "New", feed[0].Title)
+			feed, err = repo.PostsGetFeed(ctx, repository.PostsGetFeedParams{Before: feed[0].PublishedAtKey, BeforeID: feed[0].ID, FollowerID: reader.ID, Limit: 10})
+			assert.NoError(t, err)
+			if assert.Len(t, feed, 1) {
+				assert.Equal(t, old.ID, feed[0].ID)
+			}
+		}
+
+		assert.NoError(t, repo.FollowsRemove(ctx, repository.FollowsRemoveParams{FollowerID: reader.ID, FolloweeID: a.ID}))
+		_, err = repo.UsersDeleteByID(ctx, reader.ID)
+		assert.NoError(t, err)
+		followers, err = repo.FollowsGetFollowers(ctx, repository.FollowsGetFollowersParams{UserID: b.ID, Limit: 10})
+		assert.NoError(t, err)
+		assert.Empty(t, followers)
+	})
+
+	t.Run("Notifications", func(t *testing.T) {
+		user, err := repo.UsersCreate(ctx, repository.UsersCreateParams{Username: "notifications_user", Email: "notifications_user@test.com"})
+		assert.NoError(t, err)
+		actor, err := repo.UsersCreate(ctx, repository.UsersCreateParams{Username: "notifications_actor", Email: "notifications_actor@test.com"})
+		assert.NoError(t, err)
+		post, err := repo.PostsCreate(ctx, repository.PostsCreateParams{UserID: user.ID, Title: "Notified", Content: "x"})
+		assert.NoError(t, err)
+
+		follow := repository.NotificationsCreateParams{UserID: user.ID, ActorID: actor.ID, Type: "follow"}
+		_, err = repo.NotificationsCreate(ctx, follow)
+		assert.NoError(t, err)
+		_, err = repo.NotificationsCreate(ctx, follow)
+		assert.Equal(t, sql.ErrNoRows, err)
+		for i := 0; i < 2; i++ {
+			comment := repository.NotificationsCreateParams{
+				UserID:    user.ID,
+				ActorID:   actor.ID,
+				Type:      "comment",
+				PostID:    sql.NullInt64{Int64: post.ID, Valid: true},
+				CommentID: sql.NullInt64{Int64: int64(i + 1), Valid: true},
+			}
+			_, err = repo.NotificationsCreate(ctx, comment)
+			assert.NoError(t, err)
+			// The same comment event delivered again
+			_, err = repo.NotificationsCreate(ctx, comment)
+			assert.Equal(t, sql.ErrNoRows, err)
+		}
+
+		notifications, err := repo.NotificationsGetByUserID(ctx, repository.NotificationsGetByUserIDParams{UserID: user.ID, Before: 1 << 62, Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, notifications, 3) {
+			assert.Equal(t, "follow", notifications[2].Type)
+			marked, err := repo.NotificationsMarkRead(ctx, repository.NotificationsMarkReadParams{ID: notifications[2].ID, UserID: actor.ID})
+			assert.NoError(t, err)
+			assert.Zero(t, marked)
+			marked, err = repo.NotificationsMarkRead(ctx, repository.NotificationsMarkReadParams{ID: notifications[2].ID, UserID: user.ID})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(1), marked)
+		}
+		counts, err := repo.NotificationsCountUnread(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, []repository.NotificationsCountUnreadRow{{Type: "comment", Count: 2}}, counts)
+		unread, err := repo.NotificationsGetByUserID(ctx, repository.NotificationsGetByUserIDParams{UserID: user.ID, Before: 1 << 62, UnreadOnly: true, Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, unread, 2)
+
+		marked, err := repo.NotificationsMarkAllRead(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), marked)
+
+		// They go with the post they are about, and with the user who acted
+		assert.NoError(t, repo.PostsDeleteByUserID(ctx, user.ID))
+		notifications, err = repo.NotificationsGetByUserID(ctx, repository.NotificationsGetByUserIDParams{UserID: user.ID, Before: 1 << 62, Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, notifications, 1)
+		_, err = repo.UsersDeleteByID(ctx, actor.ID)
+		assert.NoError(t, err)
+		notifications, err = repo.NotificationsGetByUserID(ctx, repository.NotificationsGetByUserIDParams{UserID: user.ID, Before: 1 << 62, Limit: 10})
+		assert.NoError(t, err)
+		assert.Empty(t, notifications)
+	})
+
+	t.Run("Webhooks", func(t *testing.T) {
+		posts, err := repo.WebhooksCreate(ctx, repository.WebhooksCreateParams{Url: "https://example.com/posts", Secret: "s", Events: "post.created,post.updated"})
+		assert.NoError(t, err)
+		assert.True(t, posts.Active)
+		all, err := repo.WebhooksCreate(ctx, repository.WebhooksCreateParams{Url: "https://example.com/all", Secret: "s", Events: "*"})
+		assert.NoError(t, err)
+
+		event := repository.WebhookDeliveriesEnqueueParams{EventID: sql.NullInt64{Int64: 1, Valid: true}, Event: "post.created", Payload: "{}"}
+		queued, err := repo.WebhookDeliveriesEnqueue(ctx, event)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), queued)
+		// The same outbox event handed over again
+		queued, err = repo.WebhookDeliveriesEnqueue(ctx, event)
+		assert.NoError(t, err)
+		assert.Zero(t, queued)
+		queued, err = repo.WebhookDeliveriesEnqueue(ctx, repository.WebhookDeliveriesEnqueueParams{Event: "post", Payload: "{}"})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), queued)
+
+		// Inactive webhooks get nothing new and nothing sent
+		_, err = repo.WebhooksUpdateByID(ctx, repository.WebhooksUpdateByIDParams{Url: all.Url, Events: all.Events, Active: false, ID: all.ID})
+		assert.NoError(t, err)
+		queued, err = repo.WebhookDeliveriesEnqueue(ctx, repository.WebhookDeliveriesEnqueueParams{Event: "user.created", Payload: "{}"})
+		assert.NoError(t, err)
+		assert.Zero(t, queued)
+
+		now := sql.NullTime{Time: time.Now().UTC().Add(time.Minute), Valid: true}
+		due, err := repo.WebhookDeliveriesGetDue(ctx, repository.WebhookDeliveriesGetDueParams{Now: now, Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, due, 1) {
+			assert.Equal(t, posts.Url, due[0].Url)
+			assert.Equal(t, event.EventID, due[0].EventID)
+			assert.NoError(t, repo.WebhookDeliveriesRecordAttempt(ctx, repository.WebhookDeliveriesRecordAttemptParams{
+				Status:         "succeeded",
+				Attempts:       1,
+				NextAttemptAt:  due[0].NextAttemptAt,
+				LastAttemptAt:  now,
+				ResponseStatus: sql.NullInt64{Int64: 200, Valid: true},
+				ID:             due[0].ID,
+			}))
+			redelivery, err := repo.WebhookDeliveriesRedeliver(ctx, due[0].ID)
+			assert.NoError(t, err)
+			assert.Equal(t, "pending", redelivery.Status)
+			assert.Zero(t, redelivery.Attempts)
+			assert.Equal(t, event.EventID, redelivery.EventID)
+			assert.Equal(t, sql.NullInt64{Int64: due[0].ID, Valid: true}, redelivery.RedeliveryOf)
+		}
+		due, err = repo.WebhookDeliveriesGetDue(ctx, repository.WebhookDeliveriesGetDueParams{Now: now, Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, due, 1)
+
+		deliveries, err := repo.WebhookDeliveriesGetByWebhookID(ctx, repository.WebhookDeliveriesGetByWebhookIDParams{WebhookID: posts.ID, Before: 1 << 62, Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, deliveries, 2) {
+			assert.Equal(t, "pending", deliveries[0].Status)
+			assert.Equal(t, "succeeded", deliveries[1].Status)
+		}
+
+		// Deliveries go with their webhook
+		deleted, err := repo.WebhooksDeleteByID(ctx, posts.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+		deliveries, err = repo.WebhookDeliveriesGetByWebhookID(ctx, repository.WebhookDeliveriesGetByWebhookIDParams{WebhookID: posts.ID, Before: 1 << 62, Limit: 10})
+		assert.NoError(t, err)
+		assert.Empty(t, deliveries)
+		_, err = repo.WebhooksDeleteByID(ctx, all.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Outbox", func(t *testing.T) {
+		now := sql.NullTime{Time: time.Now().UTC().Add(time.Minute), Valid: true}
+		// Events other tests left behind
+		for _, event := range mustDue(t, repo, now) {
+			assert.NoError(t, repo.OutboxMarkDispatched(ctx, repository.OutboxMarkDispatchedParams{DispatchedAt: now, ID: event.ID}))
+		}
+
+		for _, params := range []repository.OutboxAppendParams{
+			{AggregateType: "post", AggregateID: 1, Type: "post.created", Payload: "{}"},
+			{AggregateType: "post", AggregateID: 1, Type: "post.updated", Payload: "{}"},
+			{AggregateType: "post", AggregateID: 2, Type: "post.created", Payload: "{}"},
+		} {
+			assert.NoError(t, repo.OutboxAppend(ctx, params))
+		}
+
+		// Only the oldest event of each aggregate
+		due := mustDue(t, repo, now)
+		if assert.Len(t, due, 2) {
+			assert.Equal(t, "post.created", due[0].Type)
+			assert.Equal(t, int64(1), due[0].AggregateID)
+			assert.Equal(t, int64(2), due[1].AggregateID)
+			assert.False(t, due[0].PendingSubscribers.Valid)
+
+			assert.NoError(t, repo.OutboxRecordFailure(ctx, repository.OutboxRecordFailureParams{
+				Attempts:           1,
+				PendingSubscribers: sql.NullString{String: "webhooks", Valid: true},
+				NextAttemptAt:      sql.NullTime{Time: now.Time.Add(time.Hour), Valid: true},
+				LastError:          sql.NullString{String: "webhooks: boom", Valid: true},
+				ID:                 due[0].ID,
+			}))
+			assert.NoError(t, repo.OutboxMarkDispatched(ctx, repository.OutboxMarkDispatchedParams{DispatchedAt: now, Attempts: 1, ID: due[1].ID}))
+		}
+		// The update waits for the retry of the creation
+		assert.Empty(t, mustDue(t, repo, now))
+		due = mustDue(t, repo, sql.NullTime{Time: now.Time.Add(time.Hour), Valid: true})
+		if assert.Len(t, due, 1) {
+			assert.Equal(t, "webhooks", due[0].PendingSubscribers.String)
+			assert.NoError(t, repo.OutboxDeadLettersCreate(ctx, repository.OutboxDeadLettersCreateParams{
+				EventID:       due[0].ID,
+				AggregateType: due[0].AggregateType,
+				AggregateID:   due[0].AggregateID,
+				Type:          due[0].Type,
+				Payload:       due[0].Payload,
+				Subscriber:    "webhooks",
+				Attempts:      2,
+				Error:         "boom",
+			}))
+			assert.NoError(t, repo.OutboxMarkDispatched(ctx, repository.OutboxMarkDispatchedParams{DispatchedAt: now, Attempts: 2, ID: due[0].ID}))
+		}
+		due = mustDue(t, repo, now)
+		if assert.Len(t, due, 1) {
+			assert.Equal(t, "post.updated", due[0].Type)
+			assert.NoError(t, repo.OutboxMarkDispatched(ctx, repository.OutboxMarkDispatchedParams{DispatchedAt: now, Attempts: 1, ID: due[0].ID}))
+		}
+
+		deadLetters, err := repo.OutboxDeadLettersGetAll(ctx, repository.OutboxDeadLettersGetAllParams{Before: 1 << 62, Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, deadLetters, 1) {
+			assert.Equal(t, "webhooks", deadLetters[0].Subscriber)
+			assert.Equal(t, "post.created", deadLetters[0].Type)
+		}
+
+		deleted, err := repo.OutboxDeleteDispatched(ctx, sql.NullTime{Time: now.Time.Add(time.Second), Valid: true})
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, deleted, int64(3))
+	})
+
+	t.Run("Jobs", func(t *testing.T) {
+		now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+		at := func(d time.Duration) sql.NullTime { return sql.NullTime{Time: now.Add(d), Valid: true} }
+		enqueue := func(kind string, priority int64, uniqueKey string, runAt sql.NullTime) (repository.Job, error) {
+			return repo.JobsEnqueue(ctx, repository.JobsEnqueueParams{
+				Kind:        kind,
+				Payload:     "{}",
+				Priority:    priority,
+				UniqueKey:   sql.NullString{String: uniqueKey, Valid: uniqueKey != ""},
+				MaxAttempts: 3,
+				RunAt:       runAt,
+			})
+		}
+		claim := func(worker string, d time.Duration) (repository.Job, error) {
+			return repo.JobsClaim(ctx, repository.JobsClaimParams{
+				LockedBy:    sql.NullString{String: worker, Valid: true},
+				LockedUntil: at(d + time.Minute),
+				Now:         at(d),
+			})
+		}
+
+		low, err := enqueue("low", 0, "", at(-time.Minute))
+		assert.NoError(t, err)
+		high, err := enqueue("high", 5, "unique", at(0))
+		assert.NoError(t, err)
+		_, err = enqueue("later", 9, "", at(time.Hour))
+		assert.NoError(t, err)
+		_, err = enqueue("high", 5, "unique", at(0))
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		// The highest priority first, not the ones due later
+		claimed, err := claim("a", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, high.ID, claimed.ID)
+		assert.Equal(t, "running", claimed.Status)
+		assert.Equal(t, int64(1), claimed.Attempts)
+		claimed, err = claim("b", 0)
+		assert.NoError(t, err)
+		assert.Equal(t, low.ID, claimed.ID)
+		_, err = claim("c", 0)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+
+		// Only the holder of the lease renews it and records the result
+		extended, err := repo.JobsExtendLease(ctx, repository.JobsExtendLeaseParams{LockedUntil: at(2 * time.Minute), ID: high.ID, LockedBy: sql.NullString{String: "b", Valid: true}})
+		assert.NoError(t, err)
+		assert.Zero(t, extended)
+		extended, err = repo.JobsExtendLease(ctx, repository.JobsExtendLeaseParams{LockedUntil: at(2 * time.Minute), ID: high.ID, LockedBy: sql.NullString{String: "a", Valid: true}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), extended)
+
+		// The expired lease of low is taken over
+		claimed, err = claim("c", 90*time.Second)
+		assert.NoError(t, err)
+		assert.Equal(t, low.ID, claimed.ID)
+		assert.Equal(t, int64(2), claimed.Attempts)
+		result := repository.JobsRecordResultParams{Status: "succeeded", Attempts: 2, RunAt: claimed.RunAt, UpdatedAt: at(time.Hour), FinishedAt: at(time.Hour), ID: low.ID, LockedBy: sql.NullString{String: "b", Valid: true}}
+		recorded, err := repo.JobsRecordResult(ctx, result)
+		assert.NoError(t, err)
+		assert.Zero(t, recorded)
+		result.LockedBy.String = "c"
+		recorded, err = repo.JobsRecordResult(ctx, result)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), recorded)
+
+		// Cancelled while running, the unique key is free again
+		cancelled, err := repo.JobsCancel(ctx, repository.JobsCancelParams{Now: at(time.Hour), ID: high.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, "cancelled", cancelled.Status)
+		assert.False(t, cancelled.LockedBy.Valid)
+		_, err = repo.JobsCancel(ctx, repository.JobsCancelParams{Now: at(time.Hour), ID: high.ID})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		again, err := enqueue("high", 5, "unique", at(0))
+		assert.NoError(t, err)
+		_, err = repo.JobsRetry(ctx, repository.JobsRetryParams{Now: at(time.Hour), ID: high.ID})
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+		_, err = repo.JobsCancel(ctx, repository.JobsCancelParams{Now: at(time.Hour), ID: again.ID})
+		assert.NoError(t, err)
+		retried, err := repo.JobsRetry(ctx, repository.JobsRetryParams{Now: at(time.Hour), ID: high.ID})
+		assert.NoError(t, err)
+		assert.Equal(t, "pending", retried.Status)
+		assert.Zero(t, retried.Attempts)
+		assert.False(t, retried.FinishedAt.Valid)
+
+		jobs, err := repo.JobsGetAll(ctx, repository.JobsGetAllParams{Status: "cancelled", Before: 1 << 62, Limit: 10})
+		assert.NoError(t, err)
+		if assert.Len(t, jobs, 1) {
+			assert.Equal(t, again.ID, jobs[0].ID)
+		}
+		jobs, err = repo.JobsGetAll(ctx, repository.JobsGetAllParams{Kind: "high", Before: 1 << 62, Limit: 10})
+		assert.NoError(t, err)
+		assert.Len(t, jobs, 2)
+
+		deleted, err := repo.JobsDeleteFinished(ctx, at(2*time.Hour))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), deleted)
+	})
+}
+
+func mustDue(t *testing.T, repo *repository.Queries, now sql.NullTime) []repository.Outbox {
+	due, err := repo.OutboxGetDue(context.Background(), repository.OutboxGetDueParams{Now: now, Limit: 100})
+	assert.NoError(t, err)
+	return due
+}
+
+func TestWithTx(t *testing.T) {
+	db := New("file:memory:?mode=memory&cache=shared")
+	ctx := context.Background()
+
+	t.Run("Commit on success", func(t *testing.T) {
+		err := db.WithTx(ctx, func(q *repository.Queries) error {
+			user, err := q.UsersCreate(ctx, repository.UsersCreateParams{Username: "tx_commit", Email: "tx_commit@test.com"})
+			if err != nil {
+				return err
+			}
+			_, err = q.PostsCreate(ctx, repository.PostsCreateParams{Title: "First post", Content: "Hello", UserID: user.ID})
+			return err
+		})
+		assert.NoError(t, err)
+
+		user, err := db.GetRepositoryRW().UsersGetByUsername(ctx, "tx_commit")
+		assert.NoError(t, err)
+		posts, err := db.GetRepositoryRW().PostsGetByUserID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Len(t, posts, 1)
+	})
+
+	t.Run("Rollback on error", func(t *testing.T) {
+		err := db.WithTx(ctx, func(q *repository.Queries) error {
+			if _, err := q.UsersCreate(ctx, repository.UsersCreateParams{Username: "tx_rollback", Email: "tx_rollback@test.com"}); err != nil {
+				return err
+			}
+			return errors.New("boom")
+		})
+		assert.EqualError(t, err, "boom")
+
+		_, err = db.GetRepositoryRW().UsersGetByUsername(ctx, "tx_rollback")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Nested savepoint rollback keeps outer work", func(t *testing.T) {
+		err := db.WithTxContext(ctx, func(txCtx context.Context, q *repository.Queries) error {
+			if _, err := q.UsersCreate(txCtx, repository.UsersCreateParams{Username: "tx_outer", Email: "tx_outer@test.com"}); err != nil {
+				return err
+			}
+			innerErr := db.WithTx(txCtx, func(q *repository.Queries) error {
+				if _, err := q.UsersCreate(txCtx, repository.UsersCreateParams{Username: "tx_inner", Email: "tx_inner@test.com"}); err != nil {
+					return err
+				}
+				return errors.New("inner failed")
+			})
+			assert.EqualError(t, innerErr, "inner failed")
+			return nil
+		})
+		assert.NoError(t, err)
+
+		_, err = db.GetRepositoryRW().UsersGetByUsername(ctx, "tx_outer")
+		assert.NoError(t, err)
+		_, err = db.GetRepositoryRW().UsersGetByUsername(ctx, "tx_inner")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("Cancelled context does not commit", func(t *testing.T) {
+		cancelCtx, cancel := context.WithCancel(ctx)
+		err := db.WithTx(cancelCtx, func(q *repository.Queries) error {
+			_, err := q.UsersCreate(cancelCtx, repository.UsersCreateParams{Username: "tx_cancel", Email: "tx_cancel@test.com"})
+			cancel()
+			return err
+		})
+		assert.ErrorIs(t, err, context.Canceled)
+
+		_, err = db.GetRepositoryRW().UsersGetByUsername(ctx, "tx_cancel")
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestBackupAndRestore(t *testing.T) {
+	db := New("file:memory:?mode=memory&cache=shared")
+	ctx := context.Background()
+
+	_, err := db.GetRepositoryRW().UsersCreate(ctx, repository.UsersCreateParams{Username: "backup_user", Email: "backup@test.com"})
+	assert.NoError(t, err)
+
+	cfg := BackupConfig{Dir: t.TempDir(), Keep: 1, Gzip: true}
+
+	first, err := db.Backup(ctx, cfg)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(first, ".db.gz"))
+
+	second, err := db.Backup(ctx, cfg)
+	assert.NoError(t, err)
+
+	// Only the newest snapshot is kept
+	_, err = os.Stat(first)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(second)
+	assert.NoError(t, err)
+
+	target := filepath.Join(t.TempDir(), "restored.db")
+	assert.NoError(t, os.WriteFile(target, []byte("old database"), 0644))
+	assert.NoError(t, Restore(ctx, second, target))
+
+	restored, err := sql.Open("sqlite", "file:"+target)
+	assert.NoError(t, err)
+	defer restored.Close()
+
+	user, err := repository.New(restored).UsersGetByUsername(ctx, "backup_user")
+	assert.NoError(t, err)
+	assert.Equal(t, "backup@test.com", user.Email)
+
+	// A file that is not a goose managed database is refused
+	bogus := filepath.Join(t.TempDir(), "bogus.db")
+	assert.NoError(t, os.WriteFile(bogus, nil, 0644))
+	assert.Error(t, Restore(ctx, bogus, target))
+
+	// When the wal file can't be moved away the main file is moved back
+	dir := t.TempDir()
+	current := filepath.Join(dir, "data.db")
+	assert.NoError(t, os.WriteFile(current, []byte("database"), 0644))
+	assert.NoError(t, os.WriteFile(current+"-wal", []byte("wal"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "old.db-wal", "blocked"), 0755))
+	_, err = moveDatabase(current, filepath.Join(dir, "old.db"))
+	assert.Error(t, err)
+	_, err = os.Stat(current)
+	assert.NoError(t, err)
+	_, err = os.Stat(current + "-wal")
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, "old.db"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestSchemaVersion(t *testing.T) {
+	db := New("file:memory:?mode=memory&cache=shared")
+
+	current, latest, err := SchemaVersion(context.Background(), db.GetReadWriteDB())
+	assert.NoError(t, err)
+	assert.NotZero(t, latest)
+	assert.Equal(t, latest, current, "New should migrate the database to the newest version")
+	assert.NoError(t, CheckSchema(context.Background(), db.GetReadWriteDB()))
+
+	// A database behind the embedded migrations is refused
+	url := "file:" + filepath.Join(t.TempDir(), "behind.db")
+	assert.NoError(t, Migrate(context.Background(), url, "up-to", "1"))
+	behind, err := sql.Open("sqlite", url)
+	assert.NoError(t, err)
+	defer behind.Close()
+	assert.ErrorContains(t, CheckSchema(context.Background(), behind), "database schema is at version 1")
+
+	// So is one New failed to migrate
+	migrateErr = errors.New("apply migrations: boom")
+	assert.EqualError(t, CheckSchema(context.Background(), db.GetReadWriteDB()), "apply migrations: boom")
+	migrateErr = nil
+
+	// Reading the version of an empty database leaves it empty
+	empty, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "empty.db"))
+	assert.NoError(t, err)
+	defer empty.Close()
+	current, _, err = SchemaVersion(context.Background(), empty)
+	assert.NoError(t, err)
+	assert.Zero(t, current)
+	var tables int
+	assert.NoError(t, empty.QueryRow("SELECT COUNT(*) FROM sqlite_master").Scan(&tables))
+	assert.Zero(t, tables)
 }
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"backendT/internal/health"
+)
+
+// Thresholds above/below which the wal and disk checks report the database as degraded.
+const (
+	walWarnSize     = 64 << 20
+	minFreeDiskSize = 100 << 20
+)
+
+// RegisterHealthChecks adds the database checks (both pools, schema version, wal size and free disk space) to r.
+func (s *service) RegisterHealthChecks(r *health.Registry) {
+	r.Register("db_ro", true, poolCheck(s.dbro))
+	r.Register("db_rw", true, poolCheck(s.dbrw))
+	r.Register("migrations", true, s.migrationsCheck)
+	r.Register("wal", false, s.walCheck)
+	r.Register("disk", false, s.diskCheck)
+}
+
+func poolCheck(db *sql.DB) health.CheckFunc {
+	return func(ctx context.Context) health.Result {
+		dbStats := db.Stats()
+		details := map[string]string{
+			"open_connections": strconv.Itoa(dbStats.OpenConnections),
+			"in_use":           strconv.Itoa(dbStats.InUse),
+			"idle":             strconv.Itoa(dbStats.Idle),
+			"wait_count":       strconv.FormatInt(dbStats.WaitCount, 10),
+			"wait_duration":    dbStats.WaitDuration.String(),
+		}
+		if err := db.PingContext(ctx); err != nil {
+			return health.Down(details, err)
+		}
+		return health.Up(details)
+	}
+}
+
+func (s *service) migrationsCheck(ctx context.Context) health.Result {
+	current, latest, err := SchemaVersion(ctx, s.dbro)
+	if err != nil {
+		return health.Down(nil, err)
+	}
+
+	details := map[string]string{
+		"current": strconv.FormatInt(current, 10),
+		"latest":  strconv.FormatInt(latest, 10),
+	}
+	switch {
+	case current < latest:
+		return health.Down(details, fmt.Errorf("schema is behind the embedded migrations"))
+	case current > latest:
+		return health.Degraded(details, fmt.Errorf("schema is newer than the embedded migrations"))
+	}
+	return health.Up(details)
+}
+
+func (s *service) walCheck(ctx context.Context) health.Result {
+	path := filePathFromURL(dburl)
+	info, err := os.Stat(path + "-wal")
+	if errors.Is(err, os.ErrNotExist) {
+		// In memory databases and freshly checkpointed ones have no wal file
+		return health.Up(map[string]string{"size": "0"})
+	}
+	if err != nil {
+		return health.Degraded(nil, err)
+	}
+
+	details := map[string]string{"size": strconv.FormatInt(info.Size(), 10)}
+	if info.Size() > walWarnSize {
+		return health.Degraded(details, fmt.Errorf("wal file is larger than %d bytes, checkpoints may be starved", walWarnSize))
+	}
+	return health.Up(details)
+}
+
+func (s *service) diskCheck(ctx context.Context) health.Result {
+	free, err := freeDiskSpace(filepath.Dir(filePathFromURL(dburl)))
+	if errors.Is(err, errors.ErrUnsupported) {
+		return health.Up(map[string]string{"free": "unknown"})
+	}
+	if err != nil {
+		return health.Degraded(nil, err)
+	}
+
+	details := map[string]string{"free": strconv.FormatUint(free, 10)}
+	if free < minFreeDiskSize {
+		return health.Degraded(details, fmt.Errorf("less than %d bytes of free disk space", minFreeDiskSize))
+	}
+	return health.Up(details)
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"sync"
+
+	"github.com/pressly/goose/v3"
+)
+
+// MigrationsSourceDir is where new migrations are created, relative to the repository root.
+const MigrationsSourceDir = "internal/database/migrations"
+
+// Migrate runs a goose command (up, up-to, down, down-to, redo, status, version) with the
+// embedded migrations against the database at dbURL, without starting the service.
+func Migrate(ctx context.Context, dbURL, command string, args ...string) error {
+	db, err := sql.Open("sqlite", dbURL)
+	if err != nil {
+		return fmt.Errorf("open database: %w", err)
+	}
+	defer db.Close()
+
+	setupGoose()
+	return goose.RunContext(ctx, command, db, "migrations", args...)
+}
+
+// CreateMigration writes a new, sequentially numbered, empty sql migration into dir.
+func CreateMigration(dir, name string) error {
+	goose.SetSequential(true)
+	return goose.Create(nil, dir, name, "sql")
+}
+
+// SchemaVersion returns the goose version of the database and the newest embedded migration version. It only
+// reads: a database goose never ran on is at version 0, and goose's settings are left alone, so it is safe
+// to call from health checks.
+func SchemaVersion(ctx context.Context, db *sql.DB) (current, latest int64, err error) {
+	current, err = dbVersion(ctx, db)
+	if err != nil {
+		return 0, 0, fmt.Errorf("read goose version: %w", err)
+	}
+	latest, err = latestMigrationVersion()
+	if err != nil {
+		return 0, 0, err
+	}
+	return current, latest, nil
+}
+
+// dbVersion reads the version goose recorded in goose_db_version, that of the newest migration whose last
+// record is an apply rather than a rollback, as goose itself does.
+func dbVersion(ctx context.Context, db *sql.DB) (int64, error) {
+	var exists bool
+	err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'goose_db_version')").Scan(&exists)
+	if err != nil || !exists {
+		return 0, err
+	}
+
+	var version int64
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version AS v
+WHERE is_applied AND id = (SELECT MAX(id) FROM goose_db_version WHERE version_id = v.version_id)`).Scan(&version)
+	return version, err
+}
+
+// latestMigrationVersion returns the version of the newest embedded migration, read from the file names.
+var latestMigrationVersion = sync.OnceValues(func() (int64, error) {
+	entries, err := fs.ReadDir(embedMigrations, "migrations")
+	if err != nil {
+		return 0, fmt.Errorf("read migrations: %w", err)
+	}
+	var latest int64
+	for _, entry := range entries {
+		version, err := goose.NumericComponent(entry.Name())
+		if err != nil {
+			return 0, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		latest = max(latest, version)
+	}
+	if latest == 0 {
+		return 0, fmt.Errorf("no embedded migrations")
+	}
+	return latest, nil
+})
+
+// autoMigrate makes New apply the pending migrations, see SetAutoMigrate.
+var autoMigrate = true
+
+// migrateErr is why New couldn't apply the pending migrations, CheckSchema reports it.
+var migrateErr error
+
+// SetAutoMigrate sets whether New applies the pending migrations, as it does by default. When it doesn't the
+// schema is left as it is, CheckSchema tells whether it is up to date. Set it before the first New.
+func SetAutoMigrate(enabled bool) {
+	autoMigrate = enabled
+}
+
+// CheckSchema returns an error when the database is behind the embedded migrations, or when New failed to
+// apply them.
+func CheckSchema(ctx context.Context, db *sql.DB) error {
+	if migrateErr != nil {
+		return migrateErr
+	}
+	current, latest, err := SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current < latest {
+		return fmt.Errorf("database schema is at version %d but %d is required, run \"main migrate up\" or start with -auto-migrate", current, latest)
+	}
+	return nil
+}
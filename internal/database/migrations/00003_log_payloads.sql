@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE log_payloads (
+    log_id INTEGER PRIMARY KEY,
+    request_headers TEXT,
+    request_body TEXT,
+    request_body_truncated BOOLEAN NOT NULL DEFAULT 0,
+    response_headers TEXT,
+    response_body TEXT,
+    response_body_truncated BOOLEAN NOT NULL DEFAULT 0,
+    FOREIGN KEY (log_id) REFERENCES logs(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS log_payloads;
+-- +goose StatementEnd
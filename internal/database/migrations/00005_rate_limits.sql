@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE rate_limits (
+    key TEXT PRIMARY KEY,
+    tokens REAL NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+CREATE INDEX idx_rate_limits_updated_at ON rate_limits(updated_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_rate_limits_updated_at;
+DROP TABLE IF EXISTS rate_limits;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- sqlite can't add a column defaulting to CURRENT_TIMESTAMP, the queries set it instead
+ALTER TABLE users ADD COLUMN updated_at TIMESTAMP;
+UPDATE users SET updated_at = created_at;
+
+ALTER TABLE posts ADD COLUMN updated_at TIMESTAMP;
+UPDATE posts SET updated_at = created_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE posts DROP COLUMN updated_at;
+ALTER TABLE users DROP COLUMN updated_at;
+-- +goose StatementEnd
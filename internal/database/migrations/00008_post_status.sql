@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Existing posts went live when they were created
+ALTER TABLE posts ADD COLUMN status TEXT NOT NULL DEFAULT 'published' CHECK (status IN ('draft', 'scheduled', 'published', 'archived'));
+-- When the post went live, or is due to for scheduled posts
+ALTER TABLE posts ADD COLUMN published_at TIMESTAMP;
+UPDATE posts SET published_at = created_at;
+
+CREATE INDEX idx_posts_status_published_at ON posts(status, published_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_posts_status_published_at;
+ALTER TABLE posts DROP COLUMN published_at;
+ALTER TABLE posts DROP COLUMN status;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every title and content a post ever had, numbered from 1 per post
+CREATE TABLE post_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (post_id) REFERENCES posts(id),
+    UNIQUE (post_id, revision)
+);
+
+INSERT INTO post_revisions (post_id, revision, title, content, created_at)
+SELECT id, 1, title, content, COALESCE(updated_at, created_at) FROM posts;
+
+-- Triggers record the revisions, so no write to posts can skip them
+CREATE TRIGGER posts_revision_insert AFTER INSERT ON posts
+BEGIN
+    INSERT INTO post_revisions (post_id, revision, title, content)
+    VALUES (NEW.id, 1, NEW.title, NEW.content);
+END;
+
+CREATE TRIGGER posts_revision_update AFTER UPDATE OF title, content ON posts
+WHEN OLD.title IS NOT NEW.title OR OLD.content IS NOT NEW.content
+BEGIN
+    INSERT INTO post_revisions (post_id, revision, title, content)
+    VALUES (
+        NEW.id,
+        (SELECT COALESCE(MAX(revision), 0) + 1 FROM post_revisions WHERE post_id = NEW.id),
+        NEW.title,
+        NEW.content
+    );
+END;
+
+CREATE TRIGGER posts_revision_delete AFTER DELETE ON posts
+BEGIN
+    DELETE FROM post_revisions WHERE post_id = OLD.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS posts_revision_delete;
+DROP TRIGGER IF EXISTS posts_revision_update;
+DROP TRIGGER IF EXISTS posts_revision_insert;
+DROP TABLE IF EXISTS post_revisions;
+-- +goose StatementEnd
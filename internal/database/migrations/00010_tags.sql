@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tags (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- Lowercase letters, digits and dashes, see posts.NormalizeTag
+    name TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE post_tags (
+    post_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, tag_id),
+    FOREIGN KEY (post_id) REFERENCES posts(id),
+    FOREIGN KEY (tag_id) REFERENCES tags(id)
+);
+
+CREATE INDEX idx_post_tags_tag_id ON post_tags(tag_id);
+
+CREATE TRIGGER posts_tags_delete AFTER DELETE ON posts
+BEGIN
+    DELETE FROM post_tags WHERE post_id = OLD.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS posts_tags_delete;
+DROP INDEX IF EXISTS idx_post_tags_tag_id;
+DROP TABLE IF EXISTS post_tags;
+DROP TABLE IF EXISTS tags;
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A user can leave every reaction type once per post or comment
+CREATE TABLE post_reactions (
+    post_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('like', 'love', 'laugh', 'wow', 'sad', 'angry')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (post_id, user_id, type),
+    FOREIGN KEY (post_id) REFERENCES posts(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE comment_reactions (
+    comment_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('like', 'love', 'laugh', 'wow', 'sad', 'angry')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (comment_id, user_id, type),
+    FOREIGN KEY (comment_id) REFERENCES comments(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_post_reactions_user_id ON post_reactions(user_id);
+CREATE INDEX idx_comment_reactions_user_id ON comment_reactions(user_id);
+
+CREATE TRIGGER posts_reactions_delete AFTER DELETE ON posts
+BEGIN
+    DELETE FROM post_reactions WHERE post_id = OLD.id;
+END;
+
+CREATE TRIGGER comments_reactions_delete AFTER DELETE ON comments
+BEGIN
+    DELETE FROM comment_reactions WHERE comment_id = OLD.id;
+END;
+
+CREATE TRIGGER users_reactions_delete AFTER DELETE ON users
+BEGIN
+    DELETE FROM post_reactions WHERE user_id = OLD.id;
+    DELETE FROM comment_reactions WHERE user_id = OLD.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS users_reactions_delete;
+DROP TRIGGER IF EXISTS comments_reactions_delete;
+DROP TRIGGER IF EXISTS posts_reactions_delete;
+DROP INDEX IF EXISTS idx_comment_reactions_user_id;
+DROP INDEX IF EXISTS idx_post_reactions_user_id;
+DROP TABLE IF EXISTS comment_reactions;
+DROP TABLE IF EXISTS post_reactions;
+-- +goose StatementEnd
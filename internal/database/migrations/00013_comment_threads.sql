@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Replies point to the comment they answer, depth is 0 for top level comments
+ALTER TABLE comments ADD COLUMN parent_id INTEGER;
+ALTER TABLE comments ADD COLUMN depth INTEGER NOT NULL DEFAULT 0;
+-- Set by moderators, hidden comments and their replies are left out of public responses
+ALTER TABLE comments ADD COLUMN hidden_at TIMESTAMP;
+
+CREATE INDEX idx_comments_post_id ON comments(post_id);
+CREATE INDEX idx_comments_parent_id ON comments(parent_id);
+
+-- A user reports a comment once, the report stays open until a moderator hides the comment or dismisses it
+CREATE TABLE comment_reports (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    comment_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    resolution TEXT CHECK (resolution IN ('hidden', 'dismissed')),
+    UNIQUE (comment_id, user_id),
+    FOREIGN KEY (comment_id) REFERENCES comments(id),
+    FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE INDEX idx_comment_reports_open ON comment_reports(comment_id) WHERE resolved_at IS NULL;
+
+-- Hiding a comment settles its open reports
+CREATE TRIGGER comments_hide AFTER UPDATE OF hidden_at ON comments
+WHEN OLD.hidden_at IS NULL AND NEW.hidden_at IS NOT NULL
+BEGIN
+    UPDATE comment_reports SET resolved_at = CURRENT_TIMESTAMP, resolution = 'hidden'
+    WHERE comment_id = NEW.id AND resolved_at IS NULL;
+END;
+
+-- Replies go with the comment they answer, however deep they are
+CREATE TRIGGER comments_replies_delete AFTER DELETE ON comments
+BEGIN
+    DELETE FROM comment_reports WHERE comment_id = OLD.id;
+    DELETE FROM comments WHERE id IN (
+        WITH RECURSIVE replies(id) AS (
+            SELECT id FROM comments WHERE parent_id = OLD.id
+            UNION ALL
+            SELECT comments.id FROM comments JOIN replies ON comments.parent_id = replies.id
+        )
+        SELECT id FROM replies
+    );
+END;
+
+CREATE TRIGGER users_comment_reports_delete AFTER DELETE ON users
+BEGIN
+    DELETE FROM comment_reports WHERE user_id = OLD.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS users_comment_reports_delete;
+DROP TRIGGER IF EXISTS comments_replies_delete;
+DROP TRIGGER IF EXISTS comments_hide;
+DROP INDEX IF EXISTS idx_comment_reports_open;
+DROP TABLE IF EXISTS comment_reports;
+DROP INDEX IF EXISTS idx_comments_parent_id;
+DROP INDEX IF EXISTS idx_comments_post_id;
+ALTER TABLE comments DROP COLUMN hidden_at;
+ALTER TABLE comments DROP COLUMN depth;
+ALTER TABLE comments DROP COLUMN parent_id;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE notifications (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    actor_id INTEGER NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('comment', 'reply', 'follow', 'reaction')),
+    post_id INTEGER,
+    comment_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (actor_id) REFERENCES users(id)
+);
+
+-- Newest first per user, and the unread ones for the counts
+CREATE INDEX idx_notifications_user_id ON notifications(user_id, id);
+CREATE INDEX idx_notifications_unread ON notifications(user_id, type) WHERE read_at IS NULL;
+
+-- Following again or reacting twice to the same thing doesn't notify again
+CREATE UNIQUE INDEX idx_notifications_once ON notifications(user_id, actor_id, type, COALESCE(post_id, 0), COALESCE(comment_id, 0))
+WHERE type IN ('follow', 'reaction');
+
+CREATE TRIGGER users_notifications_delete AFTER DELETE ON users
+BEGIN
+    DELETE FROM notifications WHERE user_id = OLD.id OR actor_id = OLD.id;
+END;
+
+CREATE TRIGGER posts_notifications_delete AFTER DELETE ON posts
+BEGIN
+    DELETE FROM notifications WHERE post_id = OLD.id;
+END;
+
+CREATE TRIGGER comments_notifications_delete AFTER DELETE ON comments
+BEGIN
+    DELETE FROM notifications WHERE comment_id = OLD.id;
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS comments_notifications_delete;
+DROP TRIGGER IF EXISTS posts_notifications_delete;
+DROP TRIGGER IF EXISTS users_notifications_delete;
+DROP INDEX IF EXISTS idx_notifications_once;
+DROP INDEX IF EXISTS idx_notifications_unread;
+DROP INDEX IF EXISTS idx_notifications_user_id;
+DROP TABLE IF EXISTS notifications;
+-- +goose StatementEnd
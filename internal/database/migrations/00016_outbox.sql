@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE outbox (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    -- What the event is about, events of the same aggregate are dispatched in order
+    aggregate_type TEXT NOT NULL,
+    aggregate_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    -- Comma separated subscribers that still have to handle the event, NULL for all of them
+    pending_subscribers TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    dispatched_at TIMESTAMP
+);
+
+-- The dispatcher only looks at the events not dispatched yet, the oldest of each aggregate first
+CREATE INDEX idx_outbox_pending ON outbox(aggregate_type, aggregate_id, id) WHERE dispatched_at IS NULL;
+CREATE INDEX idx_outbox_dispatched_at ON outbox(dispatched_at) WHERE dispatched_at IS NOT NULL;
+
+-- Events a subscriber failed to handle in every attempt, kept after the event is cleaned up
+CREATE TABLE outbox_dead_letters (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    subscriber TEXT NOT NULL,
+    attempts INTEGER NOT NULL,
+    error TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox_dead_letters;
+DROP INDEX IF EXISTS idx_outbox_dispatched_at;
+DROP INDEX IF EXISTS idx_outbox_pending;
+DROP TABLE IF EXISTS outbox;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE jobs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    -- Higher first, then the longest due
+    priority INTEGER NOT NULL DEFAULT 0,
+    -- At most one pending or running job per key
+    unique_key TEXT,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'succeeded', 'failed', 'cancelled')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    run_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    -- The worker running the job and until when, an expired lease is taken over by another worker
+    locked_by TEXT,
+    locked_until TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+-- The workers only look at the pending and running jobs
+CREATE INDEX idx_jobs_due ON jobs(priority DESC, run_at, id) WHERE status = 'pending';
+CREATE INDEX idx_jobs_leased ON jobs(locked_until) WHERE status = 'running';
+CREATE UNIQUE INDEX idx_jobs_unique_key ON jobs(unique_key) WHERE unique_key IS NOT NULL AND status IN ('pending', 'running');
+CREATE INDEX idx_jobs_finished_at ON jobs(finished_at) WHERE finished_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_jobs_finished_at;
+DROP INDEX IF EXISTS idx_jobs_unique_key;
+DROP INDEX IF EXISTS idx_jobs_leased;
+DROP INDEX IF EXISTS idx_jobs_due;
+DROP TABLE IF EXISTS jobs;
+-- +goose StatementEnd
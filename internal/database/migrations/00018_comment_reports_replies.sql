@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The comments deleted by the trigger don't fire it again (recursive_triggers is off), so it deletes
+-- the reports of every reply itself rather than leaving them to the trigger of the reply
+DROP TRIGGER IF EXISTS comments_replies_delete;
+CREATE TRIGGER comments_replies_delete AFTER DELETE ON comments
+BEGIN
+    DELETE FROM comment_reports WHERE comment_id = OLD.id OR comment_id IN (
+        WITH RECURSIVE replies(id) AS (
+            SELECT id FROM comments WHERE parent_id = OLD.id
+            UNION ALL
+            SELECT comments.id FROM comments JOIN replies ON comments.parent_id = replies.id
+        )
+        SELECT id FROM replies
+    );
+    DELETE FROM comments WHERE id IN (
+        WITH RECURSIVE replies(id) AS (
+            SELECT id FROM comments WHERE parent_id = OLD.id
+            UNION ALL
+            SELECT comments.id FROM comments JOIN replies ON comments.parent_id = replies.id
+        )
+        SELECT id FROM replies
+    );
+END;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS comments_replies_delete;
+CREATE TRIGGER comments_replies_delete AFTER DELETE ON comments
+BEGIN
+    DELETE FROM comment_reports WHERE comment_id = OLD.id;
+    DELETE FROM comments WHERE id IN (
+        WITH RECURSIVE replies(id) AS (
+            SELECT id FROM comments WHERE parent_id = OLD.id
+            UNION ALL
+            SELECT comments.id FROM comments JOIN replies ON comments.parent_id = replies.id
+        )
+        SELECT id FROM replies
+    );
+END;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Outbox events are delivered at least once, so comments and replies are stored once too: comment_id tells
+-- them apart. Duplicates stored before are dropped, the oldest is kept
+DELETE FROM notifications WHERE id NOT IN (
+    SELECT MIN(id) FROM notifications
+    GROUP BY user_id, actor_id, type, COALESCE(post_id, 0), COALESCE(comment_id, 0)
+);
+DROP INDEX IF EXISTS idx_notifications_once;
+CREATE UNIQUE INDEX idx_notifications_once ON notifications(user_id, actor_id, type, COALESCE(post_id, 0), COALESCE(comment_id, 0));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_notifications_once;
+CREATE UNIQUE INDEX idx_notifications_once ON notifications(user_id, actor_id, type, COALESCE(post_id, 0), COALESCE(comment_id, 0))
+WHERE type IN ('follow', 'reaction');
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Outbox events are delivered at least once, event_id keeps a webhook from getting the same event twice.
+-- Manual redeliveries keep the event_id of the delivery they repeat and point to it with redelivery_of
+ALTER TABLE webhook_deliveries ADD COLUMN event_id INTEGER;
+ALTER TABLE webhook_deliveries ADD COLUMN redelivery_of INTEGER;
+CREATE UNIQUE INDEX idx_webhook_deliveries_event ON webhook_deliveries(webhook_id, event_id) WHERE redelivery_of IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_webhook_deliveries_event;
+ALTER TABLE webhook_deliveries DROP COLUMN redelivery_of;
+ALTER TABLE webhook_deliveries DROP COLUMN event_id;
+-- +goose StatementEnd
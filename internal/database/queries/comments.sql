@@ -0,0 +1,61 @@
+-- name: CommentsCreate :one
+INSERT INTO comments (post_id, user_id, comment, parent_id, depth)
+VALUES (:post_id, :user_id, :comment, :parent_id, :depth)
+RETURNING *;
+
+-- name: CommentsGetByPostID :many
+SELECT * FROM comments WHERE post_id = sqlc.arg(post_id);
+
+-- name: CommentsGetVisibleByPostID :many
+-- Replies to hidden comments are returned too, they are dropped with the hidden comment when building the tree
+SELECT * FROM comments
+WHERE post_id = sqlc.arg(post_id) AND hidden_at IS NULL
+ORDER BY id;
+
+-- name: CommentsDeleteByUserID :exec
+DELETE FROM comments
+WHERE user_id = :user_id OR post_id IN (SELECT id FROM posts WHERE user_id = :user_id);
+
+-- name: CommentsGetByID :one
+SELECT * FROM comments WHERE id = sqlc.arg(id);
+
+-- name: CommentsThreadHidden :one
+-- Whether the comment or any comment it replies to is hidden, however far up
+WITH RECURSIVE thread(id, parent_id, hidden_at) AS (
+    SELECT c.id, c.parent_id, c.hidden_at FROM comments AS c WHERE c.id = sqlc.arg(id)
+    UNION ALL
+    SELECT comments.id, comments.parent_id, comments.hidden_at FROM comments JOIN thread ON comments.id = thread.parent_id
+)
+SELECT CAST(EXISTS (SELECT 1 FROM thread WHERE hidden_at IS NOT NULL) AS BOOLEAN) AS hidden;
+
+-- name: CommentsHide :one
+UPDATE comments
+SET hidden_at = COALESCE(hidden_at, CURRENT_TIMESTAMP)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CommentsUnhide :one
+UPDATE comments
+SET hidden_at = NULL
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: CommentReportsAdd :exec
+INSERT INTO comment_reports (comment_id, user_id, reason)
+VALUES (:comment_id, :user_id, :reason)
+ON CONFLICT (comment_id, user_id) DO NOTHING;
+
+-- name: CommentReportsDismiss :execrows
+UPDATE comment_reports SET resolved_at = CURRENT_TIMESTAMP, resolution = 'dismissed'
+WHERE comment_id = sqlc.arg(comment_id) AND resolved_at IS NULL;
+
+-- name: CommentReportsGetQueue :many
+-- Comments with open reports, the most reported first. reasons are separated by newlines
+SELECT comments.*, COUNT(*) AS report_count,
+    CAST(GROUP_CONCAT(comment_reports.reason, char(10)) AS TEXT) AS reasons
+FROM comment_reports
+JOIN comments ON comments.id = comment_reports.comment_id
+WHERE comment_reports.resolved_at IS NULL
+GROUP BY comments.id
+ORDER BY report_count DESC, comments.id
+LIMIT sqlc.arg(limit);
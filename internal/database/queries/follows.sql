@@ -0,0 +1,41 @@
+-- name: FollowsAdd :exec
+INSERT INTO follows (follower_id, followee_id)
+VALUES (:follower_id, :followee_id)
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+-- name: FollowsRemove :exec
+DELETE FROM follows
+WHERE follower_id = :follower_id AND followee_id = :followee_id;
+
+-- name: FollowsGetFollowers :many
+-- Keyset paginated by user id, after is the last id of the previous page
+SELECT users.* FROM users
+JOIN follows ON follows.follower_id = users.id
+WHERE follows.followee_id = sqlc.arg(user_id) AND users.id > sqlc.arg(after)
+ORDER BY users.id
+LIMIT sqlc.arg(limit);
+
+-- name: FollowsGetFollowing :many
+-- Keyset paginated by user id, after is the last id of the previous page
+SELECT users.* FROM users
+JOIN follows ON follows.followee_id = users.id
+WHERE follows.follower_id = sqlc.arg(user_id) AND users.id > sqlc.arg(after)
+ORDER BY users.id
+LIMIT sqlc.arg(limit);
+
+-- name: PostsGetFeed :many
+-- Published posts of the users followed by follower_id, newest first, older than the (before, before_id) key.
+-- published_at_key is published_at as stored, comparing it to the time the driver binds would be off
+-- for the rows written by CURRENT_TIMESTAMP. Walking idx_posts_status_published_at backwards and probing
+-- the follows primary key keeps a page cheap however many users are followed.
+SELECT posts.*, CAST(posts.published_at AS TEXT) AS published_at_key
+FROM posts
+WHERE posts.status = 'published'
+  AND posts.published_at <= CAST(sqlc.arg(before) AS TEXT)
+  AND (posts.published_at < CAST(sqlc.arg(before) AS TEXT) OR posts.id < sqlc.arg(before_id))
+  AND EXISTS (
+    SELECT 1 FROM follows
+    WHERE follows.follower_id = sqlc.arg(follower_id) AND follows.followee_id = posts.user_id
+  )
+ORDER BY posts.published_at DESC, posts.id DESC
+LIMIT sqlc.arg(limit);
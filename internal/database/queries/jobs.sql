@@ -0,0 +1,60 @@
+-- name: JobsEnqueue :one
+-- Nothing is returned when a pending or running job has the same unique_key
+INSERT INTO jobs (kind, payload, priority, unique_key, max_attempts, run_at)
+VALUES (:kind, :payload, :priority, :unique_key, :max_attempts, :run_at)
+ON CONFLICT DO NOTHING
+RETURNING *;
+
+-- name: JobsClaim :one
+-- Leases the next job to locked_by: the pending due job with the highest priority, due the longest,
+-- or a running job whose lease expired, its worker being gone
+UPDATE jobs
+SET status = 'running', attempts = attempts + 1, locked_by = sqlc.arg(locked_by), locked_until = sqlc.arg(locked_until), updated_at = sqlc.arg(now)
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE (status = 'pending' AND run_at <= sqlc.arg(now)) OR (status = 'running' AND locked_until <= sqlc.arg(now))
+    ORDER BY priority DESC, run_at, id
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: JobsExtendLease :execrows
+-- Nothing changes when the job was cancelled or taken over by another worker
+UPDATE jobs SET locked_until = :locked_until
+WHERE id = :id AND locked_by = :locked_by AND status = 'running';
+
+-- name: JobsRecordResult :execrows
+-- Only while locked_by still holds the lease, a job cancelled or taken over meanwhile is left alone
+UPDATE jobs
+SET status = :status, attempts = :attempts, run_at = :run_at, last_error = :last_error,
+    locked_by = NULL, locked_until = NULL, updated_at = :updated_at, finished_at = :finished_at
+WHERE id = :id AND locked_by = :locked_by AND status = 'running';
+
+-- name: JobsDeleteFinished :execrows
+DELETE FROM jobs WHERE finished_at < sqlc.arg(before);
+
+-- name: JobsGetAll :many
+-- Newest first, keyset paginated by id, before is the last id of the previous page. An empty status or kind matches all
+SELECT * FROM jobs
+WHERE (sqlc.arg(status) = '' OR status = sqlc.arg(status)) AND (sqlc.arg(kind) = '' OR kind = sqlc.arg(kind)) AND id < sqlc.arg(before)
+ORDER BY id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: JobsGetByID :one
+SELECT * FROM jobs WHERE id = sqlc.arg(id);
+
+-- name: JobsRetry :one
+-- A failed or cancelled job runs again with all its attempts, unless a job with the same unique_key is queued
+UPDATE jobs
+SET status = 'pending', attempts = 0, run_at = sqlc.arg(now), updated_at = sqlc.arg(now), finished_at = NULL
+WHERE id = sqlc.arg(id) AND status IN ('failed', 'cancelled') AND (unique_key IS NULL OR NOT EXISTS (
+    SELECT 1 FROM jobs AS queued WHERE queued.unique_key = jobs.unique_key AND queued.status IN ('pending', 'running')
+))
+RETURNING *;
+
+-- name: JobsCancel :one
+-- A running job stops once its worker notices, when it renews the lease
+UPDATE jobs
+SET status = 'cancelled', locked_by = NULL, locked_until = NULL, updated_at = sqlc.arg(now), finished_at = sqlc.arg(now)
+WHERE id = sqlc.arg(id) AND status IN ('pending', 'running')
+RETURNING *;
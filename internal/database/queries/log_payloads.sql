@@ -0,0 +1,21 @@
+-- name: LogPayloadsCreate :one
+INSERT INTO log_payloads (
+    log_id,
+    request_headers,
+    request_body,
+    request_body_truncated,
+    response_headers,
+    response_body,
+    response_body_truncated
+) VALUES (
+    :log_id,
+    :request_headers,
+    :request_body,
+    :request_body_truncated,
+    :response_headers,
+    :response_body,
+    :response_body_truncated
+) RETURNING *;
+
+-- name: LogPayloadsGetByLogID :one
+SELECT * FROM log_payloads WHERE log_id = sqlc.arg(log_id);
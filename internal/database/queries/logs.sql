@@ -27,11 +27,48 @@ INSERT INTO logs (
     :bytes_out
 ) RETURNING *;
 
+-- name: LogsCreateWithTimestamp :one
+INSERT INTO logs (
+    timestamp,
+    request_id,
+    remote_ip,
+    host,
+    method,
+    uri,
+    user_agent,
+    status,
+    error,
+    latency,
+    latency_human,
+    bytes_in,
+    bytes_out
+) VALUES (
+    :timestamp,
+    :request_id,
+    :remote_ip,
+    :host,
+    :method,
+    :uri,
+    :user_agent,
+    :status,
+    :error,
+    :latency,
+    :latency_human,
+    :bytes_in,
+    :bytes_out
+) RETURNING *;
+
 -- name: LogsGetAll :many
 SELECT method, status as response, uri as path, latency_human as response_time, timestamp as created_at
 FROM logs
 ORDER BY timestamp DESC;
 
+-- name: LogsGetByID :one
+SELECT * FROM logs WHERE id = sqlc.arg(id);
+
+-- name: LogsGetByRequestID :one
+SELECT * FROM logs WHERE request_id = sqlc.arg(request_id) ORDER BY id DESC LIMIT 1;
+
 -- name: LogsGetUniqueMethods :many
 SELECT DISTINCT method
 FROM logs
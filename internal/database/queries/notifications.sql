@@ -0,0 +1,27 @@
+-- name: NotificationsCreate :one
+-- Returns sql.ErrNoRows when the notification was sent already, see idx_notifications_once
+INSERT OR IGNORE INTO notifications (user_id, actor_id, type, post_id, comment_id)
+VALUES (:user_id, :actor_id, :type, :post_id, :comment_id)
+RETURNING *;
+
+-- name: NotificationsGetByUserID :many
+-- Newest first, keyset paginated by id, before is the last id of the previous page
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg(user_id) AND id < sqlc.arg(before)
+  AND (NOT CAST(sqlc.arg(unread_only) AS BOOLEAN) OR read_at IS NULL)
+ORDER BY id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: NotificationsCountUnread :many
+SELECT type, COUNT(*) AS count FROM notifications
+WHERE user_id = sqlc.arg(user_id) AND read_at IS NULL
+GROUP BY type
+ORDER BY type;
+
+-- name: NotificationsMarkRead :execrows
+UPDATE notifications SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+WHERE id = sqlc.arg(id) AND user_id = sqlc.arg(user_id);
+
+-- name: NotificationsMarkAllRead :execrows
+UPDATE notifications SET read_at = CURRENT_TIMESTAMP
+WHERE user_id = sqlc.arg(user_id) AND read_at IS NULL;
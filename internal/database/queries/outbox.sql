@@ -0,0 +1,39 @@
+-- name: OutboxAppend :exec
+INSERT INTO outbox (aggregate_type, aggregate_id, type, payload)
+VALUES (:aggregate_type, :aggregate_id, :type, :payload);
+
+-- name: OutboxGetDue :many
+-- The oldest event not dispatched yet of every aggregate, when its next attempt is due
+SELECT * FROM outbox
+WHERE dispatched_at IS NULL AND next_attempt_at <= sqlc.arg(now) AND NOT EXISTS (
+    SELECT 1 FROM outbox AS earlier
+    WHERE earlier.dispatched_at IS NULL AND earlier.aggregate_type = outbox.aggregate_type
+        AND earlier.aggregate_id = outbox.aggregate_id AND earlier.id < outbox.id
+)
+ORDER BY id
+LIMIT sqlc.arg(limit);
+
+-- name: OutboxMarkDispatched :exec
+UPDATE outbox
+SET dispatched_at = :dispatched_at, attempts = :attempts, pending_subscribers = NULL, last_error = :last_error
+WHERE id = :id;
+
+-- name: OutboxRecordFailure :exec
+-- Retried at next_attempt_at, only for the subscribers that failed
+UPDATE outbox
+SET attempts = :attempts, pending_subscribers = :pending_subscribers, next_attempt_at = :next_attempt_at, last_error = :last_error
+WHERE id = :id;
+
+-- name: OutboxDeleteDispatched :execrows
+DELETE FROM outbox WHERE dispatched_at < sqlc.arg(before);
+
+-- name: OutboxDeadLettersCreate :exec
+INSERT INTO outbox_dead_letters (event_id, aggregate_type, aggregate_id, type, payload, subscriber, attempts, error)
+VALUES (:event_id, :aggregate_type, :aggregate_id, :type, :payload, :subscriber, :attempts, :error);
+
+-- name: OutboxDeadLettersGetAll :many
+-- Newest first, keyset paginated by id, before is the last id of the previous page
+SELECT * FROM outbox_dead_letters
+WHERE id < sqlc.arg(before)
+ORDER BY id DESC
+LIMIT sqlc.arg(limit);
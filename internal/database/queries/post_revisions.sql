@@ -0,0 +1,8 @@
+-- name: PostRevisionsGetByPostID :many
+SELECT * FROM post_revisions WHERE post_id = sqlc.arg(post_id) ORDER BY revision;
+
+-- name: PostRevisionsGetByRevision :one
+SELECT * FROM post_revisions WHERE post_id = sqlc.arg(post_id) AND revision = sqlc.arg(revision);
+
+-- name: PostRevisionsGetLatest :one
+SELECT * FROM post_revisions WHERE post_id = sqlc.arg(post_id) ORDER BY revision DESC LIMIT 1;
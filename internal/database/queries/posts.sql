@@ -2,12 +2,45 @@
 SELECT * from posts;
 
 -- name: PostsCreate :one
-INSERT INTO posts (user_id, title, content)
-VALUES (:user_id, :title, :content)
+INSERT INTO posts (user_id, title, content, status, published_at, updated_at)
+VALUES (:user_id, :title, :content, 'published', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING *;
+
+-- name: PostsCreateWithStatus :one
+INSERT INTO posts (user_id, title, content, status, published_at, updated_at)
+VALUES (:user_id, :title, :content, :status, :published_at, CURRENT_TIMESTAMP)
 RETURNING *;
 
 -- name: PostsGetByID :one
 SELECT * FROM posts WHERE id = sqlc.arg(id);
 
 -- name: PostsGetByUserID :many
-SELECT * FROM posts WHERE user_id = sqlc.arg(user_id);
\ No newline at end of file
+SELECT * FROM posts WHERE user_id = sqlc.arg(user_id);
+
+-- name: PostsGetVisible :many
+SELECT * FROM posts WHERE status = 'published' OR user_id = sqlc.arg(viewer_id);
+
+-- name: PostsGetVisibleByUserID :many
+SELECT * FROM posts
+WHERE user_id = sqlc.arg(user_id) AND (status = 'published' OR user_id = sqlc.arg(viewer_id));
+
+-- name: PostsUpdateByID :one
+UPDATE posts
+SET title = :title, content = :content, updated_at = CURRENT_TIMESTAMP
+WHERE id = :id AND title = :old_title AND content = :old_content
+RETURNING *;
+
+-- name: PostsUpdateStatusByID :one
+UPDATE posts
+SET status = :status, published_at = :published_at, updated_at = CURRENT_TIMESTAMP
+WHERE id = :id
+RETURNING *;
+
+-- name: PostsPublishDue :many
+UPDATE posts
+SET status = 'published', updated_at = CURRENT_TIMESTAMP
+WHERE status = 'scheduled' AND published_at <= sqlc.arg(now)
+RETURNING *;
+
+-- name: PostsDeleteByUserID :exec
+DELETE FROM posts WHERE user_id = :user_id;
@@ -0,0 +1,18 @@
+-- name: RateLimitsDeleteBefore :execrows
+DELETE FROM rate_limits WHERE updated_at < sqlc.arg(updated_at);
+
+-- name: RateLimitsGet :one
+SELECT * FROM rate_limits WHERE key = sqlc.arg(key);
+
+-- name: RateLimitsUpsert :exec
+INSERT INTO rate_limits (
+    key,
+    tokens,
+    updated_at
+) VALUES (
+    :key,
+    :tokens,
+    :updated_at
+) ON CONFLICT (key) DO UPDATE SET
+    tokens = excluded.tokens,
+    updated_at = excluded.updated_at;
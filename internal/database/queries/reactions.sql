@@ -0,0 +1,47 @@
+-- name: PostReactionsAdd :exec
+INSERT INTO post_reactions (post_id, user_id, type)
+VALUES (:post_id, :user_id, :type)
+ON CONFLICT (post_id, user_id, type) DO NOTHING;
+
+-- name: PostReactionsRemove :exec
+DELETE FROM post_reactions
+WHERE post_id = :post_id AND user_id = :user_id AND type = :type;
+
+-- name: PostReactionsSummary :many
+-- Reaction counts of a post by type, mine tells whether viewer_id left that reaction
+SELECT type, COUNT(*) AS count, CAST(MAX(user_id = sqlc.arg(viewer_id)) AS BOOLEAN) AS mine
+FROM post_reactions
+WHERE post_id = sqlc.arg(post_id)
+GROUP BY type
+ORDER BY type;
+
+-- name: PostReactionsCountByPostIDs :many
+SELECT post_id, type, COUNT(*) AS count
+FROM post_reactions
+WHERE post_id IN (sqlc.slice('post_ids'))
+GROUP BY post_id, type;
+
+-- name: CommentReactionsAdd :exec
+INSERT INTO comment_reactions (comment_id, user_id, type)
+VALUES (:comment_id, :user_id, :type)
+ON CONFLICT (comment_id, user_id, type) DO NOTHING;
+
+-- name: CommentReactionsRemove :exec
+DELETE FROM comment_reactions
+WHERE comment_id = :comment_id AND user_id = :user_id AND type = :type;
+
+-- name: CommentReactionsSummary :many
+-- Reaction counts of a comment by type, mine tells whether viewer_id left that reaction
+SELECT type, COUNT(*) AS count, CAST(MAX(user_id = sqlc.arg(viewer_id)) AS BOOLEAN) AS mine
+FROM comment_reactions
+WHERE comment_id = sqlc.arg(comment_id)
+GROUP BY type
+ORDER BY type;
+
+-- name: PostsGetEngagementSince :many
+-- Published posts since published_at with their number of reactions and visible comments, ranked by posts.TrendingScore
+SELECT posts.*,
+    (SELECT COUNT(*) FROM post_reactions WHERE post_reactions.post_id = posts.id) AS reaction_count,
+    (SELECT COUNT(*) FROM comments WHERE comments.post_id = posts.id AND comments.hidden_at IS NULL) AS comment_count
+FROM posts
+WHERE posts.status = 'published' AND posts.published_at >= sqlc.arg(published_at);
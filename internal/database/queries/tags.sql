@@ -0,0 +1,59 @@
+-- name: TagsUpsert :one
+INSERT INTO tags (name)
+VALUES (:name)
+ON CONFLICT (name) DO UPDATE SET name = excluded.name
+RETURNING *;
+
+-- name: TagsGetByPostID :many
+SELECT tags.* FROM tags
+JOIN post_tags ON post_tags.tag_id = tags.id
+WHERE post_tags.post_id = sqlc.arg(post_id)
+ORDER BY tags.name;
+
+-- name: TagsGetCounts :many
+-- Usage counts only count published posts, drafts stay private
+SELECT tags.id, tags.name, COUNT(posts.id) AS post_count
+FROM tags
+LEFT JOIN post_tags ON post_tags.tag_id = tags.id
+LEFT JOIN posts ON posts.id = post_tags.post_id AND posts.status = 'published'
+GROUP BY tags.id
+ORDER BY post_count DESC, tags.name
+LIMIT sqlc.arg(limit);
+
+-- name: TagsAutocomplete :many
+SELECT tags.id, tags.name, COUNT(posts.id) AS post_count
+FROM tags
+LEFT JOIN post_tags ON post_tags.tag_id = tags.id
+LEFT JOIN posts ON posts.id = post_tags.post_id AND posts.status = 'published'
+WHERE tags.name LIKE sqlc.arg(pattern)
+GROUP BY tags.id
+ORDER BY post_count DESC, tags.name
+LIMIT sqlc.arg(limit);
+
+-- name: PostTagsAdd :exec
+INSERT INTO post_tags (post_id, tag_id)
+VALUES (:post_id, :tag_id)
+ON CONFLICT (post_id, tag_id) DO NOTHING;
+
+-- name: PostTagsRemove :execrows
+DELETE FROM post_tags
+WHERE post_id = sqlc.arg(post_id) AND tag_id = (SELECT id FROM tags WHERE name = sqlc.arg(name));
+
+-- name: PostsGetVisibleByAnyTag :many
+SELECT * FROM posts
+WHERE (status = 'published' OR user_id = sqlc.arg(viewer_id))
+  AND EXISTS (
+    SELECT 1 FROM post_tags JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.name IN (sqlc.slice('names'))
+  )
+ORDER BY id;
+
+-- name: PostsGetVisibleByAllTags :many
+-- The names have to be distinct, tag_count is how many there are
+SELECT * FROM posts
+WHERE (status = 'published' OR user_id = sqlc.arg(viewer_id))
+  AND CAST(sqlc.arg(tag_count) AS INTEGER) = (
+    SELECT COUNT(*) FROM post_tags JOIN tags ON tags.id = post_tags.tag_id
+    WHERE post_tags.post_id = posts.id AND tags.name IN (sqlc.slice('names'))
+  )
+ORDER BY id;
@@ -1,9 +1,12 @@
 -- name: UsersGetAll :many
 SELECT * from users;
 
+-- name: UsersCount :one
+SELECT COUNT(*) FROM users;
+
 -- name: UsersCreate :one
-INSERT INTO users (username, email)
-VALUES (:username, :email)
+INSERT INTO users (username, email, updated_at)
+VALUES (:username, :email, CURRENT_TIMESTAMP)
 RETURNING *;
 
 -- name: UsersGetByID :one 
@@ -17,6 +20,24 @@ SELECT * from users WHERE email = sqlc.arg(email);
 
 -- name: UsersUpdateEmailByID :one
 UPDATE users
-SET email = :email
+SET email = :email, updated_at = CURRENT_TIMESTAMP
+WHERE id = :id
+RETURNING *;
+
+-- name: UsersUpdateProfileByID :one
+UPDATE users
+SET display_name = :display_name, bio = :bio, updated_at = CURRENT_TIMESTAMP
 WHERE id = :id
 RETURNING *;
+
+-- name: UsersUpdateAvatarByID :one
+UPDATE users
+SET avatar = :avatar, updated_at = CURRENT_TIMESTAMP
+WHERE id = :id
+RETURNING *;
+
+-- name: UsersGetAvatars :many
+SELECT avatar FROM users WHERE avatar IS NOT NULL;
+
+-- name: UsersDeleteByID :execrows
+DELETE FROM users WHERE id = :id;
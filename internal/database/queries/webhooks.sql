@@ -0,0 +1,58 @@
+-- name: WebhooksCreate :one
+INSERT INTO webhooks (url, secret, events)
+VALUES (:url, :secret, :events)
+RETURNING *;
+
+-- name: WebhooksGetAll :many
+SELECT * FROM webhooks ORDER BY id;
+
+-- name: WebhooksGetByID :one
+SELECT * FROM webhooks WHERE id = sqlc.arg(id);
+
+-- name: WebhooksUpdateByID :one
+UPDATE webhooks
+SET url = :url, events = :events, active = :active, updated_at = CURRENT_TIMESTAMP
+WHERE id = :id
+RETURNING *;
+
+-- name: WebhooksDeleteByID :execrows
+DELETE FROM webhooks WHERE id = sqlc.arg(id);
+
+-- name: WebhookDeliveriesEnqueue :execrows
+-- One pending delivery of the event for every active webhook subscribed to it, webhooks which already got the
+-- outbox event are skipped
+INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload)
+SELECT id, sqlc.arg(event_id), sqlc.arg(event), sqlc.arg(payload) FROM webhooks
+WHERE active AND (events = '*' OR ',' || events || ',' LIKE '%,' || sqlc.arg(event) || ',%')
+ON CONFLICT DO NOTHING;
+
+-- name: WebhookDeliveriesGetDue :many
+-- Pending deliveries of active webhooks whose next attempt is due, the oldest first
+SELECT webhook_deliveries.*, webhooks.url, webhooks.secret
+FROM webhook_deliveries
+JOIN webhooks ON webhooks.id = webhook_deliveries.webhook_id
+WHERE webhook_deliveries.status = 'pending' AND webhook_deliveries.next_attempt_at <= sqlc.arg(now) AND webhooks.active
+ORDER BY webhook_deliveries.next_attempt_at, webhook_deliveries.id
+LIMIT sqlc.arg(limit);
+
+-- name: WebhookDeliveriesRecordAttempt :exec
+UPDATE webhook_deliveries
+SET status = :status, attempts = :attempts, next_attempt_at = :next_attempt_at, last_attempt_at = :last_attempt_at,
+    response_status = :response_status, response_body = :response_body, error = :error
+WHERE id = :id;
+
+-- name: WebhookDeliveriesGetByID :one
+SELECT * FROM webhook_deliveries WHERE id = sqlc.arg(id);
+
+-- name: WebhookDeliveriesGetByWebhookID :many
+-- Newest first, keyset paginated by id, before is the last id of the previous page
+SELECT * FROM webhook_deliveries
+WHERE webhook_id = sqlc.arg(webhook_id) AND id < sqlc.arg(before)
+ORDER BY id DESC
+LIMIT sqlc.arg(limit);
+
+-- name: WebhookDeliveriesRedeliver :one
+-- A new pending delivery of the same event and payload
+INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload, redelivery_of)
+SELECT webhook_id, event_id, event, payload, id FROM webhook_deliveries WHERE webhook_deliveries.id = sqlc.arg(id)
+RETURNING *;
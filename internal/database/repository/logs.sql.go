@@ -90,6 +90,90 @@ func (q *Queries) LogsCreate(ctx context.Context, arg LogsCreateParams) (Log, er
 	return i, err
 }
 
+const logsCreateWithTimestamp = `-- name: LogsCreateWithTimestamp :one
+INSERT INTO logs (
+    timestamp,
+    request_id,
+    remote_ip,
+    host,
+    method,
+    uri,
+    user_agent,
+    status,
+    error,
+    latency,
+    latency_human,
+    bytes_in,
+    bytes_out
+) VALUES (
+    ?1,
+    ?2,
+    ?3,
+    ?4,
+    ?5,
+    ?6,
+    ?7,
+    ?8,
+    ?9,
+    ?10,
+    ?11,
+    ?12,
+    ?13
+) RETURNING id, timestamp, request_id, remote_ip, host, method, uri, user_agent, status, error, latency, latency_human, bytes_in, bytes_out
+`
+
+type LogsCreateWithTimestampParams struct {
+	Timestamp    sql.NullTime   `json:"timestamp"`
+	RequestID    sql.NullString `json:"request_id"`
+	RemoteIp     sql.NullString `json:"remote_ip"`
+	Host         sql.NullString `json:"host"`
+	Method       sql.NullString `json:"method"`
+	Uri          sql.NullString `json:"uri"`
+	UserAgent    sql.NullString `json:"user_agent"`
+	Status       sql.NullInt64  `json:"status"`
+	Error        sql.NullString `json:"error"`
+	Latency      sql.NullInt64  `json:"latency"`
+	LatencyHuman sql.NullString `json:"latency_human"`
+	BytesIn      sql.NullInt64  `json:"bytes_in"`
+	BytesOut     sql.NullInt64  `json:"bytes_out"`
+}
+
+func (q *Queries) LogsCreateWithTimestamp(ctx context.Context, arg LogsCreateWithTimestampParams) (Log, error) {
+	row := q.db.QueryRowContext(ctx, logsCreateWithTimestamp,
+		arg.Timestamp,
+		arg.RequestID,
+		arg.RemoteIp,
+		arg.Host,
+		arg.Method,
+		arg.Uri,
+		arg.UserAgent,
+		arg.Status,
+		arg.Error,
+		arg.Latency,
+		arg.LatencyHuman,
+		arg.BytesIn,
+		arg.BytesOut,
+	)
+	var i Log
+	err := row.Scan(
+		&i.ID,
+		&i.Timestamp,
+		&i.RequestID,
+		&i.RemoteIp,
+		&i.Host,
+		&i.Method,
+		&i.Uri,
+		&i.UserAgent,
+		&i.Status,
+		&i.Error,
+		&i.Latency,
+		&i.LatencyHuman,
+		&i.BytesIn,
+		&i.BytesOut,
+	)
+	return i, err
+}
+
 const logsGetAll = `-- name: LogsGetAll :many
 SELECT method, status as response, uri as path, latency_human as response_time, timestamp as created_at
 FROM logs
@@ -360,6 +444,58 @@ func (q *Queries) LogsGetBasicViewWithOffsetLimitAdvancedOld(ctx context.Context
 	return items, nil
 }
 
+const logsGetByID = `-- name: LogsGetByID :one
+SELECT id, timestamp, request_id, remote_ip, host, method, uri, user_agent, status, error, latency, latency_human, bytes_in, bytes_out FROM logs WHERE id = ?1
+`
+
+func (q *Queries) LogsGetByID(ctx context.Context, id int64) (Log, error) {
+	row := q.db.QueryRowContext(ctx, logsGetByID, id)
+	var i Log
+	err := row.Scan(
+		&i.ID,
+		&i.Timestamp,
+		&i.RequestID,
+		&i.RemoteIp,
+		&i.Host,
+		&i.Method,
+		&i.Uri,
+		&i.UserAgent,
+		&i.Status,
+		&i.Error,
+		&i.Latency,
+		&i.LatencyHuman,
+		&i.BytesIn,
+		&i.BytesOut,
+	)
+	return i, err
+}
+
+const logsGetByRequestID = `-- name: LogsGetByRequestID :one
+SELECT id, timestamp, request_id, remote_ip, host, method, uri, user_agent, status, error, latency, latency_human, bytes_in, bytes_out FROM logs WHERE request_id = ?1 ORDER BY id DESC LIMIT 1
+`
+
+func (q *Queries) LogsGetByRequestID(ctx context.Context, requestID sql.NullString) (Log, error) {
+	row := q.db.QueryRowContext(ctx, logsGetByRequestID, requestID)
+	var i Log
+	err := row.Scan(
+		&i.ID,
+		&i.Timestamp,
+		&i.RequestID,
+		&i.RemoteIp,
+		&i.Host,
+		&i.Method,
+		&i.Uri,
+		&i.UserAgent,
+		&i.Status,
+		&i.Error,
+		&i.Latency,
+		&i.LatencyHuman,
+		&i.BytesIn,
+		&i.BytesOut,
+	)
+	return i, err
+}
+
 const logsGetMethodStats = `-- name: LogsGetMethodStats :many
 SELECT 
     method,
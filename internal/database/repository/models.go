@@ -9,13 +9,57 @@ import (
 )
 
 type Comment struct {
-	ID        int64        `json:"id"`
-	PostID    int64        `json:"post_id"`
+	ID        int64         `json:"id"`
+	PostID    int64         `json:"post_id"`
+	UserID    int64         `json:"user_id"`
+	Comment   string        `json:"comment"`
+	CreatedAt sql.NullTime  `json:"created_at"`
+	ParentID  sql.NullInt64 `json:"parent_id"`
+	Depth     int64         `json:"depth"`
+	HiddenAt  sql.NullTime  `json:"hidden_at"`
+}
+
+type CommentReaction struct {
+	CommentID int64        `json:"comment_id"`
 	UserID    int64        `json:"user_id"`
-	Comment   string       `json:"comment"`
+	Type      string       `json:"type"`
 	CreatedAt sql.NullTime `json:"created_at"`
 }
 
+type CommentReport struct {
+	ID         int64          `json:"id"`
+	CommentID  int64          `json:"comment_id"`
+	UserID     int64          `json:"user_id"`
+	Reason     string         `json:"reason"`
+	CreatedAt  sql.NullTime   `json:"created_at"`
+	ResolvedAt sql.NullTime   `json:"resolved_at"`
+	Resolution sql.NullString `json:"resolution"`
+}
+
+type Follow struct {
+	FollowerID int64        `json:"follower_id"`
+	FolloweeID int64        `json:"followee_id"`
+	CreatedAt  sql.NullTime `json:"created_at"`
+}
+
+type Job struct {
+	ID          int64          `json:"id"`
+	Kind        string         `json:"kind"`
+	Payload     string         `json:"payload"`
+	Priority    int64          `json:"priority"`
+	UniqueKey   sql.NullString `json:"unique_key"`
+	Status      string         `json:"status"`
+	Attempts    int64          `json:"attempts"`
+	MaxAttempts int64          `json:"max_attempts"`
+	RunAt       sql.NullTime   `json:"run_at"`
+	LockedBy    sql.NullString `json:"locked_by"`
+	LockedUntil sql.NullTime   `json:"locked_until"`
+	LastError   sql.NullString `json:"last_error"`
+	CreatedAt   sql.NullTime   `json:"created_at"`
+	UpdatedAt   sql.NullTime   `json:"updated_at"`
+	FinishedAt  sql.NullTime   `json:"finished_at"`
+}
+
 type Log struct {
 	ID           int64          `json:"id"`
 	Timestamp    sql.NullTime   `json:"timestamp"`
@@ -33,17 +77,133 @@ type Log struct {
 	BytesOut     sql.NullInt64  `json:"bytes_out"`
 }
 
+type LogPayload struct {
+	LogID                 int64          `json:"log_id"`
+	RequestHeaders        sql.NullString `json:"request_headers"`
+	RequestBody           sql.NullString `json:"request_body"`
+	RequestBodyTruncated  bool           `json:"request_body_truncated"`
+	ResponseHeaders       sql.NullString `json:"response_headers"`
+	ResponseBody          sql.NullString `json:"response_body"`
+	ResponseBodyTruncated bool           `json:"response_body_truncated"`
+}
+
+type Notification struct {
+	ID        int64         `json:"id"`
+	UserID    int64         `json:"user_id"`
+	ActorID   int64         `json:"actor_id"`
+	Type      string        `json:"type"`
+	PostID    sql.NullInt64 `json:"post_id"`
+	CommentID sql.NullInt64 `json:"comment_id"`
+	CreatedAt sql.NullTime  `json:"created_at"`
+	ReadAt    sql.NullTime  `json:"read_at"`
+}
+
+type Outbox struct {
+	ID                 int64          `json:"id"`
+	AggregateType      string         `json:"aggregate_type"`
+	AggregateID        int64          `json:"aggregate_id"`
+	Type               string         `json:"type"`
+	Payload            string         `json:"payload"`
+	PendingSubscribers sql.NullString `json:"pending_subscribers"`
+	Attempts           int64          `json:"attempts"`
+	NextAttemptAt      sql.NullTime   `json:"next_attempt_at"`
+	LastError          sql.NullString `json:"last_error"`
+	CreatedAt          sql.NullTime   `json:"created_at"`
+	DispatchedAt       sql.NullTime   `json:"dispatched_at"`
+}
+
+type OutboxDeadLetter struct {
+	ID            int64        `json:"id"`
+	EventID       int64        `json:"event_id"`
+	AggregateType string       `json:"aggregate_type"`
+	AggregateID   int64        `json:"aggregate_id"`
+	Type          string       `json:"type"`
+	Payload       string       `json:"payload"`
+	Subscriber    string       `json:"subscriber"`
+	Attempts      int64        `json:"attempts"`
+	Error         string       `json:"error"`
+	CreatedAt     sql.NullTime `json:"created_at"`
+}
+
 type Post struct {
-	ID        int64        `json:"id"`
+	ID          int64        `json:"id"`
+	UserID      int64        `json:"user_id"`
+	Title       string       `json:"title"`
+	Content     string       `json:"content"`
+	CreatedAt   sql.NullTime `json:"created_at"`
+	UpdatedAt   sql.NullTime `json:"updated_at"`
+	Status      string       `json:"status"`
+	PublishedAt sql.NullTime `json:"published_at"`
+}
+
+type PostReaction struct {
+	PostID    int64        `json:"post_id"`
 	UserID    int64        `json:"user_id"`
+	Type      string       `json:"type"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type PostRevision struct {
+	ID        int64        `json:"id"`
+	PostID    int64        `json:"post_id"`
+	Revision  int64        `json:"revision"`
 	Title     string       `json:"title"`
 	Content   string       `json:"content"`
 	CreatedAt sql.NullTime `json:"created_at"`
 }
 
+type PostTag struct {
+	PostID    int64        `json:"post_id"`
+	TagID     int64        `json:"tag_id"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
+type RateLimit struct {
+	Key       string  `json:"key"`
+	Tokens    float64 `json:"tokens"`
+	UpdatedAt int64   `json:"updated_at"`
+}
+
+type Tag struct {
+	ID        int64        `json:"id"`
+	Name      string       `json:"name"`
+	CreatedAt sql.NullTime `json:"created_at"`
+}
+
 type User struct {
+	ID          int64          `json:"id"`
+	Username    string         `json:"username"`
+	Email       string         `json:"email"`
+	CreatedAt   sql.NullTime   `json:"created_at"`
+	UpdatedAt   sql.NullTime   `json:"updated_at"`
+	DisplayName sql.NullString `json:"display_name"`
+	Bio         sql.NullString `json:"bio"`
+	Avatar      sql.NullString `json:"avatar"`
+}
+
+type Webhook struct {
 	ID        int64        `json:"id"`
-	Username  string       `json:"username"`
-	Email     string       `json:"email"`
+	Url       string       `json:"url"`
+	Secret    string       `json:"secret"`
+	Events    string       `json:"events"`
+	Active    bool         `json:"active"`
 	CreatedAt sql.NullTime `json:"created_at"`
+	UpdatedAt sql.NullTime `json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int64          `json:"id"`
+	WebhookID      int64          `json:"webhook_id"`
+	Event          string         `json:"event"`
+	Payload        string         `json:"payload"`
+	Status         string         `json:"status"`
+	Attempts       int64          `json:"attempts"`
+	NextAttemptAt  sql.NullTime   `json:"next_attempt_at"`
+	LastAttemptAt  sql.NullTime   `json:"last_attempt_at"`
+	ResponseStatus sql.NullInt64  `json:"response_status"`
+	ResponseBody   sql.NullString `json:"response_body"`
+	Error          sql.NullString `json:"error"`
+	CreatedAt      sql.NullTime   `json:"created_at"`
+	EventID        sql.NullInt64  `json:"event_id"`
+	RedeliveryOf   sql.NullInt64  `json:"redelivery_of"`
 }
@@ -7,12 +7,13 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 )
 
 const postsCreate = `-- name: PostsCreate :one
-INSERT INTO posts (user_id, title, content)
-VALUES (?1, ?2, ?3)
-RETURNING id, user_id, title, content, created_at
+INSERT INTO posts (user_id, title, content, status, published_at, updated_at)
+VALUES (?1, ?2, ?3, 'published', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+RETURNING id, user_id, title, content, created_at, updated_at, status, published_at
 `
 
 type PostsCreateParams struct {
@@ -30,12 +31,54 @@ func (q *Queries) PostsCreate(ctx context.Context, arg PostsCreateParams) (Post,
 		&i.Title,
 		&i.Content,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.PublishedAt,
 	)
 	return i, err
 }
 
+const postsCreateWithStatus = `-- name: PostsCreateWithStatus :one
+INSERT INTO posts (user_id, title, content, status, published_at, updated_at)
+VALUES (?1, ?2, ?3, ?4, ?5, CURRENT_TIMESTAMP)
+RETURNING id, user_id, title, content, created_at, updated_at, status, published_at
+`
+
+type PostsCreateWithStatusParams struct {
+	UserID      int64        `json:"user_id"`
+	Title       string       `json:"title"`
+	Content     string       `json:"content"`
+	Status      string       `json:"status"`
+	PublishedAt sql.NullTime `json:"published_at"`
+}
+
+func (q *Queries) PostsCreateWithStatus(ctx context.Context, arg PostsCreateWithStatusParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, postsCreateWithStatus, arg.UserID, arg.Title, arg.Content, arg.Status, arg.PublishedAt)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const postsDeleteByUserID = `-- name: PostsDeleteByUserID :exec
+DELETE FROM posts WHERE user_id = ?1
+`
+
+func (q *Queries) PostsDeleteByUserID(ctx context.Context, userID int64) error {
+	_, err := q.db.ExecContext(ctx, postsDeleteByUserID, userID)
+	return err
+}
+
 const postsGetAll = `-- name: PostsGetAll :many
-SELECT id, user_id, title, content, created_at from posts
+SELECT id, user_id, title, content, created_at, updated_at, status, published_at from posts
 `
 
 func (q *Queries) PostsGetAll(ctx context.Context) ([]Post, error) {
@@ -53,6 +96,9 @@ func (q *Queries) PostsGetAll(ctx context.Context) ([]Post, error) {
 			&i.Title,
 			&i.Content,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.PublishedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -68,7 +114,7 @@ func (q *Queries) PostsGetAll(ctx context.Context) ([]Post, error) {
 }
 
 const postsGetByID = `-- name: PostsGetByID :one
-SELECT id, user_id, title, content, created_at FROM posts WHERE id = ?1
+SELECT id, user_id, title, content, created_at, updated_at, status, published_at FROM posts WHERE id = ?1
 `
 
 func (q *Queries) PostsGetByID(ctx context.Context, id int64) (Post, error) {
@@ -80,12 +126,15 @@ func (q *Queries) PostsGetByID(ctx context.Context, id int64) (Post, error) {
 		&i.Title,
 		&i.Content,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.PublishedAt,
 	)
 	return i, err
 }
 
 const postsGetByUserID = `-- name: PostsGetByUserID :many
-SELECT id, user_id, title, content, created_at FROM posts WHERE user_id = ?1
+SELECT id, user_id, title, content, created_at, updated_at, status, published_at FROM posts WHERE user_id = ?1
 `
 
 func (q *Queries) PostsGetByUserID(ctx context.Context, userID int64) ([]Post, error) {
@@ -103,6 +152,87 @@ func (q *Queries) PostsGetByUserID(ctx context.Context, userID int64) ([]Post, e
 			&i.Title,
 			&i.Content,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const postsGetVisible = `-- name: PostsGetVisible :many
+SELECT id, user_id, title, content, created_at, updated_at, status, published_at FROM posts WHERE status = 'published' OR user_id = ?1
+`
+
+func (q *Queries) PostsGetVisible(ctx context.Context, viewerID int64) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, postsGetVisible, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Post{}
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const postsGetVisibleByUserID = `-- name: PostsGetVisibleByUserID :many
+SELECT id, user_id, title, content, created_at, updated_at, status, published_at FROM posts
+WHERE user_id = ?1 AND (status = 'published' OR user_id = ?2)
+`
+
+type PostsGetVisibleByUserIDParams struct {
+	UserID   int64 `json:"user_id"`
+	ViewerID int64 `json:"viewer_id"`
+}
+
+func (q *Queries) PostsGetVisibleByUserID(ctx context.Context, arg PostsGetVisibleByUserIDParams) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, postsGetVisibleByUserID, arg.UserID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Post{}
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.PublishedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -116,3 +246,102 @@ func (q *Queries) PostsGetByUserID(ctx context.Context, userID int64) ([]Post, e
 	}
 	return items, nil
 }
+
+const postsPublishDue = `-- name: PostsPublishDue :many
+UPDATE posts
+SET status = 'published', updated_at = CURRENT_TIMESTAMP
+WHERE status = 'scheduled' AND published_at <= ?1
+RETURNING id, user_id, title, content, created_at, updated_at, status, published_at
+`
+
+func (q *Queries) PostsPublishDue(ctx context.Context, now sql.NullTime) ([]Post, error) {
+	rows, err := q.db.QueryContext(ctx, postsPublishDue, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Post{}
+	for rows.Next() {
+		var i Post
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Title,
+			&i.Content,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Status,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const postsUpdateByID = `-- name: PostsUpdateByID :one
+UPDATE posts
+SET title = ?1, content = ?2, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?3 AND title = ?4 AND content = ?5
+RETURNING id, user_id, title, content, created_at, updated_at, status, published_at
+`
+
+type PostsUpdateByIDParams struct {
+	Title      string `json:"title"`
+	Content    string `json:"content"`
+	ID         int64  `json:"id"`
+	OldTitle   string `json:"old_title"`
+	OldContent string `json:"old_content"`
+}
+
+func (q *Queries) PostsUpdateByID(ctx context.Context, arg PostsUpdateByIDParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, postsUpdateByID, arg.Title, arg.Content, arg.ID, arg.OldTitle, arg.OldContent)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.PublishedAt,
+	)
+	return i, err
+}
+
+const postsUpdateStatusByID = `-- name: PostsUpdateStatusByID :one
+UPDATE posts
+SET status = ?1, published_at = ?2, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?3
+RETURNING id, user_id, title, content, created_at, updated_at, status, published_at
+`
+
+type PostsUpdateStatusByIDParams struct {
+	Status      string       `json:"status"`
+	PublishedAt sql.NullTime `json:"published_at"`
+	ID          int64        `json:"id"`
+}
+
+func (q *Queries) PostsUpdateStatusByID(ctx context.Context, arg PostsUpdateStatusByIDParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, postsUpdateStatusByID, arg.Status, arg.PublishedAt, arg.ID)
+	var i Post
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Title,
+		&i.Content,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.PublishedAt,
+	)
+	return i, err
+}
@@ -10,25 +10,143 @@ import (
 )
 
 type Querier interface {
+	CommentReactionsAdd(ctx context.Context, arg CommentReactionsAddParams) error
+	CommentReactionsRemove(ctx context.Context, arg CommentReactionsRemoveParams) error
+	// Reaction counts of a comment by type, mine tells whether viewer_id left that reaction
+	CommentReactionsSummary(ctx context.Context, arg CommentReactionsSummaryParams) ([]CommentReactionsSummaryRow, error)
+	CommentReportsAdd(ctx context.Context, arg CommentReportsAddParams) error
+	CommentReportsDismiss(ctx context.Context, commentID int64) (int64, error)
+	// Comments with open reports, the most reported first. reasons are separated by newlines
+	CommentReportsGetQueue(ctx context.Context, limit int64) ([]CommentReportsGetQueueRow, error)
+	CommentsCreate(ctx context.Context, arg CommentsCreateParams) (Comment, error)
+	CommentsDeleteByUserID(ctx context.Context, userID int64) error
+	CommentsGetByID(ctx context.Context, id int64) (Comment, error)
+	CommentsGetByPostID(ctx context.Context, postID int64) ([]Comment, error)
+	// Replies to hidden comments are returned too, they are dropped with the hidden comment when building the tree
+	CommentsGetVisibleByPostID(ctx context.Context, postID int64) ([]Comment, error)
+	CommentsHide(ctx context.Context, id int64) (Comment, error)
+	// Whether the comment or any comment it replies to is hidden, however far up
+	CommentsThreadHidden(ctx context.Context, id int64) (bool, error)
+	CommentsUnhide(ctx context.Context, id int64) (Comment, error)
+	FollowsAdd(ctx context.Context, arg FollowsAddParams) error
+	// Keyset paginated by user id, after is the last id of the previous page
+	FollowsGetFollowers(ctx context.Context, arg FollowsGetFollowersParams) ([]User, error)
+	// Keyset paginated by user id, after is the last id of the previous page
+	FollowsGetFollowing(ctx context.Context, arg FollowsGetFollowingParams) ([]User, error)
+	FollowsRemove(ctx context.Context, arg FollowsRemoveParams) error
+	// A running job stops once its worker notices, when it renews the lease
+	JobsCancel(ctx context.Context, arg JobsCancelParams) (Job, error)
+	// Leases the next job to locked_by: the pending due job with the highest priority, due the longest,
+	// or a running job whose lease expired, its worker being gone
+	JobsClaim(ctx context.Context, arg JobsClaimParams) (Job, error)
+	JobsDeleteFinished(ctx context.Context, before sql.NullTime) (int64, error)
+	// Nothing is returned when a pending or running job has the same unique_key
+	JobsEnqueue(ctx context.Context, arg JobsEnqueueParams) (Job, error)
+	// Nothing changes when the job was cancelled or taken over by another worker
+	JobsExtendLease(ctx context.Context, arg JobsExtendLeaseParams) (int64, error)
+	// Newest first, keyset paginated by id, before is the last id of the previous page. An empty status or kind matches all
+	JobsGetAll(ctx context.Context, arg JobsGetAllParams) ([]Job, error)
+	JobsGetByID(ctx context.Context, id int64) (Job, error)
+	// Only while locked_by still holds the lease, a job cancelled or taken over meanwhile is left alone
+	JobsRecordResult(ctx context.Context, arg JobsRecordResultParams) (int64, error)
+	// A failed or cancelled job runs again with all its attempts, unless a job with the same unique_key is queued
+	JobsRetry(ctx context.Context, arg JobsRetryParams) (Job, error)
+	LogPayloadsCreate(ctx context.Context, arg LogPayloadsCreateParams) (LogPayload, error)
+	LogPayloadsGetByLogID(ctx context.Context, logID int64) (LogPayload, error)
 	LogsCreate(ctx context.Context, arg LogsCreateParams) (Log, error)
+	LogsCreateWithTimestamp(ctx context.Context, arg LogsCreateWithTimestampParams) (Log, error)
 	LogsGetAll(ctx context.Context) ([]LogsGetAllRow, error)
 	LogsGetBasicView(ctx context.Context) ([]LogsGetBasicViewRow, error)
 	LogsGetBasicViewWithOffsetLimit(ctx context.Context, arg LogsGetBasicViewWithOffsetLimitParams) ([]LogsGetBasicViewWithOffsetLimitRow, error)
 	LogsGetBasicViewWithOffsetLimitAdvanced(ctx context.Context, arg LogsGetBasicViewWithOffsetLimitAdvancedParams) ([]LogsGetBasicViewWithOffsetLimitAdvancedRow, error)
 	LogsGetBasicViewWithOffsetLimitAdvancedOld(ctx context.Context, arg LogsGetBasicViewWithOffsetLimitAdvancedOldParams) ([]LogsGetBasicViewWithOffsetLimitAdvancedOldRow, error)
+	LogsGetByID(ctx context.Context, id int64) (Log, error)
+	LogsGetByRequestID(ctx context.Context, requestID sql.NullString) (Log, error)
 	LogsGetMethodStats(ctx context.Context) ([]LogsGetMethodStatsRow, error)
 	LogsGetStatusStats(ctx context.Context) ([]LogsGetStatusStatsRow, error)
 	LogsGetUniqueMethods(ctx context.Context) ([]sql.NullString, error)
+	NotificationsCountUnread(ctx context.Context, userID int64) ([]NotificationsCountUnreadRow, error)
+	// Returns sql.ErrNoRows when the notification was sent already, see idx_notifications_once
+	NotificationsCreate(ctx context.Context, arg NotificationsCreateParams) (Notification, error)
+	// Newest first, keyset paginated by id, before is the last id of the previous page
+	NotificationsGetByUserID(ctx context.Context, arg NotificationsGetByUserIDParams) ([]Notification, error)
+	NotificationsMarkAllRead(ctx context.Context, userID int64) (int64, error)
+	NotificationsMarkRead(ctx context.Context, arg NotificationsMarkReadParams) (int64, error)
+	OutboxAppend(ctx context.Context, arg OutboxAppendParams) error
+	OutboxDeadLettersCreate(ctx context.Context, arg OutboxDeadLettersCreateParams) error
+	// Newest first, keyset paginated by id, before is the last id of the previous page
+	OutboxDeadLettersGetAll(ctx context.Context, arg OutboxDeadLettersGetAllParams) ([]OutboxDeadLetter, error)
+	OutboxDeleteDispatched(ctx context.Context, before sql.NullTime) (int64, error)
+	// The oldest event not dispatched yet of every aggregate, when its next attempt is due
+	OutboxGetDue(ctx context.Context, arg OutboxGetDueParams) ([]Outbox, error)
+	OutboxMarkDispatched(ctx context.Context, arg OutboxMarkDispatchedParams) error
+	// Retried at next_attempt_at, only for the subscribers that failed
+	OutboxRecordFailure(ctx context.Context, arg OutboxRecordFailureParams) error
+	PostReactionsAdd(ctx context.Context, arg PostReactionsAddParams) error
+	PostReactionsCountByPostIDs(ctx context.Context, postIds []int64) ([]PostReactionsCountByPostIDsRow, error)
+	PostReactionsRemove(ctx context.Context, arg PostReactionsRemoveParams) error
+	// Reaction counts of a post by type, mine tells whether viewer_id left that reaction
+	PostReactionsSummary(ctx context.Context, arg PostReactionsSummaryParams) ([]PostReactionsSummaryRow, error)
+	PostRevisionsGetByPostID(ctx context.Context, postID int64) ([]PostRevision, error)
+	PostRevisionsGetByRevision(ctx context.Context, arg PostRevisionsGetByRevisionParams) (PostRevision, error)
+	PostRevisionsGetLatest(ctx context.Context, postID int64) (PostRevision, error)
+	PostTagsAdd(ctx context.Context, arg PostTagsAddParams) error
+	PostTagsRemove(ctx context.Context, arg PostTagsRemoveParams) (int64, error)
 	PostsCreate(ctx context.Context, arg PostsCreateParams) (Post, error)
+	PostsCreateWithStatus(ctx context.Context, arg PostsCreateWithStatusParams) (Post, error)
+	PostsDeleteByUserID(ctx context.Context, userID int64) error
 	PostsGetAll(ctx context.Context) ([]Post, error)
 	PostsGetByID(ctx context.Context, id int64) (Post, error)
 	PostsGetByUserID(ctx context.Context, userID int64) ([]Post, error)
+	// Published posts since published_at with their number of reactions and visible comments, ranked by posts.TrendingScore
+	PostsGetEngagementSince(ctx context.Context, publishedAt sql.NullTime) ([]PostsGetEngagementSinceRow, error)
+	// Published posts of the users followed by follower_id, newest first, older than the (before, before_id) key.
+	// published_at_key is published_at as stored, comparing it to the time the driver binds would be off
+	// for the rows written by CURRENT_TIMESTAMP. Walking idx_posts_status_published_at backwards and probing
+	// the follows primary key keeps a page cheap however many users are followed.
+	PostsGetFeed(ctx context.Context, arg PostsGetFeedParams) ([]PostsGetFeedRow, error)
+	PostsGetVisible(ctx context.Context, viewerID int64) ([]Post, error)
+	// The names have to be distinct, tag_count is how many there are
+	PostsGetVisibleByAllTags(ctx context.Context, arg PostsGetVisibleByAllTagsParams) ([]Post, error)
+	PostsGetVisibleByAnyTag(ctx context.Context, arg PostsGetVisibleByAnyTagParams) ([]Post, error)
+	PostsGetVisibleByUserID(ctx context.Context, arg PostsGetVisibleByUserIDParams) ([]Post, error)
+	PostsPublishDue(ctx context.Context, now sql.NullTime) ([]Post, error)
+	PostsUpdateByID(ctx context.Context, arg PostsUpdateByIDParams) (Post, error)
+	PostsUpdateStatusByID(ctx context.Context, arg PostsUpdateStatusByIDParams) (Post, error)
+	RateLimitsDeleteBefore(ctx context.Context, updatedAt int64) (int64, error)
+	RateLimitsGet(ctx context.Context, key string) (RateLimit, error)
+	RateLimitsUpsert(ctx context.Context, arg RateLimitsUpsertParams) error
+	TagsAutocomplete(ctx context.Context, arg TagsAutocompleteParams) ([]TagsAutocompleteRow, error)
+	TagsGetByPostID(ctx context.Context, postID int64) ([]Tag, error)
+	// Usage counts only count published posts, drafts stay private
+	TagsGetCounts(ctx context.Context, limit int64) ([]TagsGetCountsRow, error)
+	TagsUpsert(ctx context.Context, name string) (Tag, error)
+	UsersCount(ctx context.Context) (int64, error)
 	UsersCreate(ctx context.Context, arg UsersCreateParams) (User, error)
+	UsersDeleteByID(ctx context.Context, id int64) (int64, error)
 	UsersGetAll(ctx context.Context) ([]User, error)
+	UsersGetAvatars(ctx context.Context) ([]sql.NullString, error)
 	UsersGetByEmail(ctx context.Context, email string) (User, error)
 	UsersGetByID(ctx context.Context, id int64) (User, error)
 	UsersGetByUsername(ctx context.Context, username string) (User, error)
+	UsersUpdateAvatarByID(ctx context.Context, arg UsersUpdateAvatarByIDParams) (User, error)
 	UsersUpdateEmailByID(ctx context.Context, arg UsersUpdateEmailByIDParams) (User, error)
+	UsersUpdateProfileByID(ctx context.Context, arg UsersUpdateProfileByIDParams) (User, error)
+	// One pending delivery of the event for every active webhook subscribed to it
+	WebhookDeliveriesEnqueue(ctx context.Context, arg WebhookDeliveriesEnqueueParams) (int64, error)
+	WebhookDeliveriesGetByID(ctx context.Context, id int64) (WebhookDelivery, error)
+	// Newest first, keyset paginated by id, before is the last id of the previous page
+	WebhookDeliveriesGetByWebhookID(ctx context.Context, arg WebhookDeliveriesGetByWebhookIDParams) ([]WebhookDelivery, error)
+	// Pending deliveries of active webhooks whose next attempt is due, the oldest first
+	WebhookDeliveriesGetDue(ctx context.Context, arg WebhookDeliveriesGetDueParams) ([]WebhookDeliveriesGetDueRow, error)
+	WebhookDeliveriesRecordAttempt(ctx context.Context, arg WebhookDeliveriesRecordAttemptParams) error
+	// A new pending delivery of the same event and payload
+	WebhookDeliveriesRedeliver(ctx context.Context, id int64) (WebhookDelivery, error)
+	WebhooksCreate(ctx context.Context, arg WebhooksCreateParams) (Webhook, error)
+	WebhooksDeleteByID(ctx context.Context, id int64) (int64, error)
+	WebhooksGetAll(ctx context.Context) ([]Webhook, error)
+	WebhooksGetByID(ctx context.Context, id int64) (Webhook, error)
+	WebhooksUpdateByID(ctx context.Context, arg WebhooksUpdateByIDParams) (Webhook, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -7,12 +7,24 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 )
 
+const usersCount = `-- name: UsersCount :one
+SELECT COUNT(*) FROM users
+`
+
+func (q *Queries) UsersCount(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, usersCount)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const usersCreate = `-- name: UsersCreate :one
-INSERT INTO users (username, email)
-VALUES (?1, ?2)
-RETURNING id, username, email, created_at
+INSERT INTO users (username, email, updated_at)
+VALUES (?1, ?2, CURRENT_TIMESTAMP)
+RETURNING id, username, email, created_at, updated_at, display_name, bio, avatar
 `
 
 type UsersCreateParams struct {
@@ -28,12 +40,28 @@ func (q *Queries) UsersCreate(ctx context.Context, arg UsersCreateParams) (User,
 		&i.Username,
 		&i.Email,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Avatar,
 	)
 	return i, err
 }
 
+const usersDeleteByID = `-- name: UsersDeleteByID :execrows
+DELETE FROM users WHERE id = ?1
+`
+
+func (q *Queries) UsersDeleteByID(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, usersDeleteByID, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const usersGetAll = `-- name: UsersGetAll :many
-SELECT id, username, email, created_at from users
+SELECT id, username, email, created_at, updated_at, display_name, bio, avatar from users
 `
 
 func (q *Queries) UsersGetAll(ctx context.Context) ([]User, error) {
@@ -50,6 +78,10 @@ func (q *Queries) UsersGetAll(ctx context.Context) ([]User, error) {
 			&i.Username,
 			&i.Email,
 			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DisplayName,
+			&i.Bio,
+			&i.Avatar,
 		); err != nil {
 			return nil, err
 		}
@@ -64,8 +96,35 @@ func (q *Queries) UsersGetAll(ctx context.Context) ([]User, error) {
 	return items, nil
 }
 
+const usersGetAvatars = `-- name: UsersGetAvatars :many
+SELECT avatar FROM users WHERE avatar IS NOT NULL
+`
+
+func (q *Queries) UsersGetAvatars(ctx context.Context) ([]sql.NullString, error) {
+	rows, err := q.db.QueryContext(ctx, usersGetAvatars)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []sql.NullString{}
+	for rows.Next() {
+		var avatar sql.NullString
+		if err := rows.Scan(&avatar); err != nil {
+			return nil, err
+		}
+		items = append(items, avatar)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const usersGetByEmail = `-- name: UsersGetByEmail :one
-SELECT id, username, email, created_at from users WHERE email = ?1
+SELECT id, username, email, created_at, updated_at, display_name, bio, avatar from users WHERE email = ?1
 `
 
 func (q *Queries) UsersGetByEmail(ctx context.Context, email string) (User, error) {
@@ -76,12 +135,16 @@ func (q *Queries) UsersGetByEmail(ctx context.Context, email string) (User, erro
 		&i.Username,
 		&i.Email,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Avatar,
 	)
 	return i, err
 }
 
 const usersGetByID = `-- name: UsersGetByID :one
-SELECT id, username, email, created_at from users WHERE id = ?1
+SELECT id, username, email, created_at, updated_at, display_name, bio, avatar from users WHERE id = ?1
 `
 
 func (q *Queries) UsersGetByID(ctx context.Context, id int64) (User, error) {
@@ -92,12 +155,16 @@ func (q *Queries) UsersGetByID(ctx context.Context, id int64) (User, error) {
 		&i.Username,
 		&i.Email,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Avatar,
 	)
 	return i, err
 }
 
 const usersGetByUsername = `-- name: UsersGetByUsername :one
-SELECT id, username, email, created_at from users WHERE username = ?1
+SELECT id, username, email, created_at, updated_at, display_name, bio, avatar from users WHERE username = ?1
 `
 
 func (q *Queries) UsersGetByUsername(ctx context.Context, username string) (User, error) {
@@ -108,15 +175,47 @@ func (q *Queries) UsersGetByUsername(ctx context.Context, username string) (User
 		&i.Username,
 		&i.Email,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Avatar,
+	)
+	return i, err
+}
+
+const usersUpdateAvatarByID = `-- name: UsersUpdateAvatarByID :one
+UPDATE users
+SET avatar = ?1, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?2
+RETURNING id, username, email, created_at, updated_at, display_name, bio, avatar
+`
+
+type UsersUpdateAvatarByIDParams struct {
+	Avatar sql.NullString `json:"avatar"`
+	ID     int64          `json:"id"`
+}
+
+func (q *Queries) UsersUpdateAvatarByID(ctx context.Context, arg UsersUpdateAvatarByIDParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, usersUpdateAvatarByID, arg.Avatar, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Avatar,
 	)
 	return i, err
 }
 
 const usersUpdateEmailByID = `-- name: UsersUpdateEmailByID :one
 UPDATE users
-SET email = ?1
+SET email = ?1, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?2
-RETURNING id, username, email, created_at
+RETURNING id, username, email, created_at, updated_at, display_name, bio, avatar
 `
 
 type UsersUpdateEmailByIDParams struct {
@@ -132,6 +231,39 @@ func (q *Queries) UsersUpdateEmailByID(ctx context.Context, arg UsersUpdateEmail
 		&i.Username,
 		&i.Email,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Avatar,
+	)
+	return i, err
+}
+
+const usersUpdateProfileByID = `-- name: UsersUpdateProfileByID :one
+UPDATE users
+SET display_name = ?1, bio = ?2, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?3
+RETURNING id, username, email, created_at, updated_at, display_name, bio, avatar
+`
+
+type UsersUpdateProfileByIDParams struct {
+	DisplayName sql.NullString `json:"display_name"`
+	Bio         sql.NullString `json:"bio"`
+	ID          int64          `json:"id"`
+}
+
+func (q *Queries) UsersUpdateProfileByID(ctx context.Context, arg UsersUpdateProfileByIDParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, usersUpdateProfileByID, arg.DisplayName, arg.Bio, arg.ID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Avatar,
 	)
 	return i, err
 }
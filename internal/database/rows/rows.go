@@ -0,0 +1,90 @@
+// Package rows streams the :many queries of the big tables, handing the rows to fn one by one straight
+// from *sql.Rows instead of loading the whole result in a slice.
+// sqlc can't generate this, so the queries are kept here next to their Scan. They select the columns of
+// the matching repository queries and hand out the same row types.
+package rows
+
+import (
+	"context"
+
+	"backendT/internal/database/repository"
+)
+
+type Queries struct {
+	db repository.DBTX
+}
+
+func New(db repository.DBTX) *Queries {
+	return &Queries{db: db}
+}
+
+// logsGetAll is repository's LogsGetAll.
+const logsGetAll = `
+SELECT method, status as response, uri as path, latency_human as response_time, timestamp as created_at
+FROM logs
+ORDER BY timestamp DESC
+`
+
+// usersGetAll is repository's UsersGetAll.
+const usersGetAll = `
+SELECT id, username, email, created_at, updated_at, display_name, bio, avatar FROM users
+`
+
+// LogsGetAllEach runs LogsGetAll calling fn for every row, stopping at the first error.
+func (q *Queries) LogsGetAllEach(ctx context.Context, fn func(repository.LogsGetAllRow) error) error {
+	rows, err := q.db.QueryContext(ctx, logsGetAll)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i repository.LogsGetAllRow
+		if err := rows.Scan(
+			&i.Method,
+			&i.Response,
+			&i.Path,
+			&i.ResponseTime,
+			&i.CreatedAt,
+		); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+// UsersGetAllEach runs UsersGetAll calling fn for every row, stopping at the first error.
+func (q *Queries) UsersGetAllEach(ctx context.Context, fn func(repository.User) error) error {
+	rows, err := q.db.QueryContext(ctx, usersGetAll)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var i repository.User
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DisplayName,
+			&i.Bio,
+			&i.Avatar,
+		); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	return rows.Err()
+}
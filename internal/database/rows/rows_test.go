@@ -0,0 +1,44 @@
+package rows
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"backendT/internal/database"
+	"backendT/internal/database/repository"
+	"backendT/internal/database/seed"
+)
+
+// The streamed rows are the ones of the repository queries they mirror, in the same order.
+func TestMatchesRepository(t *testing.T) {
+	ctx := context.Background()
+	db := database.New("file:rows?mode=memory&cache=shared")
+	defer db.Close()
+	_, err := seed.RunIfEmpty(ctx, db, "demo", seed.DefaultSeed)
+	assert.NoError(t, err)
+
+	repo := db.GetRepositoryRO()
+	streams := New(db.GetReadOnlyDB())
+
+	logs, err := repo.LogsGetAll(ctx)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, logs)
+	var streamedLogs []repository.LogsGetAllRow
+	assert.NoError(t, streams.LogsGetAllEach(ctx, func(r repository.LogsGetAllRow) error {
+		streamedLogs = append(streamedLogs, r)
+		return nil
+	}))
+	assert.Equal(t, logs, streamedLogs)
+
+	users, err := repo.UsersGetAll(ctx)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, users)
+	var streamedUsers []repository.User
+	assert.NoError(t, streams.UsersGetAllEach(ctx, func(u repository.User) error {
+		streamedUsers = append(streamedUsers, u)
+		return nil
+	}))
+	assert.Equal(t, users, streamedUsers)
+}
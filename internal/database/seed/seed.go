@@ -0,0 +1,260 @@
+// Package seed fills the database with generated data.
+// The same profile and random seed always produce the same users, follows, posts, tags, comments, reactions and logs,
+// so it can be used for demos, load tests and as test fixtures.
+package seed
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"backendT/internal/database"
+	"backendT/internal/database/repository"
+)
+
+// DefaultSeed is the random seed used when none is given.
+const DefaultSeed int64 = 1
+
+// Profile describes how much data gets generated.
+type Profile struct {
+	Name            string
+	Users           int
+	PostsPerUser    int
+	CommentsPerPost int
+	Logs            int
+}
+
+// Profiles are the named data sets that can be seeded.
+var Profiles = map[string]Profile{
+	"empty":     {Name: "empty"},
+	"demo":      {Name: "demo", Users: 10, PostsPerUser: 3, CommentsPerPost: 2, Logs: 200},
+	"load-test": {Name: "load-test", Users: 5000, PostsPerUser: 5, CommentsPerPost: 2, Logs: 100000},
+}
+
+// ProfileNames returns the names of all profiles, sorted.
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for name := range Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run seeds the database with the named profile in a single transaction,
+// it fails (and inserts nothing) if the generated users already exist.
+func Run(ctx context.Context, db database.Service, profileName string, seed int64) error {
+	profile, ok := Profiles[profileName]
+	if !ok {
+		return fmt.Errorf("unknown seed profile %q, available: %s", profileName, strings.Join(ProfileNames(), ", "))
+	}
+
+	return db.WithTx(ctx, func(q *repository.Queries) error {
+		return generate(ctx, q, profile, seed)
+	})
+}
+
+// RunIfEmpty seeds the database only when it has no users yet and reports whether it did.
+func RunIfEmpty(ctx context.Context, db database.Service, profileName string, seed int64) (bool, error) {
+	count, err := db.GetRepositoryRW().UsersCount(ctx)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	return true, Run(ctx, db, profileName, seed)
+}
+
+func generate(ctx context.Context, q *repository.Queries, profile Profile, seed int64) error {
+	if profile.Users == 0 && profile.Logs == 0 {
+		return nil
+	}
+
+	// Users, posts, comments and logs come from the original stream, everything added later has its own
+	// so a seed keeps generating the same data as before it existed
+	rnd := rand.New(rand.NewSource(seed))
+	tagRnd := sectionRand(seed, "tags")
+	replyRnd := sectionRand(seed, "replies")
+	reactionRnd := sectionRand(seed, "reactions")
+	followRnd := sectionRand(seed, "follows")
+
+	// The well known account from the README, so swagger can be tried right away
+	user, err := q.UsersCreate(ctx, repository.UsersCreateParams{
+		Username: "test",
+		Email:    "test@test.com",
+	})
+	if err != nil {
+		return fmt.Errorf("create test user: %w", err)
+	}
+	if _, err := q.PostsCreate(ctx, repository.PostsCreateParams{
+		Title:   "Hello World",
+		Content: "This is the first post.",
+		UserID:  user.ID,
+	}); err != nil {
+		return fmt.Errorf("create test post: %w", err)
+	}
+
+	userIDs := []int64{user.ID}
+	for i := 1; i <= profile.Users; i++ {
+		username := fmt.Sprintf("%s%d", pick(rnd, words), i)
+		user, err := q.UsersCreate(ctx, repository.UsersCreateParams{
+			Username: username,
+			Email:    username + "@example.com",
+		})
+		if err != nil {
+			return fmt.Errorf("create user %s: %w", username, err)
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+
+	for _, userID := range userIDs[1:] {
+		for i := 0; i < profile.PostsPerUser; i++ {
+			post, err := q.PostsCreate(ctx, repository.PostsCreateParams{
+				Title:   sentence(rnd, 3+rnd.Intn(4)),
+				Content: paragraph(rnd, 2+rnd.Intn(4)),
+				UserID:  userID,
+			})
+			if err != nil {
+				return fmt.Errorf("create post: %w", err)
+			}
+
+			// Up to two tags per post, a repeated pick is a no-op
+			for j := tagRnd.Intn(3); j > 0; j-- {
+				tag, err := q.TagsUpsert(ctx, pick(tagRnd, tags))
+				if err != nil {
+					return fmt.Errorf("create tag: %w", err)
+				}
+				if err := q.PostTagsAdd(ctx, repository.PostTagsAddParams{PostID: post.ID, TagID: tag.ID}); err != nil {
+					return fmt.Errorf("tag post: %w", err)
+				}
+			}
+
+			// Every other comment or so replies to an earlier one
+			var comments []repository.Comment
+			for j := 0; j < profile.CommentsPerPost; j++ {
+				params := repository.CommentsCreateParams{
+					PostID:  post.ID,
+					UserID:  userIDs[rnd.Intn(len(userIDs))],
+					Comment: sentence(rnd, 4+rnd.Intn(8)),
+				}
+				if len(comments) > 0 && replyRnd.Intn(2) == 0 {
+					parent := comments[replyRnd.Intn(len(comments))]
+					params.ParentID = sql.NullInt64{Int64: parent.ID, Valid: true}
+					params.Depth = parent.Depth + 1
+				}
+				comment, err := q.CommentsCreate(ctx, params)
+				if err != nil {
+					return fmt.Errorf("create comment: %w", err)
+				}
+				comments = append(comments, comment)
+			}
+
+			// Up to three reactions per post so /posts/trending has something to rank
+			for j := reactionRnd.Intn(4); j > 0; j-- {
+				if err := q.PostReactionsAdd(ctx, repository.PostReactionsAddParams{
+					PostID: post.ID,
+					UserID: userIDs[reactionRnd.Intn(len(userIDs))],
+					Type:   pick(reactionRnd, reactions),
+				}); err != nil {
+					return fmt.Errorf("react to post: %w", err)
+				}
+			}
+		}
+	}
+
+	// Every generated user follows up to three others, so their feeds aren't empty
+	for _, userID := range userIDs[1:] {
+		for j := followRnd.Intn(4); j > 0; j-- {
+			followeeID := userIDs[followRnd.Intn(len(userIDs))]
+			if followeeID == userID {
+				continue
+			}
+			if err := q.FollowsAdd(ctx, repository.FollowsAddParams{FollowerID: userID, FolloweeID: followeeID}); err != nil {
+				return fmt.Errorf("follow user: %w", err)
+			}
+		}
+	}
+
+	// Logs are spread over the last week so the time range filters have something to show
+	now := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < profile.Logs; i++ {
+		method := pick(rnd, methods)
+		status := pick(rnd, statuses)
+		latency := time.Duration(200+rnd.Intn(50000)) * time.Microsecond
+		if _, err := q.LogsCreateWithTimestamp(ctx, repository.LogsCreateWithTimestampParams{
+			Timestamp:    sql.NullTime{Time: now.Add(-time.Duration(rnd.Int63n(int64(7 * 24 * time.Hour)))).Truncate(time.Second), Valid: true},
+			RequestID:    sql.NullString{String: fmt.Sprintf("seed-%08x", rnd.Uint32()), Valid: true},
+			RemoteIp:     sql.NullString{String: fmt.Sprintf("10.0.%d.%d", rnd.Intn(256), rnd.Intn(256)), Valid: true},
+			Host:         sql.NullString{String: "localhost:8080", Valid: true},
+			Method:       sql.NullString{String: method, Valid: true},
+			Uri:          sql.NullString{String: pick(rnd, paths), Valid: true},
+			UserAgent:    sql.NullString{String: pick(rnd, userAgents), Valid: true},
+			Status:       sql.NullInt64{Int64: int64(status), Valid: true},
+			Error:        sql.NullString{String: errorFor(status), Valid: status >= 400},
+			Latency:      sql.NullInt64{Int64: latency.Microseconds(), Valid: true},
+			LatencyHuman: sql.NullString{String: latency.String(), Valid: true},
+			BytesIn:      sql.NullInt64{Int64: int64(rnd.Intn(512)), Valid: true},
+			BytesOut:     sql.NullInt64{Int64: int64(rnd.Intn(8192)), Valid: true},
+		}); err != nil {
+			return fmt.Errorf("create log: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func errorFor(status int) string {
+	if status < 400 {
+		return ""
+	}
+	return fmt.Sprintf("code=%d, message=%s", status, http.StatusText(status))
+}
+
+// sectionRand is the random stream of a section of data, seeded from seed and the section name.
+func sectionRand(seed int64, section string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(section))
+	return rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+}
+
+func pick[T any](rnd *rand.Rand, items []T) T {
+	return items[rnd.Intn(len(items))]
+}
+
+func sentence(rnd *rand.Rand, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = pick(rnd, words)
+	}
+	s := strings.Join(parts, " ")
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func paragraph(rnd *rand.Rand, sentences int) string {
+	parts := make([]string, sentences)
+	for i := range parts {
+		parts[i] = sentence(rnd, 5+rnd.Intn(10)) + "."
+	}
+	return strings.Join(parts, " ")
+}
+
+var (
+	words = []string{
+		"alpha", "bravo", "cedar", "delta", "ember", "falcon", "garden", "harbor", "island", "jasper",
+		"kettle", "lumen", "meadow", "nectar", "orbit", "pepper", "quartz", "river", "saffron", "timber",
+		"umber", "velvet", "willow", "xenon", "yonder", "zephyr", "amber", "breeze", "canyon", "dune",
+	}
+	tags       = []string{"announcements", "golang", "sqlite", "echo", "travel", "recipes", "music", "how-to"}
+	reactions  = []string{"like", "like", "like", "love", "laugh", "wow"}
+	methods    = []string{http.MethodGet, http.MethodGet, http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete}
+	statuses   = []int{200, 200, 200, 200, 201, 204, 400, 404, 500}
+	paths      = []string{"/users", "/posts", "/logs", "/health", "/posts/id/1", "/users/id/1", "/logs/paginated?offset=0&limit=10"}
+	userAgents = []string{"curl/8.5.0", "Mozilla/5.0 (X11; Linux x86_64)", "PostmanRuntime/7.36.0", "Go-http-client/1.1"}
+)
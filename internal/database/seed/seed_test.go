@@ -0,0 +1,81 @@
+package seed
+
+import (
+	"context"
+	"testing"
+
+	"backendT/internal/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func snapshot(t *testing.T, profile string, seed int64) (users, posts []string, logs int) {
+	db := database.New("file:seed_test?mode=memory&cache=shared")
+	defer db.Close()
+	ctx := context.Background()
+
+	assert.NoError(t, Run(ctx, db, profile, seed))
+
+	allUsers, err := db.GetRepositoryRW().UsersGetAll(ctx)
+	assert.NoError(t, err)
+	for _, u := range allUsers {
+		users = append(users, u.Username)
+	}
+
+	allPosts, err := db.GetRepositoryRW().PostsGetAll(ctx)
+	assert.NoError(t, err)
+	for _, p := range allPosts {
+		posts = append(posts, p.Title)
+	}
+
+	allLogs, err := db.GetRepositoryRW().LogsGetAll(ctx)
+	assert.NoError(t, err)
+	return users, posts, len(allLogs)
+}
+
+func TestRunIsDeterministic(t *testing.T) {
+	users, posts, logs := snapshot(t, "demo", 42)
+	assert.Len(t, users, Profiles["demo"].Users+1)
+	assert.Len(t, posts, Profiles["demo"].Users*Profiles["demo"].PostsPerUser+1)
+	assert.Equal(t, Profiles["demo"].Logs, logs)
+	assert.Equal(t, "test", users[0])
+
+	usersAgain, postsAgain, _ := snapshot(t, "demo", 42)
+	assert.Equal(t, users, usersAgain)
+	assert.Equal(t, posts, postsAgain)
+
+	_, postsOtherSeed, _ := snapshot(t, "demo", 7)
+	assert.NotEqual(t, posts, postsOtherSeed)
+}
+
+// The data added to the seed later on has its own random streams, the users, posts and logs of a seed
+// are still the ones it generated before.
+func TestRunIsStable(t *testing.T) {
+	users, posts, _ := snapshot(t, "demo", 42)
+	assert.Equal(t, "nectar10", users[len(users)-1])
+	assert.Equal(t, "River pepper canyon cedar", posts[1])
+	assert.Equal(t, "Willow dune jasper alpha", posts[len(posts)-1])
+}
+
+func TestRunProfiles(t *testing.T) {
+	users, _, _ := snapshot(t, "empty", DefaultSeed)
+	assert.Empty(t, users)
+
+	db := database.New("file:seed_test?mode=memory&cache=shared")
+	defer db.Close()
+	assert.Error(t, Run(context.Background(), db, "nope", DefaultSeed))
+}
+
+func TestRunIfEmpty(t *testing.T) {
+	db := database.New("file:seed_test?mode=memory&cache=shared")
+	defer db.Close()
+	ctx := context.Background()
+
+	seeded, err := RunIfEmpty(ctx, db, "demo", DefaultSeed)
+	assert.NoError(t, err)
+	assert.True(t, seeded)
+
+	seeded, err = RunIfEmpty(ctx, db, "demo", DefaultSeed)
+	assert.NoError(t, err)
+	assert.False(t, seeded)
+}
@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"backendT/internal/database/repository"
+)
+
+// How many times a transaction is retried when sqlite reports the database as busy,
+// and how long to wait before the first retry (doubled on every attempt).
+const (
+	txMaxRetries   = 5
+	txRetryBackoff = 10 * time.Millisecond
+)
+
+// txState is stored in the context of a running transaction so nested calls
+// can reuse it through savepoints instead of opening a second transaction
+// (which would deadlock, the rw pool only has a single connection).
+type txState struct {
+	tx        *sql.Tx
+	queries   *repository.Queries
+	savepoint int
+}
+
+type txKey struct{}
+
+func txFromContext(ctx context.Context) *txState {
+	state, _ := ctx.Value(txKey{}).(*txState)
+	return state
+}
+
+// WithTx runs fn inside a transaction on the rw db.
+// If fn returns an error (or panics) the transaction is rolled back, otherwise it is committed.
+// Use WithTxContext when fn itself needs to start nested transactions.
+func (s *service) WithTx(ctx context.Context, fn func(q *repository.Queries) error) error {
+	return s.WithTxContext(ctx, func(_ context.Context, q *repository.Queries) error {
+		return fn(q)
+	})
+}
+
+// WithTxContext is like WithTx but also passes the transaction context to fn.
+// Calling WithTx/WithTxContext again with that context creates a savepoint inside the
+// running transaction, so the inner call can fail and roll back without aborting the outer one.
+// Top level transactions are retried when sqlite reports SQLITE_BUSY, so fn may run more than once.
+func (s *service) WithTxContext(ctx context.Context, fn func(ctx context.Context, q *repository.Queries) error) error {
+	if state := txFromContext(ctx); state != nil {
+		return s.withSavepoint(ctx, state, fn)
+	}
+
+	backoff := txRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := s.runTx(ctx, fn)
+		if err == nil || !isBusy(err) || attempt >= txMaxRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *service) runTx(ctx context.Context, fn func(ctx context.Context, q *repository.Queries) error) (err error) {
+	tx, err := s.dbrw.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	state := &txState{tx: tx, queries: s.reporw.WithTx(tx)}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, state), state.queries); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	// Don't commit work whose caller has already given up on it
+	if err := ctx.Err(); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (s *service) withSavepoint(ctx context.Context, state *txState, fn func(ctx context.Context, q *repository.Queries) error) (err error) {
+	state.savepoint++
+	name := fmt.Sprintf("sp_%d", state.savepoint)
+
+	if _, err := state.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("create savepoint %s: %w", name, err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			rollbackToSavepoint(state.tx, name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(ctx, state.queries); err != nil {
+		rollbackToSavepoint(state.tx, name)
+		return err
+	}
+
+	if _, err := state.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("release savepoint %s: %w", name, err)
+	}
+	return nil
+}
+
+// rollbackToSavepoint undoes everything done since the savepoint and removes it,
+// it uses a background context so the rollback still happens when ctx was cancelled.
+func rollbackToSavepoint(tx *sql.Tx, name string) {
+	_, _ = tx.ExecContext(context.Background(), "ROLLBACK TO SAVEPOINT "+name)
+	_, _ = tx.ExecContext(context.Background(), "RELEASE SAVEPOINT "+name)
+}
+
+// isBusy reports whether err is sqlite's SQLITE_BUSY (including its extended codes).
+func isBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()&0xff == sqlite3.SQLITE_BUSY
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+// Package health runs the readiness checks of the application.
+// Subsystems register their checks in a Registry and the /health/ready endpoint runs all of them.
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// Result is the outcome of a single check.
+type Result struct {
+	Status  Status            `json:"status"`
+	Details map[string]string `json:"details,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// CheckFunc checks one dependency, it should return quickly and respect ctx.
+type CheckFunc func(ctx context.Context) Result
+
+// Report is the combined result of all checks.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+type check struct {
+	fn       CheckFunc
+	critical bool
+}
+
+// Registry holds the named checks of all subsystems.
+type Registry struct {
+	mu      sync.RWMutex
+	checks  map[string]check
+	timeout time.Duration
+}
+
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{
+		checks:  make(map[string]check),
+		timeout: timeout,
+	}
+}
+
+// Register adds a check, registering the same name again replaces it.
+// A failing critical check makes the whole report down, a failing non-critical check only degrades it.
+func (r *Registry) Register(name string, critical bool, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = check{fn: fn, critical: critical}
+}
+
+// Names returns the names of all registered checks, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Run executes all checks concurrently, each limited by the registry timeout.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := make(map[string]check, len(r.checks))
+	for name, c := range r.checks {
+		checks[name] = c
+	}
+	r.mu.RUnlock()
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result, len(checks))}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := r.runOne(ctx, c.fn)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			switch {
+			case result.Status == StatusUp:
+			case c.critical && result.Status == StatusDown:
+				report.Status = StatusDown
+			case report.Status == StatusUp:
+				report.Status = StatusDegraded
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (r *Registry) runOne(ctx context.Context, fn CheckFunc) Result {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	done := make(chan Result, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- Result{Status: StatusDown, Error: "check panicked"}
+			}
+		}()
+		done <- fn(ctx)
+	}()
+
+	select {
+	case result := <-done:
+		return result
+	case <-ctx.Done():
+		return Result{Status: StatusDown, Error: "check timed out"}
+	}
+}
+
+// Up, Degraded and Down are shorthands for building results.
+func Up(details map[string]string) Result {
+	return Result{Status: StatusUp, Details: details}
+}
+
+func Degraded(details map[string]string, err error) Result {
+	return Result{Status: StatusDegraded, Details: details, Error: errString(err)}
+}
+
+func Down(details map[string]string, err error) Result {
+	return Result{Status: StatusDown, Details: details, Error: errString(err)}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
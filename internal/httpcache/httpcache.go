@@ -0,0 +1,222 @@
+// Package httpcache gives read endpoints strong ETags and conditional request handling (304 Not Modified),
+// keeps their hot responses in an in-process LRU cache dropped on writes, and checks If-Match on updates.
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+	// HIT when the response came from the cache, MISS when it was computed
+	HeaderXCache = "X-Cache"
+)
+
+// Headers set by the handler that are stored with a cached response, the others (request ids,
+// rate limits...) belong to the request that computed it. Link points paginated lists to their next page.
+var cachedHeaders = []string{echo.HeaderContentType, echo.HeaderLastModified, "Link"}
+
+// ETag returns the strong ETag of a response body, surrounding whitespace is ignored.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(bytes.TrimSpace(body))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// SetLastModified sets the Last-Modified header to the latest of times, zero times are skipped.
+func SetLastModified(c echo.Context, times ...time.Time) {
+	var latest time.Time
+	for _, t := range times {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+	if !latest.IsZero() {
+		c.Response().Header().Set(echo.HeaderLastModified, latest.UTC().Format(http.TimeFormat))
+	}
+}
+
+// SetETag sets the ETag header to the one v is served with, so clients can send it back in If-Match.
+// Parts of the response that change on their own (like counts) go in volatile: the ETag changes with
+// them so If-None-Match notices, but IfMatch leaves them out and they never fail an update.
+func SetETag(c echo.Context, v any, volatile ...any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	etag := ETag(body)
+	if len(volatile) > 0 {
+		if extra, err := json.Marshal(volatile); err == nil {
+			sum := sha256.Sum256(extra)
+			etag = strings.TrimSuffix(etag, `"`) + "-" + hex.EncodeToString(sum[:8]) + `"`
+		}
+	}
+	c.Response().Header().Set(HeaderETag, etag)
+}
+
+// IfMatch reports whether the request may modify current: it has no If-Match header,
+// or the header lists the ETag current is served with (or *), ignoring the volatile part.
+func IfMatch(c echo.Context, current any) bool {
+	header := c.Request().Header.Get(HeaderIfMatch)
+	if header == "" {
+		return true
+	}
+
+	body, err := json.Marshal(current)
+	if err != nil {
+		return false
+	}
+	tags := strings.Split(header, ",")
+	for i, tag := range tags {
+		if before, _, ok := strings.Cut(tag, "-"); ok {
+			tags[i] = before + `"`
+		}
+	}
+	return matchETag(strings.Join(tags, ","), ETag(body), false)
+}
+
+// Read serves the GET requests of a resource from cache when possible, otherwise it stores the 200 responses
+// of the handler. Either way the response gets its ETag, the one the handler set (see SetETag) or the hash of
+// the body, and conditional requests are answered with 304.
+// Responses that depend on request headers (like who is asking) name them in vary, each value gets its own entry.
+func Read(cache *LRU, resource string, vary ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Method != http.MethodGet {
+				return next(c)
+			}
+
+			key := resource + " " + c.Request().URL.RequestURI()
+			for _, name := range vary {
+				c.Response().Header().Add(echo.HeaderVary, name)
+				key += "\n" + name + ": " + c.Request().Header.Get(name)
+			}
+			if entry, ok := cache.Get(key); ok {
+				for name, values := range entry.Header {
+					c.Response().Header()[name] = values
+				}
+				c.Response().Header().Set(HeaderXCache, "HIT")
+				return respond(c, entry)
+			}
+
+			generation := cache.Generation(resource)
+			resp := c.Response()
+			buf := &bufferWriter{ResponseWriter: resp.Writer}
+			resp.Writer = buf
+			err := next(c)
+			resp.Writer = buf.ResponseWriter
+
+			if !resp.Committed {
+				return err
+			}
+			if buf.status != http.StatusOK {
+				buf.ResponseWriter.WriteHeader(buf.status)
+				buf.ResponseWriter.Write(buf.body.Bytes())
+				return err
+			}
+
+			entry := &Entry{Header: http.Header{}, Body: buf.body.Bytes(), ETag: resp.Header().Get(HeaderETag)}
+			if entry.ETag == "" {
+				entry.ETag = ETag(buf.body.Bytes())
+			}
+			for _, name := range cachedHeaders {
+				if value := resp.Header().Get(name); value != "" {
+					entry.Header.Set(name, value)
+				}
+			}
+			cache.Add(resource, generation, key, entry)
+			resp.Header().Set(HeaderXCache, "MISS")
+
+			// Only the buffer got the response so far, send it for real
+			resp.Committed, resp.Size = false, 0
+			return respond(c, entry)
+		}
+	}
+}
+
+// Invalidate drops the cached responses of the resources after every successful request.
+func Invalidate(cache *LRU, resources ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			if status := c.Response().Status; err == nil && status >= 200 && status < 300 {
+				cache.Invalidate(resources...)
+			}
+			return err
+		}
+	}
+}
+
+func respond(c echo.Context, entry *Entry) error {
+	h := c.Response().Header()
+	h.Set(HeaderETag, entry.ETag)
+	// Clients may keep the response but have to revalidate it every time
+	h.Set(echo.HeaderCacheControl, "no-cache")
+
+	if notModified(c.Request(), entry.ETag, h.Get(echo.HeaderLastModified)) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.Blob(http.StatusOK, h.Get(echo.HeaderContentType), entry.Body)
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is no If-None-Match (RFC 9110 13.2.2).
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if header := r.Header.Get(HeaderIfNoneMatch); header != "" {
+		return matchETag(header, etag, true)
+	}
+
+	since, err := http.ParseTime(r.Header.Get(echo.HeaderIfModifiedSince))
+	if err != nil || lastModified == "" {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	return err == nil && !modified.After(since)
+}
+
+// matchETag reports whether etag is in the comma separated list of a condition header.
+// Weak comparison (for If-None-Match) ignores the W/ prefix, strong comparison never matches weak tags.
+func matchETag(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = tag[2:]
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferWriter holds the response of the handler so its ETag can be computed before anything is sent.
+type bufferWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
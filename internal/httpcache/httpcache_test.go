@@ -0,0 +1,146 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	l := NewLRU(2)
+
+	assert.True(t, l.Add("users", l.Generation("users"), "a", &Entry{ETag: `"a"`}))
+	assert.True(t, l.Add("posts", l.Generation("posts"), "b", &Entry{ETag: `"b"`}))
+	_, ok := l.Get("a")
+	assert.True(t, ok)
+
+	// b is the least recently used one
+	assert.True(t, l.Add("users", l.Generation("users"), "c", &Entry{ETag: `"c"`}))
+	_, ok = l.Get("b")
+	assert.False(t, ok)
+	assert.Equal(t, 2, l.Len())
+
+	// a response computed before an invalidation is not stored
+	generation := l.Generation("users")
+	l.Invalidate("users")
+	assert.Equal(t, 0, l.Len())
+	assert.False(t, l.Add("users", generation, "a", &Entry{}))
+	assert.True(t, l.Add("posts", l.Generation("posts"), "b", &Entry{}))
+
+	assert.False(t, NewLRU(0).Add("users", 0, "a", &Entry{}))
+}
+
+func TestMatchETag(t *testing.T) {
+	assert.True(t, matchETag(`"a"`, `"a"`, false))
+	assert.True(t, matchETag(`"b", "a"`, `"a"`, false))
+	assert.True(t, matchETag(`*`, `"a"`, false))
+	assert.False(t, matchETag(`"b"`, `"a"`, false))
+	assert.False(t, matchETag(`W/"a"`, `"a"`, false))
+	assert.True(t, matchETag(`W/"a"`, `"a"`, true))
+}
+
+func TestVolatileETag(t *testing.T) {
+	e := echo.New()
+	etag := func(v any, volatile ...any) string {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		SetETag(c, v, volatile...)
+		return c.Response().Header().Get(HeaderETag)
+	}
+	ifMatch := func(header string, current any) bool {
+		req := httptest.NewRequest(http.MethodPut, "/", nil)
+		req.Header.Set(HeaderIfMatch, header)
+		return IfMatch(e.NewContext(req, httptest.NewRecorder()), current)
+	}
+
+	plain := etag("post")
+	assert.Equal(t, ETag([]byte(`"post"`)), plain)
+	counted := etag("post", map[string]int{"like": 1})
+	assert.NotEqual(t, plain, counted)
+	assert.NotEqual(t, counted, etag("post", map[string]int{"like": 2}))
+
+	assert.True(t, ifMatch(plain, "post"))
+	assert.True(t, ifMatch(counted, "post"))
+	assert.True(t, ifMatch(`"other", `+counted, "post"))
+	assert.False(t, ifMatch(counted, "edited post"))
+	assert.False(t, matchETag(counted, plain, true), "If-None-Match compares the whole ETag")
+}
+
+func TestRead(t *testing.T) {
+	cache := NewLRU(10)
+	calls := 0
+
+	e := echo.New()
+	e.GET("/things", func(c echo.Context) error {
+		calls++
+		c.Response().Header().Set(echo.HeaderLastModified, "Mon, 02 Jan 2006 15:04:05 GMT")
+		return c.JSON(http.StatusOK, map[string]int{"calls": 1})
+	}, Read(cache, "things"))
+	e.POST("/things", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	}, Invalidate(cache, "things"))
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/things", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "MISS", rec.Header().Get(HeaderXCache))
+	assert.JSONEq(t, `{"calls":1}`, rec.Body.String())
+	etag := rec.Header().Get(HeaderETag)
+	assert.Equal(t, ETag([]byte(`{"calls":1}`)), etag)
+
+	rec = get("", "")
+	assert.Equal(t, "HIT", rec.Header().Get(HeaderXCache))
+	assert.Equal(t, etag, rec.Header().Get(HeaderETag))
+	assert.Equal(t, "Mon, 02 Jan 2006 15:04:05 GMT", rec.Header().Get(echo.HeaderLastModified))
+	assert.Equal(t, 1, calls)
+
+	rec = get(HeaderIfNoneMatch, etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	assert.Equal(t, http.StatusNotModified, get(echo.HeaderIfModifiedSince, "Mon, 02 Jan 2006 15:04:05 GMT").Code)
+	assert.Equal(t, http.StatusOK, get(echo.HeaderIfModifiedSince, "Sun, 01 Jan 2006 15:04:05 GMT").Code)
+	assert.Equal(t, http.StatusOK, get(HeaderIfNoneMatch, `"other"`).Code)
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/things", nil))
+	assert.Equal(t, "MISS", get("", "").Header().Get(HeaderXCache))
+	assert.Equal(t, 2, calls)
+}
+
+func TestReadVary(t *testing.T) {
+	cache := NewLRU(10)
+
+	e := echo.New()
+	e.GET("/things", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, map[string]string{"user": c.Request().Header.Get("X-User")})
+	}, Read(cache, "things", "X-User"))
+
+	get := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/things", nil)
+		req.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, "MISS", get("a").Header().Get(HeaderXCache))
+	rec := get("b")
+	assert.Equal(t, "MISS", rec.Header().Get(HeaderXCache))
+	assert.JSONEq(t, `{"user":"b"}`, rec.Body.String())
+	assert.Contains(t, rec.Header().Values(echo.HeaderVary), "X-User")
+
+	rec = get("a")
+	assert.Equal(t, "HIT", rec.Header().Get(HeaderXCache))
+	assert.JSONEq(t, `{"user":"a"}`, rec.Body.String())
+}
@@ -0,0 +1,111 @@
+package httpcache
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+)
+
+// Entry is a cached 200 response.
+type Entry struct {
+	Header http.Header
+	Body   []byte
+	ETag   string
+}
+
+// LRU holds the most recently used responses of every resource, evicting the least recently used
+// when full. Entries are grouped by resource so a write can drop every response it affects.
+type LRU struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+
+	// Bumped on every invalidation of a resource, responses computed before it are not stored
+	generations map[string]uint64
+}
+
+type lruItem struct {
+	resource string
+	key      string
+	entry    *Entry
+}
+
+// NewLRU creates a cache of at most size responses, with size 0 nothing is stored.
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:        size,
+		order:       list.New(),
+		entries:     make(map[string]*list.Element),
+		generations: make(map[string]uint64),
+	}
+}
+
+func (l *LRU) Get(key string) (*Entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.entries[key]
+	if !ok {
+		return nil, false
+	}
+	l.order.MoveToFront(el)
+	return el.Value.(*lruItem).entry, true
+}
+
+// Generation returns the current generation of a resource, to be passed to Add.
+func (l *LRU) Generation(resource string) uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.generations[resource]
+}
+
+// Add stores a response of resource unless the resource was invalidated since generation was taken,
+// so a read racing a write never caches the old data.
+func (l *LRU) Add(resource string, generation uint64, key string, entry *Entry) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.size <= 0 || l.generations[resource] != generation {
+		return false
+	}
+	if el, ok := l.entries[key]; ok {
+		l.order.Remove(el)
+	}
+	l.entries[key] = l.order.PushFront(&lruItem{resource: resource, key: key, entry: entry})
+
+	for l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.entries, oldest.Value.(*lruItem).key)
+	}
+	return true
+}
+
+// Invalidate drops every response of the resources.
+func (l *LRU) Invalidate(resources ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, resource := range resources {
+		l.generations[resource]++
+	}
+	for el := l.order.Front(); el != nil; {
+		next := el.Next()
+		item := el.Value.(*lruItem)
+		for _, resource := range resources {
+			if item.resource == resource {
+				l.order.Remove(el)
+				delete(l.entries, item.key)
+				break
+			}
+		}
+		el = next
+	}
+}
+
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
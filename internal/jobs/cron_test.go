@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronNext(t *testing.T) {
+	// A Friday
+	after := time.Date(2025, 1, 31, 12, 34, 56, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2025, 1, 31, 12, 35, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2025, 1, 31, 13, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 1, 31, 12, 45, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2025, 2, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2025, 1, 31, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		// Either day field matches when both are given
+		{"0 0 15 * 1", time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 3 *", time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			cron, err := ParseCron(tt.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.next, cron.Next(after))
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@often"} {
+		_, err := ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,102 @@
+// Package jobs is a durable queue of background work stored in the jobs table. Jobs are enqueued, in
+// the transaction of the write they belong to when there is one, and run by a pool of workers that
+// lease them, so a job survives restarts and a job whose worker died is run again once its lease expires.
+// Failed jobs are retried with a backoff until they run out of attempts, recurring jobs are scheduled
+// with cron expressions.
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// Job statuses. Pending jobs wait for their run_at, running ones are leased by a worker, the others are finished.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Statuses are the job statuses, in the order of a job's life.
+var Statuses = []string{StatusPending, StatusRunning, StatusSucceeded, StatusFailed, StatusCancelled}
+
+// DefaultMaxAttempts is how often a job is attempted unless Options say otherwise.
+const DefaultMaxAttempts = 5
+
+// Options describe how a job is run, the zero value runs it right away with the default attempts.
+type Options struct {
+	// Higher priorities run first
+	Priority int64
+	// Not before, now when zero
+	RunAt time.Time
+	// At most one pending or running job has the key, enqueueing another one changes nothing
+	UniqueKey string
+	// Attempts before the job fails for good, DefaultMaxAttempts when zero
+	MaxAttempts int64
+}
+
+// Job is a job as handed to its handler.
+type Job struct {
+	ID      int64
+	Kind    string
+	Payload json.RawMessage
+	// This attempt, from 1 to MaxAttempts
+	Attempt     int64
+	MaxAttempts int64
+}
+
+// Decode decodes the payload of the job into v.
+func (j Job) Decode(v any) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return fmt.Errorf("decode %s job %d: %w", j.Kind, j.ID, err)
+	}
+	return nil
+}
+
+// Handler runs a job. It may run more than once for the same job, when it fails or its worker stops
+// before the job is recorded as done, so it has to be idempotent. ctx is cancelled when the job is
+// cancelled or the server shuts down.
+type Handler func(ctx context.Context, job Job) error
+
+// EnqueueRepo stores jobs, the queries of the transaction of the write they belong to when there is one.
+type EnqueueRepo interface {
+	JobsEnqueue(ctx context.Context, params repository.JobsEnqueueParams) (repository.Job, error)
+}
+
+// Enqueue stores a job of the given kind with payload data. When a job with the same UniqueKey is pending
+// or running already nothing is stored and enqueued is false.
+func Enqueue(ctx context.Context, q EnqueueRepo, kind string, data any, opts Options) (id int64, enqueued bool, err error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return 0, false, fmt.Errorf("encode %s job: %w", kind, err)
+	}
+	if opts.RunAt.IsZero() {
+		opts.RunAt = time.Now()
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+
+	job, err := q.JobsEnqueue(ctx, repository.JobsEnqueueParams{
+		Kind:        kind,
+		Payload:     string(payload),
+		Priority:    opts.Priority,
+		UniqueKey:   sql.NullString{String: opts.UniqueKey, Valid: opts.UniqueKey != ""},
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       sql.NullTime{Time: opts.RunAt.UTC(), Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return job.ID, true, nil
+}
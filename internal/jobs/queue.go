@@ -0,0 +1,389 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// KindCleanup is the recurring job deleting the finished jobs older than Retention.
+const KindCleanup = "jobs.cleanup"
+
+// scheduleKeyPrefix starts the unique key of recurring jobs, followed by their kind
+const scheduleKeyPrefix = "schedule:"
+
+// Config describes how jobs are run and retried.
+type Config struct {
+	// Jobs run at once. With 0 the instance runs no jobs but still enqueues the recurring ones, for the
+	// instances that have workers
+	Workers int
+	// How often idle workers look for due jobs
+	PollInterval time.Duration
+	// How long a job is leased to its worker, renewed while it runs. The job of a worker that died runs
+	// again once its lease expired
+	Lease time.Duration
+	// Wait before the first retry, doubled for every further one
+	RetryBase time.Duration
+	// How long running jobs get to finish on shutdown, they are stopped and released after that
+	ShutdownTimeout time.Duration
+	// How long finished jobs are kept (0 keeps them)
+	Retention time.Duration
+}
+
+// ConfigFromEnv reads the queue configuration from JOBS_WORKERS (4), JOBS_POLL_INTERVAL (1s), JOBS_LEASE (1m),
+// JOBS_RETRY_BASE (10s), JOBS_SHUTDOWN_TIMEOUT (4s) and JOBS_RETENTION (168h).
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Workers:         4,
+		PollInterval:    time.Second,
+		Lease:           time.Minute,
+		RetryBase:       10 * time.Second,
+		ShutdownTimeout: 4 * time.Second,
+		Retention:       7 * 24 * time.Hour,
+	}
+	if workers, err := strconv.Atoi(os.Getenv("JOBS_WORKERS")); err == nil && workers >= 0 {
+		cfg.Workers = workers
+	}
+	if interval, err := time.ParseDuration(os.Getenv("JOBS_POLL_INTERVAL")); err == nil && interval > 0 {
+		cfg.PollInterval = interval
+	}
+	if lease, err := time.ParseDuration(os.Getenv("JOBS_LEASE")); err == nil && lease > 0 {
+		cfg.Lease = lease
+	}
+	if base, err := time.ParseDuration(os.Getenv("JOBS_RETRY_BASE")); err == nil && base > 0 {
+		cfg.RetryBase = base
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("JOBS_SHUTDOWN_TIMEOUT")); err == nil && timeout >= 0 {
+		cfg.ShutdownTimeout = timeout
+	}
+	if retention, err := time.ParseDuration(os.Getenv("JOBS_RETENTION")); err == nil && retention >= 0 {
+		cfg.Retention = retention
+	}
+	return cfg
+}
+
+// Backoff is the wait before the next attempt of a job that failed attempts times: RetryBase, then
+// twice as long after every further failure.
+func (cfg Config) Backoff(attempts int64) time.Duration {
+	wait := cfg.RetryBase
+	for i := int64(1); i < attempts && wait < time.Hour; i++ {
+		wait *= 2
+	}
+	return wait
+}
+
+// QueueRepo leases the due jobs and records how they went.
+type QueueRepo interface {
+	JobsClaim(ctx context.Context, params repository.JobsClaimParams) (repository.Job, error)
+	JobsDeleteFinished(ctx context.Context, before sql.NullTime) (int64, error)
+	JobsEnqueue(ctx context.Context, params repository.JobsEnqueueParams) (repository.Job, error)
+	JobsExtendLease(ctx context.Context, params repository.JobsExtendLeaseParams) (int64, error)
+	JobsRecordResult(ctx context.Context, params repository.JobsRecordResultParams) (int64, error)
+}
+
+// schedule is a recurring job.
+type schedule struct {
+	kind string
+	cron Cron
+	data any
+}
+
+// Queue runs the jobs stored in the jobs table with the handler of their kind.
+type Queue struct {
+	repo      QueueRepo
+	cfg       Config
+	handlers  map[string]Handler
+	schedules []schedule
+
+	// id names the process in the leases, leases numbers them so every lease is held by a single run
+	id     string
+	leases atomic.Int64
+	// now is the clock of the queue, tests fix it
+	now func() time.Time
+}
+
+// NewQueue creates a queue of the jobs stored in repo. It cleans up the finished jobs itself, every hour.
+func NewQueue(repo QueueRepo, cfg Config) *Queue {
+	host, _ := os.Hostname()
+	q := &Queue{
+		repo:     repo,
+		cfg:      cfg,
+		handlers: make(map[string]Handler),
+		id:       fmt.Sprintf("%s:%d:%04x", host, os.Getpid(), rand.N(1<<16)),
+		now:      time.Now,
+	}
+	if cfg.Retention > 0 {
+		q.Handle(KindCleanup, q.cleanup)
+		q.mustSchedule(KindCleanup, "@hourly", nil)
+	}
+	return q
+}
+
+// Handle runs the jobs of the given kind with fn from now on. Handle before Run.
+func (q *Queue) Handle(kind string, fn Handler) {
+	q.handlers[kind] = fn
+}
+
+// Schedule enqueues a job of the given kind with payload data at every time matching the cron expression spec,
+// in UTC. Runs don't overlap: while one is pending or running the next one isn't enqueued, and a run missed
+// while the server was down happens once when it starts again. Schedule before Run.
+func (q *Queue) Schedule(kind, spec string, data any) error {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+	q.schedules = append(q.schedules, schedule{kind: kind, cron: cron, data: data})
+	return nil
+}
+
+func (q *Queue) mustSchedule(kind, spec string, data any) {
+	if err := q.Schedule(kind, spec, data); err != nil {
+		panic(err)
+	}
+}
+
+// Run runs the due jobs with Workers workers and enqueues the recurring ones until ctx is cancelled.
+// The running jobs then get ShutdownTimeout to finish, those that don't are stopped and released to run
+// again on the next start, before Run returns.
+func (q *Queue) Run(ctx context.Context) {
+	if q.cfg.PollInterval <= 0 {
+		return
+	}
+
+	// Jobs outlive ctx by ShutdownTimeout
+	jobCtx, stopJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopJobs()
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(q.cfg.ShutdownTimeout, stopJobs)
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	wg.Go(func() { q.runScheduler(ctx) })
+	for range q.cfg.Workers {
+		wg.Go(func() { q.work(ctx, jobCtx) })
+	}
+	wg.Wait()
+}
+
+// work runs one due job after the other until ctx is cancelled, looking for more every PollInterval when
+// there are none.
+func (q *Queue) work(ctx, jobCtx context.Context) {
+	ticker := time.NewTicker(q.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		for ctx.Err() == nil {
+			ran, err := q.runNext(ctx, jobCtx, q.now())
+			if err != nil && ctx.Err() == nil {
+				log.Printf("Error running jobs: %v", err)
+			}
+			if !ran || err != nil {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runScheduler enqueues the recurring jobs every PollInterval until ctx is cancelled.
+func (q *Queue) runScheduler(ctx context.Context) {
+	if len(q.schedules) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(q.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := q.EnqueueScheduled(ctx, q.now()); err != nil && ctx.Err() == nil {
+			log.Printf("Error scheduling jobs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EnqueueScheduled enqueues the next run after now of every recurring job that has none pending or running.
+func (q *Queue) EnqueueScheduled(ctx context.Context, now time.Time) error {
+	for _, s := range q.schedules {
+		next := s.cron.Next(now)
+		if next.IsZero() {
+			continue
+		}
+		if _, _, err := Enqueue(ctx, q.repo, s.kind, s.data, Options{RunAt: next, UniqueKey: scheduleKeyPrefix + s.kind}); err != nil {
+			return fmt.Errorf("schedule %s: %w", s.kind, err)
+		}
+	}
+	return nil
+}
+
+// RunDue runs the jobs due at now one after the other, until none are left, and returns how many ran.
+func (q *Queue) RunDue(ctx context.Context, now time.Time) (int, error) {
+	ran := 0
+	for {
+		ok, err := q.runNext(ctx, ctx, now)
+		if err != nil || !ok {
+			return ran, err
+		}
+		ran++
+	}
+}
+
+// runNext leases the next due job and runs it with jobCtx. ran is false when no job is due.
+func (q *Queue) runNext(ctx, jobCtx context.Context, now time.Time) (ran bool, err error) {
+	lease := sql.NullString{String: fmt.Sprintf("%s-%d", q.id, q.leases.Add(1)), Valid: true}
+	row, err := q.repo.JobsClaim(ctx, repository.JobsClaimParams{
+		LockedBy:    lease,
+		LockedUntil: sql.NullTime{Time: now.UTC().Add(q.cfg.Lease), Valid: true},
+		Now:         sql.NullTime{Time: now.UTC(), Valid: true},
+	})
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, q.run(jobCtx, row)
+}
+
+// run runs a leased job, renewing the lease meanwhile, and records how it went: succeeded, retried later,
+// failed once out of attempts, or released when ctx was cancelled by the shutdown. Nothing is recorded when
+// the lease was lost, the job having been cancelled. The result is timed when the handler returned, not when
+// the job was claimed.
+func (q *Queue) run(ctx context.Context, row repository.Job) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var lost atomic.Bool
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		if !q.renewLease(runCtx, row.ID, row.LockedBy) {
+			lost.Store(true)
+			cancel()
+		}
+	}()
+
+	var err error
+	handler, ok := q.handlers[row.Kind]
+	switch {
+	// Its worker died during the last attempt, the job may be what killed it
+	case row.Attempts > row.MaxAttempts:
+		err = errors.New("lease expired during the last attempt")
+		row.Attempts = row.MaxAttempts
+	case !ok:
+		err = fmt.Errorf("no handler for %s jobs", row.Kind)
+	default:
+		err = runHandler(runCtx, handler, Job{
+			ID:          row.ID,
+			Kind:        row.Kind,
+			Payload:     json.RawMessage(row.Payload),
+			Attempt:     row.Attempts,
+			MaxAttempts: row.MaxAttempts,
+		})
+	}
+	cancel()
+	<-renewed
+	if lost.Load() {
+		log.Printf("Stopped %s job %d, it was cancelled or its lease taken over", row.Kind, row.ID)
+		return nil
+	}
+
+	now := q.now().UTC()
+	result := repository.JobsRecordResultParams{
+		Status:    StatusSucceeded,
+		Attempts:  row.Attempts,
+		RunAt:     row.RunAt,
+		LastError: row.LastError,
+		UpdatedAt: sql.NullTime{Time: now, Valid: true},
+		ID:        row.ID,
+		LockedBy:  row.LockedBy,
+	}
+	switch {
+	// Stopped by the shutdown, the attempt doesn't count
+	case err != nil && ctx.Err() != nil:
+		result.Status = StatusPending
+		result.Attempts--
+	case err != nil && row.Attempts < row.MaxAttempts:
+		result.Status = StatusPending
+		result.RunAt = sql.NullTime{Time: now.Add(q.cfg.Backoff(row.Attempts)), Valid: true}
+		result.LastError = sql.NullString{String: err.Error(), Valid: true}
+	case err != nil:
+		log.Printf("%s job %d failed after %d attempts: %v", row.Kind, row.ID, row.Attempts, err)
+		result.Status = StatusFailed
+		result.LastError = sql.NullString{String: err.Error(), Valid: true}
+		result.FinishedAt = result.UpdatedAt
+	default:
+		result.FinishedAt = result.UpdatedAt
+	}
+	// Recorded even when shutting down
+	if _, err := q.repo.JobsRecordResult(context.WithoutCancel(ctx), result); err != nil {
+		return fmt.Errorf("record result of job %d: %w", row.ID, err)
+	}
+	return nil
+}
+
+// renewLease extends the lease of the job every half Lease until ctx is cancelled. It returns false when
+// the lease was lost.
+func (q *Queue) renewLease(ctx context.Context, id int64, lease sql.NullString) bool {
+	if q.cfg.Lease <= 0 {
+		<-ctx.Done()
+		return true
+	}
+	ticker := time.NewTicker(q.cfg.Lease / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return true
+		case <-ticker.C:
+		}
+		renewed, err := q.repo.JobsExtendLease(ctx, repository.JobsExtendLeaseParams{
+			LockedUntil: sql.NullTime{Time: q.now().UTC().Add(q.cfg.Lease), Valid: true},
+			ID:          id,
+			LockedBy:    lease,
+		})
+		if err != nil {
+			// Tried again on the next tick, the lease lasts twice as long
+			if ctx.Err() == nil {
+				log.Printf("Error renewing the lease of job %d: %v", id, err)
+			}
+			continue
+		}
+		if renewed == 0 {
+			return false
+		}
+	}
+}
+
+// runHandler runs the handler, a panic fails the attempt instead of the server.
+func runHandler(ctx context.Context, handler Handler, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// cleanup is the handler of the KindCleanup jobs.
+func (q *Queue) cleanup(ctx context.Context, job Job) error {
+	_, err := q.repo.JobsDeleteFinished(ctx, sql.NullTime{Time: q.now().UTC().Add(-q.cfg.Retention), Valid: true})
+	return err
+}
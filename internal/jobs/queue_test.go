@@ -0,0 +1,371 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"backendT/internal/database/repository"
+)
+
+type fakeRepo struct {
+	mu   sync.Mutex
+	jobs []repository.Job
+}
+
+func (r *fakeRepo) JobsClaim(ctx context.Context, params repository.JobsClaimParams) (repository.Job, error) {
+	if err := ctx.Err(); err != nil {
+		return repository.Job{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var next *repository.Job
+	for i := range r.jobs {
+		job := &r.jobs[i]
+		due := job.Status == StatusPending && !job.RunAt.Time.After(params.Now.Time)
+		expired := job.Status == StatusRunning && !job.LockedUntil.Time.After(params.Now.Time)
+		if (due || expired) && (next == nil || job.Priority > next.Priority || job.Priority == next.Priority && job.RunAt.Time.Before(next.RunAt.Time)) {
+			next = job
+		}
+	}
+	if next == nil {
+		return repository.Job{}, sql.ErrNoRows
+	}
+	next.Status, next.Attempts, next.LockedBy, next.LockedUntil = StatusRunning, next.Attempts+1, params.LockedBy, params.LockedUntil
+	return *next, nil
+}
+
+func (r *fakeRepo) JobsDeleteFinished(ctx context.Context, before sql.NullTime) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeRepo) JobsEnqueue(ctx context.Context, params repository.JobsEnqueueParams) (repository.Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, job := range r.jobs {
+		if params.UniqueKey.Valid && job.UniqueKey == params.UniqueKey && (job.Status == StatusPending || job.Status == StatusRunning) {
+			return repository.Job{}, sql.ErrNoRows
+		}
+	}
+	r.jobs = append(r.jobs, repository.Job{
+		ID:          int64(len(r.jobs) + 1),
+		Kind:        params.Kind,
+		Payload:     params.Payload,
+		Priority:    params.Priority,
+		UniqueKey:   params.UniqueKey,
+		Status:      StatusPending,
+		MaxAttempts: params.MaxAttempts,
+		RunAt:       params.RunAt,
+	})
+	return r.jobs[len(r.jobs)-1], nil
+}
+
+func (r *fakeRepo) JobsExtendLease(ctx context.Context, params repository.JobsExtendLeaseParams) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := &r.jobs[params.ID-1]
+	if job.Status != StatusRunning || job.LockedBy != params.LockedBy {
+		return 0, nil
+	}
+	job.LockedUntil = params.LockedUntil
+	return 1, nil
+}
+
+func (r *fakeRepo) JobsRecordResult(ctx context.Context, params repository.JobsRecordResultParams) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job := &r.jobs[params.ID-1]
+	if job.Status != StatusRunning || job.LockedBy != params.LockedBy {
+		return 0, nil
+	}
+	job.Status, job.Attempts, job.RunAt, job.LastError, job.FinishedAt = params.Status, params.Attempts, params.RunAt, params.LastError, params.FinishedAt
+	job.LockedBy, job.LockedUntil = sql.NullString{}, sql.NullTime{}
+	return 1, nil
+}
+
+func (r *fakeRepo) get(id int64) repository.Job {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.jobs[id-1]
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRepo{}
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	q := NewQueue(repo, Config{Lease: time.Minute, RetryBase: time.Minute})
+	q.now = func() time.Time { return now }
+
+	var ran []string
+	q.Handle("record", func(ctx context.Context, job Job) error {
+		var name string
+		assert.NoError(t, job.Decode(&name))
+		ran = append(ran, name)
+		return nil
+	})
+	q.Handle("flaky", func(ctx context.Context, job Job) error {
+		ran = append(ran, "flaky")
+		if job.Attempt == 1 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	q.Handle("panics", func(ctx context.Context, job Job) error {
+		panic("oops")
+	})
+
+	enqueue := func(kind string, data any, opts Options) int64 {
+		id, enqueued, err := Enqueue(ctx, repo, kind, data, opts)
+		assert.NoError(t, err)
+		assert.True(t, enqueued)
+		return id
+	}
+	low := enqueue("record", "low", Options{RunAt: now.Add(-time.Minute)})
+	high := enqueue("record", "high", Options{RunAt: now, Priority: 10, UniqueKey: "high"})
+	later := enqueue("record", "later", Options{RunAt: now.Add(time.Hour)})
+	flaky := enqueue("flaky", nil, Options{RunAt: now})
+	panics := enqueue("panics", nil, Options{RunAt: now, MaxAttempts: 1})
+	unknown := enqueue("unknown", nil, Options{RunAt: now, MaxAttempts: 1})
+
+	// Equal jobs aren't queued twice
+	_, enqueued, err := Enqueue(ctx, repo, "record", "high", Options{UniqueKey: "high"})
+	assert.NoError(t, err)
+	assert.False(t, enqueued)
+
+	count, err := q.RunDue(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+	assert.Equal(t, []string{"high", "low", "flaky"}, ran)
+	assert.Equal(t, StatusSucceeded, repo.get(high).Status)
+	assert.Equal(t, StatusSucceeded, repo.get(low).Status)
+	assert.True(t, repo.get(low).FinishedAt.Valid)
+	assert.Equal(t, StatusPending, repo.get(later).Status)
+
+	// Retried after the backoff
+	assert.Equal(t, StatusPending, repo.get(flaky).Status)
+	assert.Equal(t, "boom", repo.get(flaky).LastError.String)
+	assert.Equal(t, now.Add(time.Minute), repo.get(flaky).RunAt.Time)
+	assert.False(t, repo.get(flaky).LockedBy.Valid)
+
+	// Out of attempts
+	assert.Equal(t, StatusFailed, repo.get(panics).Status)
+	assert.Equal(t, "panic: oops", repo.get(panics).LastError.String)
+	assert.Equal(t, StatusFailed, repo.get(unknown).Status)
+	assert.Equal(t, "no handler for unknown jobs", repo.get(unknown).LastError.String)
+
+	// Now a unique key can be used again
+	enqueue("record", "again", Options{RunAt: now, UniqueKey: "high"})
+
+	now = now.Add(time.Minute)
+	count, err = q.RunDue(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{"high", "low", "flaky", "again", "flaky"}, ran)
+	assert.Equal(t, StatusSucceeded, repo.get(flaky).Status)
+	assert.Equal(t, int64(2), repo.get(flaky).Attempts)
+}
+
+func TestQueueSlowHandler(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRepo{}
+	claimed := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	now := claimed
+	q := NewQueue(repo, Config{Lease: time.Minute, RetryBase: time.Minute})
+	q.now = func() time.Time { return now }
+
+	// The handlers take longer than RetryBase
+	q.Handle("slow", func(ctx context.Context, job Job) error {
+		now = now.Add(5 * time.Minute)
+		if job.Attempt == 1 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	id, _, err := Enqueue(ctx, repo, "slow", nil, Options{RunAt: claimed})
+	assert.NoError(t, err)
+
+	count, err := q.RunDue(ctx, claimed)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	// Retried a minute after the attempt ended, not while it was running
+	assert.Equal(t, StatusPending, repo.get(id).Status)
+	assert.Equal(t, claimed.Add(6*time.Minute), repo.get(id).RunAt.Time)
+
+	count, err = q.RunDue(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+	now = now.Add(time.Minute)
+	count, err = q.RunDue(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, StatusSucceeded, repo.get(id).Status)
+	assert.Equal(t, claimed.Add(11*time.Minute), repo.get(id).FinishedAt.Time)
+}
+
+func TestQueueShutdown(t *testing.T) {
+	repo := &fakeRepo{}
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	q := NewQueue(repo, Config{Lease: time.Minute, RetryBase: time.Minute})
+	ctx, cancel := context.WithCancel(context.Background())
+	q.Handle("slow", func(ctx context.Context, job Job) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	id, _, err := Enqueue(ctx, repo, "slow", nil, Options{RunAt: now})
+	assert.NoError(t, err)
+
+	// Stopped halfway, released to run again right away
+	count, err := q.RunDue(ctx, now)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, count)
+	job := repo.get(id)
+	assert.Equal(t, StatusPending, job.Status)
+	assert.Zero(t, job.Attempts)
+	assert.Equal(t, now, job.RunAt.Time)
+	assert.False(t, job.LockedBy.Valid)
+}
+
+func TestQueueLeases(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRepo{}
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	q := NewQueue(repo, Config{Lease: 20 * time.Millisecond, RetryBase: time.Minute})
+
+	started := make(chan int64)
+	q.Handle("wait", func(ctx context.Context, job Job) error {
+		started <- job.ID
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	id, _, err := Enqueue(ctx, repo, "wait", nil, Options{RunAt: now})
+	assert.NoError(t, err)
+
+	// Cancelled while running, the handler is stopped when the lease can't be renewed
+	done := make(chan error)
+	go func() {
+		_, err := q.RunDue(ctx, now)
+		done <- err
+	}()
+	assert.Equal(t, id, <-started)
+	repo.mu.Lock()
+	repo.jobs[id-1].Status = StatusCancelled
+	repo.mu.Unlock()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("cancelled job kept running")
+	}
+	assert.Equal(t, StatusCancelled, repo.get(id).Status)
+
+	// The worker of the last attempt died, it isn't attempted again
+	id, _, err = Enqueue(ctx, repo, "wait", nil, Options{RunAt: now, MaxAttempts: 2})
+	assert.NoError(t, err)
+	repo.mu.Lock()
+	repo.jobs[id-1].Status = StatusRunning
+	repo.jobs[id-1].Attempts = 2
+	repo.jobs[id-1].LockedUntil = sql.NullTime{Time: now, Valid: true}
+	repo.mu.Unlock()
+	count, err := q.RunDue(ctx, now.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	job := repo.get(id)
+	assert.Equal(t, StatusFailed, job.Status)
+	assert.Equal(t, int64(2), job.Attempts)
+	assert.Equal(t, "lease expired during the last attempt", job.LastError.String)
+}
+
+func TestEnqueueScheduled(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRepo{}
+	now := time.Date(2025, 1, 31, 12, 34, 0, 0, time.UTC)
+	q := NewQueue(repo, Config{Lease: time.Minute, RetryBase: time.Minute, Retention: time.Hour})
+	q.Handle("report", func(ctx context.Context, job Job) error { return nil })
+	assert.Error(t, q.Schedule("report", "every day", nil))
+	assert.NoError(t, q.Schedule("report", "0 6 * * *", map[string]string{"format": "csv"}))
+
+	// A single pending run per recurring job
+	assert.NoError(t, q.EnqueueScheduled(ctx, now))
+	assert.NoError(t, q.EnqueueScheduled(ctx, now.Add(time.Minute)))
+	if assert.Len(t, repo.jobs, 2) {
+		kinds := []string{repo.jobs[0].Kind, repo.jobs[1].Kind}
+		assert.True(t, slices.Contains(kinds, KindCleanup))
+		report := repo.jobs[slices.Index(kinds, "report")]
+		assert.Equal(t, time.Date(2025, 2, 1, 6, 0, 0, 0, time.UTC), report.RunAt.Time)
+		assert.Equal(t, "schedule:report", report.UniqueKey.String)
+		assert.JSONEq(t, `{"format":"csv"}`, report.Payload)
+	}
+
+	// Once it ran, the next one is queued
+	count, err := q.RunDue(ctx, time.Date(2025, 2, 1, 6, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.NoError(t, q.EnqueueScheduled(ctx, time.Date(2025, 2, 1, 6, 0, 1, 0, time.UTC)))
+	if assert.Len(t, repo.jobs, 4) {
+		assert.Equal(t, time.Date(2025, 2, 2, 6, 0, 0, 0, time.UTC), repo.jobs[3].RunAt.Time)
+	}
+}
+
+func TestQueueRun(t *testing.T) {
+	repo := &fakeRepo{}
+	q := NewQueue(repo, Config{Workers: 2, PollInterval: 5 * time.Millisecond, Lease: time.Minute, RetryBase: time.Minute, ShutdownTimeout: 50 * time.Millisecond})
+	started := make(chan string, 2)
+	q.Handle("finishing", func(ctx context.Context, job Job) error {
+		started <- job.Kind
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	q.Handle("stuck", func(ctx context.Context, job Job) error {
+		started <- job.Kind
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	finishing, _, err := Enqueue(ctx, repo, "finishing", nil, Options{})
+	assert.NoError(t, err)
+	stuck, _, err := Enqueue(ctx, repo, "stuck", nil, Options{})
+	assert.NoError(t, err)
+
+	stopped := make(chan struct{})
+	go func() {
+		q.Run(ctx)
+		close(stopped)
+	}()
+	<-started
+	<-started
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Run didn't return after the shutdown timeout")
+	}
+
+	// The job finishing within ShutdownTimeout is done, the other one released
+	assert.Equal(t, StatusSucceeded, repo.get(finishing).Status)
+	assert.Equal(t, StatusPending, repo.get(stuck).Status)
+	assert.Zero(t, repo.get(stuck).Attempts)
+}
+
+// Without workers nothing runs, but the recurring jobs are still enqueued
+func TestQueueRunWithoutWorkers(t *testing.T) {
+	repo := &fakeRepo{}
+	q := NewQueue(repo, Config{Workers: 0, PollInterval: 5 * time.Millisecond, Lease: time.Minute, RetryBase: time.Minute})
+	q.Handle("report", func(ctx context.Context, job Job) error { return nil })
+	assert.NoError(t, q.Schedule("report", "@hourly", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	q.Run(ctx)
+
+	if assert.Len(t, repo.jobs, 1) {
+		assert.Equal(t, "report", repo.jobs[0].Kind)
+		assert.Equal(t, StatusPending, repo.jobs[0].Status)
+	}
+}
@@ -0,0 +1,190 @@
+// Package notify turns what users do to each other (comments, replies, follows, reactions) into
+// notifications, stores them and hands them to the live subscribers of the notified user. It learns
+// what users do from the outbox events, see HandleEvent.
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/outbox"
+)
+
+// Notification types, what the actor did.
+const (
+	TypeComment  = "comment"
+	TypeReply    = "reply"
+	TypeFollow   = "follow"
+	TypeReaction = "reaction"
+)
+
+// Types are all the notification types.
+var Types = []string{TypeComment, TypeReply, TypeFollow, TypeReaction}
+
+// subscriberBuffer is how many notifications a subscriber can lag behind before it misses some,
+// they can still be listed.
+const subscriberBuffer = 16
+
+// Repo stores the notifications.
+type Repo interface {
+	NotificationsCreate(ctx context.Context, params repository.NotificationsCreateParams) (repository.Notification, error)
+}
+
+// Notifier stores notifications and publishes them to the subscribers of their user.
+// Its methods do nothing on a nil Notifier.
+type Notifier struct {
+	repo Repo
+
+	mu          sync.Mutex
+	subscribers map[int64]map[chan repository.Notification]struct{}
+	closed      bool
+}
+
+// New creates a notifier storing the notifications in repo.
+func New(repo Repo) *Notifier {
+	return &Notifier{
+		repo:        repo,
+		subscribers: make(map[int64]map[chan repository.Notification]struct{}),
+	}
+}
+
+// Notify stores the notification and hands it to the subscribers of its user. Users aren't notified of
+// what they do themselves, nor twice of the same thing, so events delivered again notify once.
+func (n *Notifier) Notify(ctx context.Context, params repository.NotificationsCreateParams) error {
+	if n == nil || params.UserID == params.ActorID {
+		return nil
+	}
+
+	notification, err := n.repo.NotificationsCreate(ctx, params)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("store %s notification for user %d: %w", params.Type, params.UserID, err)
+	}
+	n.publish(notification)
+	return nil
+}
+
+// HandleEvent is the outbox subscriber of the notifier, it notifies the users concerned by the comments,
+// follows and reactions. A comment notifies the author of the comment replied to of a reply, and the
+// author of the post (once) of a comment.
+func (n *Notifier) HandleEvent(ctx context.Context, event outbox.Event) error {
+	switch event.Type {
+	case outbox.EventCommentCreated:
+		var comment outbox.CommentCreated
+		if err := event.Decode(&comment); err != nil {
+			return err
+		}
+		notification := repository.NotificationsCreateParams{
+			ActorID:   comment.UserID,
+			PostID:    sql.NullInt64{Int64: comment.PostID, Valid: true},
+			CommentID: sql.NullInt64{Int64: comment.CommentID, Valid: true},
+		}
+		if comment.ParentAuthorID != 0 {
+			notification.UserID, notification.Type = comment.ParentAuthorID, TypeReply
+			if err := n.Notify(ctx, notification); err != nil {
+				return err
+			}
+		}
+		if comment.PostAuthorID != comment.ParentAuthorID {
+			notification.UserID, notification.Type = comment.PostAuthorID, TypeComment
+			return n.Notify(ctx, notification)
+		}
+
+	case outbox.EventUserFollowed:
+		var follow outbox.Followed
+		if err := event.Decode(&follow); err != nil {
+			return err
+		}
+		return n.Notify(ctx, repository.NotificationsCreateParams{
+			UserID:  follow.UserID,
+			ActorID: follow.FollowerID,
+			Type:    TypeFollow,
+		})
+
+	case outbox.EventReactionAdded:
+		var reaction outbox.ReactionAdded
+		if err := event.Decode(&reaction); err != nil {
+			return err
+		}
+		notification := repository.NotificationsCreateParams{
+			UserID:  reaction.AuthorID,
+			ActorID: reaction.UserID,
+			Type:    TypeReaction,
+			PostID:  sql.NullInt64{Int64: reaction.PostID, Valid: true},
+		}
+		if reaction.CommentID != 0 {
+			notification.CommentID = sql.NullInt64{Int64: reaction.CommentID, Valid: true}
+		}
+		return n.Notify(ctx, notification)
+	}
+	return nil
+}
+
+// Subscribe returns a channel receiving the new notifications of the user, until cancel is called
+// or the notifier is closed, which closes the channel.
+func (n *Notifier) Subscribe(userID int64) (notifications <-chan repository.Notification, cancel func()) {
+	ch := make(chan repository.Notification, subscriberBuffer)
+	if n == nil {
+		close(ch)
+		return ch, func() {}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	if n.subscribers[userID] == nil {
+		n.subscribers[userID] = make(map[chan repository.Notification]struct{})
+	}
+	n.subscribers[userID][ch] = struct{}{}
+
+	return ch, func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		if _, ok := n.subscribers[userID][ch]; !ok {
+			return
+		}
+		delete(n.subscribers[userID], ch)
+		if len(n.subscribers[userID]) == 0 {
+			delete(n.subscribers, userID)
+		}
+		close(ch)
+	}
+}
+
+// Close ends every subscription, on shutdown so the live streams don't hold it up.
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.closed = true
+	for userID, chans := range n.subscribers {
+		for ch := range chans {
+			close(ch)
+		}
+		delete(n.subscribers, userID)
+	}
+}
+
+// publish hands the notification to the subscribers of its user. A subscriber that fell behind
+// misses it rather than holding up the request that caused it.
+func (n *Notifier) publish(notification repository.Notification) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for ch := range n.subscribers[notification.UserID] {
+		select {
+		case ch <- notification:
+		default:
+		}
+	}
+}
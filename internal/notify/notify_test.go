@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/outbox"
+)
+
+type fakeRepo struct {
+	stored []repository.NotificationsCreateParams
+	// fail, when set, is the error of the call with the same index, nil ones succeed
+	fail []error
+	call int
+}
+
+func (r *fakeRepo) NotificationsCreate(ctx context.Context, params repository.NotificationsCreateParams) (repository.Notification, error) {
+	r.call++
+	if r.call <= len(r.fail) && r.fail[r.call-1] != nil {
+		return repository.Notification{}, r.fail[r.call-1]
+	}
+	// Like idx_notifications_once
+	for _, stored := range r.stored {
+		if stored == params {
+			return repository.Notification{}, sql.ErrNoRows
+		}
+	}
+	r.stored = append(r.stored, params)
+	return repository.Notification{ID: int64(len(r.stored)), UserID: params.UserID, ActorID: params.ActorID, Type: params.Type}, nil
+}
+
+func TestNotifier(t *testing.T) {
+	repo := &fakeRepo{}
+	n := New(repo)
+
+	mine, cancel := n.Subscribe(1)
+	others, cancelOthers := n.Subscribe(2)
+	defer cancelOthers()
+
+	follow := repository.NotificationsCreateParams{UserID: 1, ActorID: 2, Type: TypeFollow}
+	assert.NoError(t, n.Notify(context.Background(), follow))
+	assert.Equal(t, repository.Notification{ID: 1, UserID: 1, ActorID: 2, Type: TypeFollow}, <-mine)
+	assert.Empty(t, others)
+
+	// Sent already, and users aren't notified of what they do themselves
+	assert.NoError(t, n.Notify(context.Background(), follow))
+	assert.NoError(t, n.Notify(context.Background(), repository.NotificationsCreateParams{UserID: 1, ActorID: 1, Type: TypeComment}))
+	assert.Len(t, repo.stored, 1)
+	assert.Empty(t, mine)
+
+	// A subscriber that fell behind misses notifications instead of blocking
+	for i := 0; i < subscriberBuffer+5; i++ {
+		n.Notify(context.Background(), repository.NotificationsCreateParams{UserID: 1, ActorID: 2, Type: TypeComment, CommentID: sql.NullInt64{Int64: int64(i), Valid: true}})
+	}
+	assert.Len(t, mine, subscriberBuffer)
+
+	cancel()
+	cancel()
+	_, open := <-drain(mine)
+	assert.False(t, open)
+
+	n.Close()
+	_, open = <-others
+	assert.False(t, open)
+	late, _ := n.Subscribe(1)
+	_, open = <-late
+	assert.False(t, open)
+}
+
+func TestHandleEvent(t *testing.T) {
+	repo := &fakeRepo{}
+	n := New(repo)
+	event := func(eventType string, payload string) outbox.Event {
+		return outbox.Event{ID: 1, Type: eventType, Payload: json.RawMessage(payload)}
+	}
+	post := sql.NullInt64{Int64: 10, Valid: true}
+
+	// A reply to the author of the post notifies them once
+	assert.NoError(t, n.HandleEvent(context.Background(), event(outbox.EventCommentCreated, `{"comment_id":5,"post_id":10,"user_id":2,"post_author_id":1,"parent_author_id":1}`)))
+	assert.NoError(t, n.HandleEvent(context.Background(), event(outbox.EventCommentCreated, `{"comment_id":6,"post_id":10,"user_id":2,"post_author_id":1,"parent_author_id":3}`)))
+	assert.NoError(t, n.HandleEvent(context.Background(), event(outbox.EventReactionAdded, `{"user_id":3,"type":"like","post_id":10,"comment_id":6,"author_id":2}`)))
+	assert.NoError(t, n.HandleEvent(context.Background(), event(outbox.EventUserFollowed, `{"user_id":1,"follower_id":2}`)))
+	assert.NoError(t, n.HandleEvent(context.Background(), event(outbox.EventPostCreated, `{"id":10}`)))
+	assert.Equal(t, []repository.NotificationsCreateParams{
+		{UserID: 1, ActorID: 2, Type: TypeReply, PostID: post, CommentID: sql.NullInt64{Int64: 5, Valid: true}},
+		{UserID: 3, ActorID: 2, Type: TypeReply, PostID: post, CommentID: sql.NullInt64{Int64: 6, Valid: true}},
+		{UserID: 1, ActorID: 2, Type: TypeComment, PostID: post, CommentID: sql.NullInt64{Int64: 6, Valid: true}},
+		{UserID: 2, ActorID: 3, Type: TypeReaction, PostID: post, CommentID: sql.NullInt64{Int64: 6, Valid: true}},
+		{UserID: 1, ActorID: 2, Type: TypeFollow},
+	}, repo.stored)
+
+	assert.Error(t, n.HandleEvent(context.Background(), event(outbox.EventUserFollowed, `{`)))
+}
+
+// An event handed over again after the subscriber failed partway stores and pushes what went through once
+func TestHandleEventRedelivered(t *testing.T) {
+	repo := &fakeRepo{fail: []error{nil, errors.New("database is locked")}}
+	n := New(repo)
+	replies, cancel := n.Subscribe(3)
+	defer cancel()
+
+	event := outbox.Event{ID: 1, Type: outbox.EventCommentCreated, Payload: json.RawMessage(`{"comment_id":6,"post_id":10,"user_id":2,"post_author_id":1,"parent_author_id":3}`)}
+	assert.Error(t, n.HandleEvent(context.Background(), event))
+	assert.NoError(t, n.HandleEvent(context.Background(), event))
+
+	post, comment := sql.NullInt64{Int64: 10, Valid: true}, sql.NullInt64{Int64: 6, Valid: true}
+	assert.Equal(t, []repository.NotificationsCreateParams{
+		{UserID: 3, ActorID: 2, Type: TypeReply, PostID: post, CommentID: comment},
+		{UserID: 1, ActorID: 2, Type: TypeComment, PostID: post, CommentID: comment},
+	}, repo.stored)
+	assert.Len(t, replies, 1)
+}
+
+func TestNilNotifier(t *testing.T) {
+	var n *Notifier
+	assert.NoError(t, n.Notify(context.Background(), repository.NotificationsCreateParams{UserID: 1, ActorID: 2, Type: TypeFollow}))
+	ch, cancel := n.Subscribe(1)
+	cancel()
+	_, open := <-ch
+	assert.False(t, open)
+	n.Close()
+}
+
+// drain skips what is buffered in a closed channel.
+func drain(ch <-chan repository.Notification) <-chan repository.Notification {
+	for range ch {
+	}
+	return ch
+}
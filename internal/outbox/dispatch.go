@@ -0,0 +1,242 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// batchSize is how many events are read at once
+const batchSize = 100
+
+// Config describes how events are dispatched and retried.
+type Config struct {
+	// How often the outbox is looked at (0 disables the dispatcher)
+	Interval time.Duration
+	// Attempts before an event is dead lettered for the subscribers still failing
+	MaxAttempts int
+	// Wait before the first retry, doubled for every further one
+	RetryBase time.Duration
+	// How long dispatched events are kept (0 keeps them)
+	Retention time.Duration
+}
+
+// ConfigFromEnv reads the dispatch configuration from OUTBOX_DISPATCH_INTERVAL (1s), OUTBOX_MAX_ATTEMPTS (5),
+// OUTBOX_RETRY_BASE (5s) and OUTBOX_RETENTION (168h).
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Interval:    time.Second,
+		MaxAttempts: 5,
+		RetryBase:   5 * time.Second,
+		Retention:   7 * 24 * time.Hour,
+	}
+	if interval, err := time.ParseDuration(os.Getenv("OUTBOX_DISPATCH_INTERVAL")); err == nil {
+		cfg.Interval = interval
+	}
+	if attempts, err := strconv.Atoi(os.Getenv("OUTBOX_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		cfg.MaxAttempts = attempts
+	}
+	if base, err := time.ParseDuration(os.Getenv("OUTBOX_RETRY_BASE")); err == nil && base > 0 {
+		cfg.RetryBase = base
+	}
+	if retention, err := time.ParseDuration(os.Getenv("OUTBOX_RETENTION")); err == nil && retention >= 0 {
+		cfg.Retention = retention
+	}
+	return cfg
+}
+
+// Backoff is the wait before the next attempt of an event that failed attempts times: RetryBase, then
+// twice as long after every further failure.
+func (cfg Config) Backoff(attempts int64) time.Duration {
+	wait := cfg.RetryBase
+	for i := int64(1); i < attempts && wait < time.Hour; i++ {
+		wait *= 2
+	}
+	return wait
+}
+
+// Subscriber handles an event. Events may be handed over more than once, when a subscriber fails after
+// doing part of its work or the server stops before the event is marked dispatched.
+type Subscriber func(ctx context.Context, event Event) error
+
+// DispatchRepo reads the due events and records how their dispatch went.
+type DispatchRepo interface {
+	OutboxDeadLettersCreate(ctx context.Context, params repository.OutboxDeadLettersCreateParams) error
+	OutboxDeleteDispatched(ctx context.Context, before sql.NullTime) (int64, error)
+	OutboxGetDue(ctx context.Context, params repository.OutboxGetDueParams) ([]repository.Outbox, error)
+	OutboxMarkDispatched(ctx context.Context, params repository.OutboxMarkDispatchedParams) error
+	OutboxRecordFailure(ctx context.Context, params repository.OutboxRecordFailureParams) error
+}
+
+// Dispatcher hands the events appended to the outbox to the subscribers. An event is only dispatched
+// once the earlier events of its aggregate are, a failing subscriber holds up its aggregate until the
+// event is dead lettered. A single one runs at a time.
+type Dispatcher struct {
+	repo        DispatchRepo
+	cfg         Config
+	names       []string
+	subscribers map[string]Subscriber
+}
+
+// NewDispatcher creates a dispatcher of the events stored in repo.
+func NewDispatcher(repo DispatchRepo, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		repo:        repo,
+		cfg:         cfg,
+		subscribers: make(map[string]Subscriber),
+	}
+}
+
+// Subscribe hands every event to fn from now on. The name identifies the subscriber in the outbox
+// and the dead letters, so it must not change between releases. Subscribe before Run.
+func (d *Dispatcher) Subscribe(name string, fn Subscriber) {
+	if _, ok := d.subscribers[name]; !ok {
+		d.names = append(d.names, name)
+	}
+	d.subscribers[name] = fn
+}
+
+// Run dispatches the due events every Interval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if d.cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.DispatchDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("Error dispatching outbox events: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Cleanup deletes the events dispatched more than Retention before now and returns how many there were.
+func (d *Dispatcher) Cleanup(ctx context.Context, now time.Time) (int64, error) {
+	if d.cfg.Retention <= 0 {
+		return 0, nil
+	}
+	return d.repo.OutboxDeleteDispatched(ctx, sql.NullTime{Time: now.UTC().Add(-d.cfg.Retention), Valid: true})
+}
+
+// DispatchDue dispatches the events due at now, until none are left, and returns how many were dispatched
+// (not counting the ones retried later).
+func (d *Dispatcher) DispatchDue(ctx context.Context, now time.Time) (int, error) {
+	total := 0
+	for {
+		// The next event of an aggregate is only due once the one before is dispatched
+		due, err := d.repo.OutboxGetDue(ctx, repository.OutboxGetDueParams{
+			Now:   sql.NullTime{Time: now.UTC(), Valid: true},
+			Limit: batchSize,
+		})
+		if err != nil {
+			return total, err
+		}
+
+		dispatched := 0
+		for _, row := range due {
+			ok, err := d.dispatch(ctx, row, now)
+			if err != nil {
+				return total, err
+			}
+			if ok {
+				dispatched++
+			}
+		}
+		total += dispatched
+		if dispatched == 0 {
+			return total, nil
+		}
+	}
+}
+
+// dispatch hands the event to the subscribers that didn't handle it yet and records how it went: dispatched,
+// retried later for the subscribers that failed, or dead lettered for them once out of attempts.
+// ok is false when the event is retried later.
+func (d *Dispatcher) dispatch(ctx context.Context, row repository.Outbox, now time.Time) (ok bool, err error) {
+	event := Event{
+		ID:            row.ID,
+		AggregateType: row.AggregateType,
+		AggregateID:   row.AggregateID,
+		Type:          row.Type,
+		Payload:       json.RawMessage(row.Payload),
+		CreatedAt:     row.CreatedAt.Time,
+	}
+	pending := d.names
+	if row.PendingSubscribers.Valid {
+		pending = strings.Split(row.PendingSubscribers.String, ",")
+	}
+
+	var failed []string
+	var errs []error
+	for _, name := range pending {
+		// Subscribers that are gone can't fail
+		fn, ok := d.subscribers[name]
+		if !ok {
+			continue
+		}
+		if err := fn(ctx, event); err != nil {
+			failed = append(failed, name)
+			errs = append(errs, err)
+		}
+	}
+	// Stopped halfway, the event is handed over again on the next start
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	attempts := row.Attempts + 1
+	var lastError sql.NullString
+	for i, err := range errs {
+		if i > 0 {
+			lastError.String += "; "
+		}
+		lastError.String += failed[i] + ": " + err.Error()
+		lastError.Valid = true
+	}
+	if len(failed) > 0 && attempts < int64(d.cfg.MaxAttempts) {
+		return false, d.repo.OutboxRecordFailure(ctx, repository.OutboxRecordFailureParams{
+			Attempts:           attempts,
+			PendingSubscribers: sql.NullString{String: strings.Join(failed, ","), Valid: true},
+			NextAttemptAt:      sql.NullTime{Time: now.UTC().Add(d.cfg.Backoff(attempts)), Valid: true},
+			LastError:          lastError,
+			ID:                 row.ID,
+		})
+	}
+
+	for i, name := range failed {
+		log.Printf("Dead lettering %s event %d for %s after %d attempts: %v", row.Type, row.ID, name, attempts, errs[i])
+		if err := d.repo.OutboxDeadLettersCreate(ctx, repository.OutboxDeadLettersCreateParams{
+			EventID:       row.ID,
+			AggregateType: row.AggregateType,
+			AggregateID:   row.AggregateID,
+			Type:          row.Type,
+			Payload:       row.Payload,
+			Subscriber:    name,
+			Attempts:      attempts,
+			Error:         errs[i].Error(),
+		}); err != nil {
+			return false, fmt.Errorf("dead letter event %d: %w", row.ID, err)
+		}
+	}
+	err = d.repo.OutboxMarkDispatched(ctx, repository.OutboxMarkDispatchedParams{
+		DispatchedAt: sql.NullTime{Time: now.UTC(), Valid: true},
+		Attempts:     attempts,
+		LastError:    lastError,
+		ID:           row.ID,
+	})
+	return err == nil, err
+}
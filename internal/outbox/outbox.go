@@ -0,0 +1,98 @@
+// Package outbox makes the side effects of writes reliable. Handlers append events to the outbox table
+// in the transaction of their write, so an event is stored if and only if the write is, and a dispatcher
+// hands them to the in-process subscribers (notifications, webhooks, cache invalidation) at least once,
+// in order per aggregate.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// Aggregates, what events are about. The events of one aggregate are dispatched in the order they were appended.
+const (
+	AggregateUser = "user"
+	AggregatePost = "post"
+)
+
+// Event types. The payload of user events is the user and the payload of post events the post, as served
+// by API v2, the other ones have a payload of their own below.
+const (
+	EventUserCreated    = "user.created"
+	EventUserUpdated    = "user.updated"
+	EventUserDeleted    = "user.deleted"
+	EventUserFollowed   = "user.followed"
+	EventPostCreated    = "post.created"
+	EventPostUpdated    = "post.updated"
+	EventCommentCreated = "comment.created"
+	EventReactionAdded  = "reaction.added"
+)
+
+// Followed is the payload of EventUserFollowed, appended to the followed user.
+type Followed struct {
+	UserID     int64 `json:"user_id"`
+	FollowerID int64 `json:"follower_id"`
+}
+
+// CommentCreated is the payload of EventCommentCreated, appended to the post commented on.
+type CommentCreated struct {
+	CommentID    int64 `json:"comment_id"`
+	PostID       int64 `json:"post_id"`
+	UserID       int64 `json:"user_id"`
+	PostAuthorID int64 `json:"post_author_id"`
+	// Author of the comment replied to, 0 for top level comments
+	ParentAuthorID int64 `json:"parent_author_id,omitempty"`
+}
+
+// ReactionAdded is the payload of EventReactionAdded, appended to the post reacted to or the post of the comment.
+type ReactionAdded struct {
+	UserID int64  `json:"user_id"`
+	Type   string `json:"type"`
+	PostID int64  `json:"post_id"`
+	// Comment reacted to, 0 for reactions to the post
+	CommentID int64 `json:"comment_id,omitempty"`
+	// Author of the post or comment reacted to
+	AuthorID int64 `json:"author_id"`
+}
+
+// Event is an event as handed to the subscribers.
+type Event struct {
+	ID            int64
+	AggregateType string
+	AggregateID   int64
+	Type          string
+	Payload       json.RawMessage
+	CreatedAt     time.Time
+}
+
+// Decode decodes the payload of the event into v.
+func (e Event) Decode(v any) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("decode %s event %d: %w", e.Type, e.ID, err)
+	}
+	return nil
+}
+
+// AppendRepo stores events, the queries of the transaction of the write they are about.
+type AppendRepo interface {
+	OutboxAppend(ctx context.Context, params repository.OutboxAppendParams) error
+}
+
+// Append stores an event with payload data about the aggregate. Call it with the queries of the
+// transaction of the write, the event is dispatched once that commits.
+func Append(ctx context.Context, q AppendRepo, aggregateType string, aggregateID int64, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", eventType, err)
+	}
+	return q.OutboxAppend(ctx, repository.OutboxAppendParams{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Type:          eventType,
+		Payload:       string(payload),
+	})
+}
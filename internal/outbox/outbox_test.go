@@ -0,0 +1,137 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"backendT/internal/database/repository"
+)
+
+type fakeRepo struct {
+	events      []repository.Outbox
+	deadLetters []repository.OutboxDeadLettersCreateParams
+}
+
+func (r *fakeRepo) OutboxAppend(ctx context.Context, params repository.OutboxAppendParams) error {
+	r.events = append(r.events, repository.Outbox{
+		ID:            int64(len(r.events) + 1),
+		AggregateType: params.AggregateType,
+		AggregateID:   params.AggregateID,
+		Type:          params.Type,
+		Payload:       params.Payload,
+	})
+	return nil
+}
+
+func (r *fakeRepo) OutboxDeadLettersCreate(ctx context.Context, params repository.OutboxDeadLettersCreateParams) error {
+	r.deadLetters = append(r.deadLetters, params)
+	return nil
+}
+
+func (r *fakeRepo) OutboxDeleteDispatched(ctx context.Context, before sql.NullTime) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeRepo) OutboxGetDue(ctx context.Context, params repository.OutboxGetDueParams) ([]repository.Outbox, error) {
+	var due []repository.Outbox
+	blocked := map[[2]any]bool{}
+	for _, event := range r.events {
+		if event.DispatchedAt.Valid {
+			continue
+		}
+		aggregate := [2]any{event.AggregateType, event.AggregateID}
+		if !blocked[aggregate] && !event.NextAttemptAt.Time.After(params.Now.Time) {
+			due = append(due, event)
+		}
+		blocked[aggregate] = true
+	}
+	return due, nil
+}
+
+func (r *fakeRepo) OutboxMarkDispatched(ctx context.Context, params repository.OutboxMarkDispatchedParams) error {
+	event := &r.events[params.ID-1]
+	event.DispatchedAt, event.Attempts, event.LastError = params.DispatchedAt, params.Attempts, params.LastError
+	event.PendingSubscribers = sql.NullString{}
+	return nil
+}
+
+func (r *fakeRepo) OutboxRecordFailure(ctx context.Context, params repository.OutboxRecordFailureParams) error {
+	event := &r.events[params.ID-1]
+	event.Attempts, event.PendingSubscribers, event.NextAttemptAt, event.LastError = params.Attempts, params.PendingSubscribers, params.NextAttemptAt, params.LastError
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRepo{}
+	assert.NoError(t, Append(ctx, repo, AggregatePost, 1, EventPostCreated, map[string]any{"id": 1}))
+	assert.NoError(t, Append(ctx, repo, AggregatePost, 1, EventPostUpdated, map[string]any{"id": 1}))
+	assert.NoError(t, Append(ctx, repo, AggregatePost, 2, EventPostCreated, map[string]any{"id": 2}))
+	assert.NoError(t, Append(ctx, repo, AggregateUser, 1, EventUserFollowed, Followed{UserID: 1, FollowerID: 2}))
+
+	var a, b []int64
+	failing := map[int64]int{1: 1, 4: 10}
+	d := NewDispatcher(repo, Config{MaxAttempts: 3, RetryBase: time.Minute})
+	d.Subscribe("a", func(ctx context.Context, event Event) error {
+		a = append(a, event.ID)
+		return nil
+	})
+	d.Subscribe("b", func(ctx context.Context, event Event) error {
+		b = append(b, event.ID)
+		if failing[event.ID] > 0 {
+			failing[event.ID]--
+			return errors.New("boom")
+		}
+		return nil
+	})
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	// The update of post 1 waits for its creation, post 2 doesn't
+	dispatched, err := d.DispatchDue(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	assert.Equal(t, []int64{1, 3, 4}, a)
+	assert.Equal(t, []int64{1, 3, 4}, b)
+	assert.Equal(t, "b", repo.events[0].PendingSubscribers.String)
+	assert.Equal(t, "b: boom", repo.events[0].LastError.String)
+	assert.Equal(t, now.Add(time.Minute), repo.events[0].NextAttemptAt.Time)
+
+	// Only the failed subscriber gets the retry
+	dispatched, err = d.DispatchDue(ctx, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, dispatched)
+	assert.Equal(t, []int64{1, 3, 4, 2}, a)
+	assert.Equal(t, []int64{1, 3, 4, 1, 4, 2}, b)
+	assert.True(t, repo.events[1].DispatchedAt.Valid)
+	assert.Equal(t, int64(2), repo.events[0].Attempts)
+
+	// Out of attempts, dead lettered for b alone
+	dispatched, err = d.DispatchDue(ctx, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	assert.True(t, repo.events[3].DispatchedAt.Valid)
+	if assert.Len(t, repo.deadLetters, 1) {
+		assert.Equal(t, int64(4), repo.deadLetters[0].EventID)
+		assert.Equal(t, "b", repo.deadLetters[0].Subscriber)
+		assert.Equal(t, int64(3), repo.deadLetters[0].Attempts)
+		assert.Equal(t, "boom", repo.deadLetters[0].Error)
+		assert.JSONEq(t, `{"user_id":1,"follower_id":2}`, repo.deadLetters[0].Payload)
+	}
+	assert.Equal(t, []int64{1, 3, 4, 2}, a)
+
+	dispatched, err = d.DispatchDue(ctx, now.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Zero(t, dispatched)
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := Config{RetryBase: 5 * time.Second}
+	assert.Equal(t, 5*time.Second, cfg.Backoff(1))
+	assert.Equal(t, 20*time.Second, cfg.Backoff(3))
+	assert.LessOrEqual(t, cfg.Backoff(1000), 2*time.Hour)
+}
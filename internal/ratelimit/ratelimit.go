@@ -0,0 +1,162 @@
+// Package ratelimit implements token bucket rate limits kept in memory or in the database.
+// Every key (a route group and a client identity) has a bucket of Burst tokens refilled at Rate tokens per second,
+// each request takes a token and is rejected when the bucket is empty.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Identity is what requests are grouped by, each identity gets its own bucket.
+type Identity string
+
+const (
+	ByIP     Identity = "ip"
+	ByAPIKey Identity = "api_key"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second holding at most Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Rule is the limit of a route group and the identity its buckets are keyed by.
+// A zero Limit means the group is not limited.
+type Rule struct {
+	Limit Limit
+	By    Identity
+}
+
+// Enabled reports whether the rule limits anything.
+func (r Rule) Enabled() bool {
+	return r.Limit.Rate > 0 && r.Limit.Burst > 0
+}
+
+// Policy describes the rule for the RateLimit-Policy header, e.g. "100;w=60".
+func (r Rule) Policy() string {
+	window := float64(r.Limit.Burst) / r.Limit.Rate
+	return fmt.Sprintf("%d;w=%d", r.Limit.Burst, int(math.Ceil(window)))
+}
+
+// RefillTime is how long an empty bucket takes to be full again.
+func (r Rule) RefillTime() time.Duration {
+	if !r.Enabled() {
+		return 0
+	}
+	return time.Duration(float64(r.Limit.Burst) / r.Limit.Rate * float64(time.Second))
+}
+
+var units = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "second": time.Second,
+	"m": time.Minute, "min": time.Minute, "minute": time.Minute,
+	"h": time.Hour, "hour": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour,
+}
+
+// ParseRule parses rules like "100/m", "10/s burst=20" or "1000/h by=api_key".
+// The burst defaults to the number of requests per period and the identity to the client ip.
+// "off" (or an empty string) disables the limit.
+func ParseRule(s string) (Rule, error) {
+	rule := Rule{By: ByIP}
+	fields := strings.Fields(s)
+	if len(fields) == 0 || fields[0] == "off" {
+		return rule, nil
+	}
+
+	count, unit, ok := strings.Cut(fields[0], "/")
+	n, err := strconv.Atoi(count)
+	period, known := units[strings.ToLower(unit)]
+	if !ok || err != nil || n <= 0 || !known {
+		return rule, fmt.Errorf("invalid rate %q, expected <requests>/<s|m|h|d>", fields[0])
+	}
+	rule.Limit = Limit{Rate: float64(n) / period.Seconds(), Burst: n}
+
+	for _, field := range fields[1:] {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "burst":
+			burst, err := strconv.Atoi(value)
+			if err != nil || burst <= 0 {
+				return rule, fmt.Errorf("invalid burst %q", value)
+			}
+			rule.Limit.Burst = burst
+		case "by":
+			switch id := Identity(value); id {
+			case ByIP, ByAPIKey:
+				rule.By = id
+			default:
+				return rule, fmt.Errorf("invalid identity %q, expected ip or api_key", value)
+			}
+		default:
+			return rule, fmt.Errorf("unknown option %q", field)
+		}
+	}
+	return rule, nil
+}
+
+// Result is the state of a bucket after taking a token.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+	// RetryAfter is the time until the next token, zero when the request was allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets.
+type Store interface {
+	// Take takes a token from the bucket of key.
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+	// Sweep forgets the buckets not used since before, returning how many were removed.
+	Sweep(ctx context.Context, before time.Time) (int64, error)
+}
+
+// take refills a bucket that had tokens at last, then tries to take one.
+// It returns the tokens left in the bucket.
+func take(tokens float64, last, now time.Time, l Limit) (float64, Result) {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens += elapsed * l.Rate
+	}
+	tokens = math.Min(tokens, float64(l.Burst))
+
+	res := Result{Limit: l.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / l.Rate)
+	}
+	res.Remaining = int(tokens)
+	res.Reset = seconds((float64(l.Burst) - tokens) / l.Rate)
+	return tokens, res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// RunSweeper removes the buckets idle for longer than idle every interval until ctx is cancelled.
+// idle should be at least the longest RefillTime, a bucket idle that long is full and the same as a missing one.
+func RunSweeper(ctx context.Context, store Store, interval, idle time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if _, err := store.Sweep(ctx, now.Add(-idle)); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"backendT/internal/database"
+)
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("120/m")
+	assert.NoError(t, err)
+	assert.Equal(t, Rule{Limit: Limit{Rate: 2, Burst: 120}, By: ByIP}, rule)
+	assert.Equal(t, "120;w=60", rule.Policy())
+	assert.Equal(t, time.Minute, rule.RefillTime())
+
+	rule, err = ParseRule("10/s burst=5 by=api_key")
+	assert.NoError(t, err)
+	assert.Equal(t, Rule{Limit: Limit{Rate: 10, Burst: 5}, By: ByAPIKey}, rule)
+
+	rule, err = ParseRule("off")
+	assert.NoError(t, err)
+	assert.False(t, rule.Enabled())
+
+	for _, invalid := range []string{"10", "10/week", "-1/s", "10/s burst=0", "10/s by=email", "10/s by=user", "10/s fast"} {
+		_, err := ParseRule(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	limit := Limit{Rate: 1, Burst: 2}
+	now := time.Unix(1700000000, 0)
+
+	res, err := store.Take(ctx, "a", limit, now)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}, res)
+
+	res, _ = store.Take(ctx, "a", limit, now)
+	assert.True(t, res.Allowed)
+	assert.Equal(t, 0, res.Remaining)
+
+	res, _ = store.Take(ctx, "a", limit, now)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, time.Second, res.RetryAfter)
+
+	// Other keys have their own bucket
+	res, _ = store.Take(ctx, "b", limit, now)
+	assert.True(t, res.Allowed)
+
+	// Half a second later there's still no full token, a second later there is one
+	res, _ = store.Take(ctx, "a", limit, now.Add(500*time.Millisecond))
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+	res, _ = store.Take(ctx, "a", limit, now.Add(time.Second))
+	assert.True(t, res.Allowed)
+
+	removed, err := store.Sweep(ctx, now.Add(time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), removed, "only b is idle")
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestSQLiteStore(t *testing.T) {
+	db := database.New("file:ratelimit?mode=memory&cache=shared")
+	defer db.Close()
+	testStore(t, NewSQLiteStore(db))
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// TxRunner runs fn in a transaction, database.Service implements it.
+type TxRunner interface {
+	WithTx(ctx context.Context, fn func(q *repository.Queries) error) error
+}
+
+// SQLiteStore keeps the buckets in the rate_limits table, so they survive restarts
+// and are shared by every instance using the same database.
+type SQLiteStore struct {
+	db TxRunner
+}
+
+func NewSQLiteStore(db TxRunner) *SQLiteStore {
+	return &SQLiteStore{db: db}
+}
+
+func (s *SQLiteStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	var res Result
+	err := s.db.WithTx(ctx, func(q *repository.Queries) error {
+		tokens, last := float64(limit.Burst), now
+		row, err := q.RateLimitsGet(ctx, key)
+		switch {
+		case err == nil:
+			tokens, last = row.Tokens, time.Unix(0, row.UpdatedAt)
+		case err != sql.ErrNoRows:
+			return err
+		}
+
+		tokens, res = take(tokens, last, now, limit)
+		return q.RateLimitsUpsert(ctx, repository.RateLimitsUpsertParams{
+			Key:       key,
+			Tokens:    tokens,
+			UpdatedAt: now.UnixNano(),
+		})
+	})
+	return res, err
+}
+
+func (s *SQLiteStore) Sweep(ctx context.Context, before time.Time) (int64, error) {
+	var removed int64
+	err := s.db.WithTx(ctx, func(q *repository.Queries) error {
+		var err error
+		removed, err = q.RateLimitsDeleteBefore(ctx, before.UnixNano())
+		return err
+	})
+	return removed, err
+}
@@ -0,0 +1,212 @@
+// Package redact masks sensitive values (passwords, tokens, emails...) before requests are logged or shipped to analytics.
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces every redacted value.
+const Mask = "[REDACTED]"
+
+// Default rules, extended (never replaced) by the REDACT_* env variables.
+var (
+	DefaultHeaders     = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-CSRF-Token"}
+	DefaultJSONKeys    = []string{"password", "passwd", "secret", "token", "access_token", "refresh_token", "api_key", "apikey", "authorization", "cookie", "email"}
+	DefaultQueryParams = []string{"password", "token", "access_token", "refresh_token", "api_key", "apikey", "secret", "email"}
+	DefaultPatterns    = []string{
+		// emails anywhere, e.g. in /users/email/:email paths (also url encoded) or error messages
+		`[A-Za-z0-9._%+\-]+(@|%40)[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`,
+		// bearer tokens
+		`(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*`,
+	}
+)
+
+// Redactor masks values by header name, JSON key, query parameter and regular expression.
+// Names are matched case-insensitively.
+type Redactor struct {
+	headers  map[string]bool
+	jsonKeys map[string]bool
+	params   map[string]bool
+	patterns []*regexp.Regexp
+
+	// matches "key": "value" pairs of the redacted keys in bodies that are not valid JSON (e.g. truncated)
+	keyValue *regexp.Regexp
+}
+
+// New creates a redactor from the given rules, invalid patterns are returned as an error.
+func New(headers, jsonKeys, params, patterns []string) (*Redactor, error) {
+	r := &Redactor{
+		headers:  lowerSet(headers),
+		jsonKeys: lowerSet(jsonKeys),
+		params:   lowerSet(params),
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	quoted := make([]string, 0, len(r.jsonKeys))
+	for key := range r.jsonKeys {
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+	if len(quoted) > 0 {
+		r.keyValue = regexp.MustCompile(`(?i)"(` + strings.Join(quoted, "|") + `)"\s*:\s*"(?:[^"\\]|\\.)*"?`)
+	}
+	return r, nil
+}
+
+// Default returns a redactor with only the default rules.
+func Default() *Redactor {
+	r, _ := New(DefaultHeaders, DefaultJSONKeys, DefaultQueryParams, DefaultPatterns)
+	return r
+}
+
+// FromEnv returns the default rules extended with the comma separated REDACT_HEADERS,
+// REDACT_JSON_KEYS and REDACT_QUERY_PARAMS and the REDACT_PATTERN regular expression.
+func FromEnv() (*Redactor, error) {
+	patterns := DefaultPatterns
+	if p := os.Getenv("REDACT_PATTERN"); p != "" {
+		patterns = append(append([]string{}, DefaultPatterns...), p)
+	}
+	return New(
+		append(append([]string{}, DefaultHeaders...), splitList(os.Getenv("REDACT_HEADERS"))...),
+		append(append([]string{}, DefaultJSONKeys...), splitList(os.Getenv("REDACT_JSON_KEYS"))...),
+		append(append([]string{}, DefaultQueryParams...), splitList(os.Getenv("REDACT_QUERY_PARAMS"))...),
+		patterns,
+	)
+}
+
+// HeaderNames returns the redacted header names (lower case).
+func (r *Redactor) HeaderNames() []string {
+	return keys(r.headers)
+}
+
+// JSONKeys returns the redacted JSON keys (lower case).
+func (r *Redactor) JSONKeys() []string {
+	return keys(r.jsonKeys)
+}
+
+// String masks everything matching the patterns.
+func (r *Redactor) String(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllString(s, Mask)
+	}
+	return s
+}
+
+// URI masks the values of redacted query parameters, then applies the patterns to the whole uri.
+func (r *Redactor) URI(uri string) string {
+	path, query, hasQuery := strings.Cut(uri, "?")
+	if hasQuery {
+		uri = path + "?" + r.Query(query)
+	}
+	return r.String(uri)
+}
+
+// Query masks the values of redacted parameters in a raw query string, keeping the parameter order.
+func (r *Redactor) Query(rawQuery string) string {
+	parts := strings.Split(rawQuery, "&")
+	for i, part := range parts {
+		key, _, hasValue := strings.Cut(part, "=")
+		if hasValue && r.params[strings.ToLower(key)] {
+			parts[i] = key + "=" + Mask
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// Headers returns a copy of h with redacted headers masked and the patterns applied to the rest.
+func (r *Redactor) Headers(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for name, values := range h {
+		masked := make([]string, len(values))
+		for i, v := range values {
+			if r.headers[strings.ToLower(name)] {
+				masked[i] = Mask
+			} else {
+				masked[i] = r.String(v)
+			}
+		}
+		out[name] = masked
+	}
+	return out
+}
+
+// Body masks a request or response body. JSON bodies have the values of redacted keys masked
+// at any depth, anything else (form bodies, text, truncated JSON) is masked as text.
+func (r *Redactor) Body(body []byte) []byte {
+	var doc any
+	if len(body) == 0 || json.Unmarshal(body, &doc) != nil {
+		return []byte(r.text(string(body)))
+	}
+
+	masked, err := json.Marshal(r.walk(doc))
+	if err != nil {
+		return []byte(r.text(string(body)))
+	}
+	return masked
+}
+
+// text masks redacted "key": "value" pairs and key=value parameters, then applies the patterns.
+func (r *Redactor) text(s string) string {
+	if r.keyValue != nil {
+		s = r.keyValue.ReplaceAllString(s, `"$1":"`+Mask+`"`)
+	}
+	return r.String(r.Query(s))
+}
+
+func (r *Redactor) walk(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if r.jsonKeys[strings.ToLower(key)] {
+				v[key] = Mask
+			} else {
+				v[key] = r.walk(value)
+			}
+		}
+		return v
+	case []any:
+		for i, value := range v {
+			v[i] = r.walk(value)
+		}
+		return v
+	case string:
+		return r.String(v)
+	default:
+		return v
+	}
+}
+
+func lowerSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(strings.TrimSpace(v))] = true
+	}
+	return set
+}
+
+func keys(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for k := range set {
+		out = append(out, k)
+	}
+	return out
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
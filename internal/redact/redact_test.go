@@ -0,0 +1,65 @@
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURI(t *testing.T) {
+	r := Default()
+
+	assert.Equal(t, "/users/email/"+Mask, r.URI("/users/email/jane@example.com"))
+	assert.Equal(t, "/users/email/"+Mask, r.URI("/users/email/jane%40example.com"))
+	assert.Equal(t, "/logs?token="+Mask+"&limit=10&Password="+Mask, r.URI("/logs?token=abc&limit=10&Password=hunter2"))
+	assert.Equal(t, "/posts?offset=0", r.URI("/posts?offset=0"))
+}
+
+func TestHeaders(t *testing.T) {
+	r := Default()
+
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc.def")
+	h.Set("Cookie", "session=123")
+	h.Set("X-Forwarded-For", "1.2.3.4")
+
+	masked := r.Headers(h)
+	assert.Equal(t, Mask, masked.Get("Authorization"))
+	assert.Equal(t, Mask, masked.Get("Cookie"))
+	assert.Equal(t, "1.2.3.4", masked.Get("X-Forwarded-For"))
+	assert.Equal(t, "Bearer abc.def", h.Get("Authorization"), "the original headers are left alone")
+}
+
+func TestBody(t *testing.T) {
+	r := Default()
+
+	body := r.Body([]byte(`{"username":"jane","email":"jane@example.com","nested":{"Password":"hunter2","note":"mail me at jane@example.com"},"list":[{"token":"abc"}]}`))
+
+	var doc map[string]any
+	assert.NoError(t, json.Unmarshal(body, &doc))
+	assert.Equal(t, "jane", doc["username"])
+	assert.Equal(t, Mask, doc["email"])
+	assert.Equal(t, Mask, doc["nested"].(map[string]any)["Password"])
+	assert.Equal(t, "mail me at "+Mask, doc["nested"].(map[string]any)["note"])
+	assert.Equal(t, Mask, doc["list"].([]any)[0].(map[string]any)["token"])
+
+	assert.Equal(t, "password for "+Mask, string(r.Body([]byte("password for jane@example.com"))))
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("REDACT_QUERY_PARAMS", "ssn")
+	t.Setenv("REDACT_JSON_KEYS", "iban")
+	t.Setenv("REDACT_PATTERN", `\d{3}-\d{2}-\d{4}`)
+
+	r, err := FromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "/x?ssn="+Mask+"&email="+Mask, r.URI("/x?ssn=1&email=2"))
+	assert.Equal(t, "id "+Mask, r.String("id 123-45-6789"))
+	assert.JSONEq(t, `{"iban":"`+Mask+`"}`, string(r.Body([]byte(`{"iban":"DE00"}`))))
+
+	t.Setenv("REDACT_PATTERN", `(`)
+	_, err = FromEnv()
+	assert.Error(t, err)
+}
@@ -0,0 +1,207 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/redact"
+)
+
+// RequestRecord is what analytics sinks receive about every handled request.
+type RequestRecord struct {
+	Timestamp    time.Time `json:"timestamp"`
+	RequestID    string    `json:"request_id"`
+	RemoteIP     string    `json:"remote_ip"`
+	Host         string    `json:"host"`
+	Method       string    `json:"method"`
+	URI          string    `json:"uri"`
+	UserAgent    string    `json:"user_agent"`
+	Status       int       `json:"status"`
+	Error        string    `json:"error,omitempty"`
+	Latency      int64     `json:"latency"`
+	LatencyHuman string    `json:"latency_human"`
+	BytesIn      int64     `json:"bytes_in"`
+	BytesOut     int64     `json:"bytes_out"`
+
+	// Only set when payload capture is enabled (LOG_PAYLOADS)
+	Payload *RequestPayload `json:"payload,omitempty"`
+}
+
+// AnalyticsSink ships request records somewhere (the logs table, a file, a webhook...).
+// Record is called after the response is written, errors are only logged.
+type AnalyticsSink interface {
+	Name() string
+	Record(ctx context.Context, rec *RequestRecord) error
+}
+
+// analyticsWrapper is implemented by sinks that need to see the raw request and response
+// themselves (like Treblle's middleware), they wrap the handler instead of receiving records.
+type analyticsWrapper interface {
+	Wrap(next echo.HandlerFunc) echo.HandlerFunc
+}
+
+// AnalyticsMiddleware records every request into the given sinks.
+// Records are redacted before any sink sees them.
+func (s *Server) AnalyticsMiddleware(sinks ...AnalyticsSink) echo.MiddlewareFunc {
+	redactor := s.redactor()
+	payloads := payloadCaptureFromEnv()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		for _, sink := range sinks {
+			if wrapper, ok := sink.(analyticsWrapper); ok {
+				next = wrapper.Wrap(next)
+			}
+		}
+
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			var capture *capturing
+			if payloads != nil {
+				capture = payloads.start(c)
+			}
+
+			// Process the request
+			err := serve(next, c)
+			aborted := err == http.ErrAbortHandler
+			if err != nil && !aborted {
+				c.Error(err)
+			}
+
+			rec := newRequestRecord(c, start, err)
+			redactRecord(redactor, rec)
+			if capture != nil {
+				rec.Payload = capture.finish(c, redactor)
+			}
+			for _, sink := range sinks {
+				if sinkErr := sink.Record(c.Request().Context(), rec); sinkErr != nil {
+					log.Printf("Error recording request in %s sink: %v", sink.Name(), sinkErr)
+				}
+			}
+
+			if aborted {
+				panic(http.ErrAbortHandler)
+			}
+			return err
+		}
+	}
+}
+
+// serve runs the handler. A response it aborts (see stream.JSONArray) is still recorded, so the panic is
+// held back until then and returned as http.ErrAbortHandler.
+func serve(next echo.HandlerFunc, c echo.Context) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r != http.ErrAbortHandler {
+				panic(r)
+			}
+			err = http.ErrAbortHandler
+		}
+	}()
+	return next(c)
+}
+
+// LoggingMiddleware records every request into the logs table.
+func (s *Server) LoggingMiddleware() echo.MiddlewareFunc {
+	return s.AnalyticsMiddleware(s.newLogsSink())
+}
+
+func newRequestRecord(c echo.Context, start time.Time, err error) *RequestRecord {
+	latency := time.Since(start)
+	rec := &RequestRecord{
+		Timestamp:    start.UTC(),
+		RequestID:    c.Response().Header().Get(echo.HeaderXRequestID),
+		RemoteIP:     c.RealIP(),
+		Host:         c.Request().Host,
+		Method:       c.Request().Method,
+		URI:          c.Request().RequestURI,
+		UserAgent:    c.Request().UserAgent(),
+		Status:       c.Response().Status,
+		Latency:      latency.Microseconds(),
+		LatencyHuman: latency.String(),
+		BytesIn:      c.Request().ContentLength,
+		BytesOut:     c.Response().Size,
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	return rec
+}
+
+// redactRecord masks sensitive values in the parts of a record that come from the client.
+func redactRecord(r *redact.Redactor, rec *RequestRecord) {
+	rec.URI = r.URI(rec.URI)
+	rec.UserAgent = r.String(rec.UserAgent)
+	rec.Error = r.String(rec.Error)
+}
+
+// redactor returns the redaction rules from the env, creating them on first use.
+// Broken custom rules fall back to the defaults so nothing sensitive leaks because of a typo.
+func (s *Server) redactor() *redact.Redactor {
+	if s.redaction == nil {
+		r, err := redact.FromEnv()
+		if err != nil {
+			log.Printf("Invalid REDACT_* configuration, using default redaction rules: %v", err)
+			r = redact.Default()
+		}
+		s.redaction = r
+	}
+	return s.redaction
+}
+
+// analyticsSinksFromEnv builds the sinks listed in ANALYTICS_SINKS (comma separated: logs, treblle, file, webhook).
+// Without it requests go to the logs table, and to Treblle when its tokens are set.
+func (s *Server) analyticsSinksFromEnv() []AnalyticsSink {
+	names := os.Getenv("ANALYTICS_SINKS")
+	if names == "" {
+		names = "logs"
+		if os.Getenv("TREBLLE_SDK_TOKEN") != "" && os.Getenv("TREBLLE_API_KEY") != "" {
+			names += ",treblle"
+		}
+	}
+
+	var sinks []AnalyticsSink
+	for _, name := range strings.Split(names, ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "logs":
+			sinks = append(sinks, s.newLogsSink())
+		case "treblle":
+			sinks = append(sinks, newTreblleSink(os.Getenv("TREBLLE_SDK_TOKEN"), os.Getenv("TREBLLE_API_KEY"), s.redactor()))
+		case "file":
+			path := os.Getenv("ANALYTICS_FILE")
+			if path == "" {
+				path = "./db/analytics.jsonl"
+			}
+			sink, err := newFileSink(path)
+			if err != nil {
+				log.Printf("Error creating file analytics sink: %v", err)
+				continue
+			}
+			// Closed after the last request, see waitWorkers
+			ctx := s.sinksContext()
+			s.workers.Go(func() { sink.closeOn(ctx) })
+			sinks = append(sinks, sink)
+		case "webhook":
+			url := os.Getenv("ANALYTICS_WEBHOOK_URL")
+			if url == "" {
+				log.Printf("ANALYTICS_WEBHOOK_URL is not set, webhook analytics sink disabled")
+				continue
+			}
+			sink := newWebhookSink(url)
+			// Flushed after the last request, see waitWorkers
+			ctx := s.sinksContext()
+			s.workers.Go(func() { sink.run(ctx) })
+			sinks = append(sinks, sink)
+		default:
+			log.Printf("Unknown analytics sink %q", name)
+		}
+	}
+	return sinks
+}
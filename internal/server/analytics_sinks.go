@@ -0,0 +1,285 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Treblle/treblle-go/v2"
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/redact"
+)
+
+// logsSink stores records in the local logs table.
+type logsSink struct {
+	s *Server
+}
+
+func (s *Server) newLogsSink() *logsSink {
+	return &logsSink{s: s}
+}
+
+func (l *logsSink) Name() string { return "logs" }
+
+func (l *logsSink) Record(ctx context.Context, rec *RequestRecord) error {
+	entry := repository.LogsCreateParams{
+		RequestID:    sql.NullString{String: rec.RequestID, Valid: true},
+		RemoteIp:     sql.NullString{String: rec.RemoteIP, Valid: true},
+		Host:         sql.NullString{String: rec.Host, Valid: true},
+		Method:       sql.NullString{String: rec.Method, Valid: true},
+		Uri:          sql.NullString{String: rec.URI, Valid: true},
+		UserAgent:    sql.NullString{String: rec.UserAgent, Valid: true},
+		Status:       sql.NullInt64{Int64: int64(rec.Status), Valid: true},
+		Error:        sql.NullString{String: rec.Error, Valid: rec.Error != ""},
+		Latency:      sql.NullInt64{Int64: rec.Latency, Valid: true},
+		LatencyHuman: sql.NullString{String: rec.LatencyHuman, Valid: true},
+		BytesIn:      sql.NullInt64{Int64: rec.BytesIn, Valid: true},
+		BytesOut:     sql.NullInt64{Int64: rec.BytesOut, Valid: true},
+	}
+
+	// The pending counter and the outcome of the write are the log writer health reported by /health/ready
+	l.s.pendingLogWrites.Add(1)
+	defer l.s.pendingLogWrites.Add(-1)
+	err := l.write(ctx, entry, rec.Payload)
+	l.s.logWriter.record(err)
+	return err
+}
+
+// write stores the log entry, and its payload when there is one. Both go in one transaction, so there never
+// is a log without the payload that was captured for it.
+func (l *logsSink) write(ctx context.Context, entry repository.LogsCreateParams, payload *RequestPayload) error {
+	if payload == nil {
+		_, err := l.s.db.GetRepositoryRW().LogsCreate(ctx, entry)
+		return err
+	}
+
+	requestHeaders, _ := json.Marshal(payload.RequestHeaders)
+	responseHeaders, _ := json.Marshal(payload.ResponseHeaders)
+	return l.s.db.WithTx(ctx, func(q *repository.Queries) error {
+		created, err := q.LogsCreate(ctx, entry)
+		if err != nil {
+			return err
+		}
+		_, err = q.LogPayloadsCreate(ctx, repository.LogPayloadsCreateParams{
+			LogID:                 created.ID,
+			RequestHeaders:        sql.NullString{String: string(requestHeaders), Valid: true},
+			RequestBody:           sql.NullString{String: payload.RequestBody, Valid: payload.RequestBody != ""},
+			RequestBodyTruncated:  payload.RequestBodyTruncated,
+			ResponseHeaders:       sql.NullString{String: string(responseHeaders), Valid: true},
+			ResponseBody:          sql.NullString{String: payload.ResponseBody, Valid: payload.ResponseBody != ""},
+			ResponseBodyTruncated: payload.ResponseBodyTruncated,
+		})
+		return err
+	})
+}
+
+// fileSink appends records as JSON lines to a local file.
+type fileSink struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+func newFileSink(path string) (*fileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileSink{file: file, enc: json.NewEncoder(file)}, nil
+}
+
+// closeOn closes the file once ctx is cancelled, records still coming in after that are refused.
+func (f *fileSink) closeOn(ctx context.Context) {
+	<-ctx.Done()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.file.Close(); err != nil {
+		log.Printf("Error closing analytics file: %v", err)
+	}
+}
+
+func (f *fileSink) Name() string { return "file" }
+
+func (f *fileSink) Record(ctx context.Context, rec *RequestRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.enc.Encode(rec)
+}
+
+// webhookSink posts records in batches as a JSON array to an http endpoint.
+// Records are queued so slow receivers never hold up requests, when the queue is full they are dropped.
+type webhookSink struct {
+	url    string
+	client *http.Client
+	queue  chan *RequestRecord
+}
+
+const (
+	webhookSinkQueueSize     = 1000
+	webhookSinkBatchSize     = 50
+	webhookSinkFlushInterval = 2 * time.Second
+)
+
+func newWebhookSink(url string) *webhookSink {
+	return &webhookSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan *RequestRecord, webhookSinkQueueSize),
+	}
+}
+
+func (w *webhookSink) Name() string { return "webhook" }
+
+func (w *webhookSink) Record(ctx context.Context, rec *RequestRecord) error {
+	select {
+	case w.queue <- rec:
+		return nil
+	default:
+		return fmt.Errorf("queue is full, record dropped")
+	}
+}
+
+// run sends the queued records in batches until ctx is cancelled, then sends whatever is left.
+func (w *webhookSink) run(ctx context.Context) {
+	ticker := time.NewTicker(webhookSinkFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*RequestRecord, 0, webhookSinkBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.send(batch); err != nil {
+			log.Printf("Error sending %d records to analytics webhook: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Send whatever is still queued before stopping
+			for {
+				select {
+				case rec := <-w.queue:
+					batch = append(batch, rec)
+				default:
+					flush()
+					return
+				}
+			}
+		case rec := <-w.queue:
+			batch = append(batch, rec)
+			if len(batch) >= webhookSinkBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (w *webhookSink) send(batch []*RequestRecord) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.url, echo.MIMEApplicationJSON, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// treblleSink forwards requests to Treblle, it wraps the handler with Treblle's net/http middleware.
+// Treblle only gets a redacted copy of the request, and masks the redacted keys in responses itself.
+type treblleSink struct {
+	redactor *redact.Redactor
+}
+
+func newTreblleSink(sdkToken, apiKey string, redactor *redact.Redactor) *treblleSink {
+	treblle.Configure(treblle.Configuration{
+		SDK_TOKEN:              sdkToken,
+		API_KEY:                apiKey,
+		AdditionalFieldsToMask: append(redactor.JSONKeys(), redactor.HeaderNames()...),
+		Debug:                  false,
+	})
+	return &treblleSink{redactor: redactor}
+}
+
+func (t *treblleSink) Name() string { return "treblle" }
+
+// Record does nothing, Treblle collects everything in Wrap.
+func (t *treblleSink) Record(ctx context.Context, rec *RequestRecord) error { return nil }
+
+func (t *treblleSink) Wrap(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Read and buffer the request body so both Treblle and Echo handlers can consume it.
+		var bodyBytes []byte
+		if c.Request().Body != nil {
+			var err error
+			bodyBytes, err = io.ReadAll(c.Request().Body)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "failed to read request body",
+				})
+			}
+		}
+		// restore body for Echo handlers
+		c.Request().Body = io.NopCloser(bytes.NewReader(bodyBytes))
+		// Treblle only ever sees the redacted body
+		treblleBody := t.redactor.Body(bodyBytes)
+
+		h := treblle.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// give Treblle a copy of the request body
+			if len(bodyBytes) > 0 {
+				r.Body = io.NopCloser(bytes.NewReader(treblleBody))
+			}
+
+			// Replace Echo's underlying response writer with Treblle's wrapped writer
+			originalWriter := c.Response().Writer
+			c.Response().Writer = w
+
+			if err := next(c); err != nil {
+				// let Echo handle the error
+				c.Error(err)
+			}
+
+			// restore original writer after the handler completes
+			c.Response().Writer = originalWriter
+		}))
+
+		// Use Echo's underlying response writer and a redacted request copy for Treblle
+		reqForTreblle := c.Request().Clone(c.Request().Context())
+		reqForTreblle.Header = t.redactor.Headers(reqForTreblle.Header)
+		reqForTreblle.URL.RawQuery = t.redactor.Query(reqForTreblle.URL.RawQuery)
+		if len(bodyBytes) > 0 {
+			reqForTreblle.Body = io.NopCloser(bytes.NewReader(treblleBody))
+		}
+		h.ServeHTTP(c.Response().Writer, reqForTreblle)
+
+		return nil
+	}
+}
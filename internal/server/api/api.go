@@ -0,0 +1,81 @@
+// Package api is the JSON representation of the resources served from API v2 on, decoupled from the
+// repository rows: nullable columns are null or a plain value and times are RFC 3339 in UTC.
+// v1 keeps serving the rows as they are, handlers go through Render to serve whichever the request asked for.
+package api
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// versionKey holds the API version serving the request in the echo.Context.
+const versionKey = "api_version"
+
+// SetVersion records the API version serving the request, the versioned route groups call it.
+func SetVersion(c echo.Context, version string) {
+	c.Set(versionKey, version)
+}
+
+// Legacy reports whether the request is served by v1, whose responses are the repository rows.
+// Requests outside of a versioned group are too.
+func Legacy(c echo.Context) bool {
+	version, _ := c.Get(versionKey).(string)
+	return version == "" || version == "v1"
+}
+
+// Render returns what v is served as: the row itself for v1, its model from v2 on.
+func Render[T, M any](c echo.Context, v T, model func(T) M) any {
+	if Legacy(c) {
+		return v
+	}
+	return model(v)
+}
+
+// RenderAll is Render for a list, an empty list is [] and never null from v2 on.
+func RenderAll[T, M any](c echo.Context, vs []T, model func(T) M) any {
+	if Legacy(c) {
+		return vs
+	}
+	models := make([]M, len(vs))
+	for i, v := range vs {
+		models[i] = model(v)
+	}
+	return models
+}
+
+// Each is Render for the rows of a streamed response, handed out one by one by each.
+func Each[T, M any](c echo.Context, each func(context.Context, func(T) error) error, model func(T) M) func(context.Context, func(any) error) error {
+	return func(ctx context.Context, fn func(any) error) error {
+		return each(ctx, func(v T) error {
+			return fn(Render(c, v, model))
+		})
+	}
+}
+
+// Time is t in UTC, or nil when it is NULL.
+func Time(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	utc := t.Time.UTC()
+	return &utc
+}
+
+// String is s, or nil when it is NULL.
+func String(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// Int64 is i, or nil when it is NULL.
+func Int64(i sql.NullInt64) *int64 {
+	if !i.Valid {
+		return nil
+	}
+	return &i.Int64
+}
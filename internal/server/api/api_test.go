@@ -0,0 +1,99 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"backendT/internal/database/repository"
+)
+
+func TestRender(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	user := repository.User{ID: 1, Username: "test", Email: "test@example.com"}
+
+	// Outside of a versioned group and on v1 the row is served as it is
+	assert.Equal(t, user, Render(c, user, NewUser))
+	SetVersion(c, "v1")
+	assert.Equal(t, []repository.User(nil), RenderAll(c, []repository.User(nil), NewUser))
+
+	SetVersion(c, "v2")
+	assert.Equal(t, NewUser(user), Render(c, user, NewUser))
+	assert.Equal(t, []User{}, RenderAll(c, []repository.User(nil), NewUser))
+}
+
+func TestModelsJSON(t *testing.T) {
+	created := time.Date(2025, 1, 31, 13, 0, 0, 0, time.FixedZone("CET", 3600))
+	body, err := json.Marshal(NewUser(repository.User{
+		ID:        1,
+		Username:  "test",
+		Email:     "test@example.com",
+		CreatedAt: sql.NullTime{Time: created, Valid: true},
+	}))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"username":"test","email":"test@example.com","display_name":null,"bio":null,
+		"avatar_url":null,"avatar_thumbnail_url":null,"created_at":"2025-01-31T12:00:00Z","updated_at":null}`, string(body))
+
+	user := NewUser(repository.User{Avatar: sql.NullString{String: "abc.jpg", Valid: true}})
+	assert.Equal(t, "/avatars/abc.jpg", *user.AvatarURL)
+	assert.Equal(t, "/avatars/abc_thumb.jpg", *user.AvatarThumbnailURL)
+
+	body, err = json.Marshal(NewLogDetail(repository.Log{
+		ID:     2,
+		Method: sql.NullString{String: "GET", Valid: true},
+		Status: sql.NullInt64{Int64: 200, Valid: true},
+	}, &repository.LogPayload{
+		LogID:          2,
+		RequestHeaders: sql.NullString{String: `{"Accept":["application/json"]}`, Valid: true},
+		ResponseBody:   sql.NullString{String: `{"ok":true}`, Valid: true},
+	}))
+	assert.NoError(t, err)
+
+	var detail map[string]any
+	assert.NoError(t, json.Unmarshal(body, &detail))
+	assert.Equal(t, "GET", detail["method"])
+	assert.Equal(t, float64(200), detail["status"])
+	assert.Nil(t, detail["timestamp"])
+	assert.Nil(t, detail["error"])
+
+	payload := detail["payload"].(map[string]any)
+	assert.Equal(t, map[string]any{"Accept": []any{"application/json"}}, payload["request_headers"])
+	assert.Nil(t, payload["response_headers"])
+	assert.Nil(t, payload["request_body"])
+	assert.Equal(t, `{"ok":true}`, payload["response_body"])
+}
+
+func TestPagination(t *testing.T) {
+	e := echo.New()
+	limit := func(target string) (int64, bool) {
+		return Limit(e.NewContext(httptest.NewRequest(http.MethodGet, target, nil), httptest.NewRecorder()))
+	}
+	n, ok := limit("/")
+	assert.True(t, ok)
+	assert.Equal(t, int64(20), n)
+	n, ok = limit("/?limit=100")
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), n)
+	_, ok = limit("/?limit=101")
+	assert.False(t, ok)
+
+	cursor := EncodeCursor("2025-01-31 12:00:00", "42")
+	key, ok := DecodeCursor(cursor, 2)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"2025-01-31 12:00:00", "42"}, key)
+	_, ok = DecodeCursor(cursor, 1)
+	assert.False(t, ok)
+	_, ok = DecodeCursor("not base64!", 1)
+	assert.False(t, ok)
+
+	rec := httptest.NewRecorder()
+	SetNextPage(e.NewContext(httptest.NewRequest(http.MethodGet, "/v2/feed?limit=5&cursor=old", nil), rec), "next")
+	assert.Equal(t, `</v2/feed?cursor=next&limit=5>; rel="next"`, rec.Header().Get(HeaderLink))
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// Comment is a comment as served by the API, with its replies when served as part of a tree.
+type Comment struct {
+	ID        int64      `json:"id" example:"1"`
+	PostID    int64      `json:"post_id" example:"1"`
+	UserID    int64      `json:"user_id" example:"1"`
+	ParentID  *int64     `json:"parent_id" example:"1" extensions:"x-nullable"`
+	Comment   string     `json:"comment" example:"Nice post!"`
+	CreatedAt *time.Time `json:"created_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	Replies   []Comment  `json:"replies"`
+}
+
+func NewComment(c repository.Comment) Comment {
+	return Comment{
+		ID:        c.ID,
+		PostID:    c.PostID,
+		UserID:    c.UserID,
+		ParentID:  Int64(c.ParentID),
+		Comment:   c.Comment,
+		CreatedAt: Time(c.CreatedAt),
+		Replies:   []Comment{},
+	}
+}
+
+// CommentTree nests the comments of a post under the ones they reply to, in the order given.
+// Replies whose parent is missing (because it is hidden) are left out with it.
+func CommentTree(comments []repository.Comment) []Comment {
+	children := map[int64][]repository.Comment{}
+	for _, c := range comments {
+		children[c.ParentID.Int64] = append(children[c.ParentID.Int64], c)
+	}
+	var build func(parentID int64) []Comment
+	build = func(parentID int64) []Comment {
+		tree := []Comment{}
+		for _, c := range children[parentID] {
+			comment := NewComment(c)
+			comment.Replies = build(c.ID)
+			tree = append(tree, comment)
+		}
+		return tree
+	}
+	// Top level comments have no parent, which reads as parent 0
+	return build(0)
+}
+
+// ReportedComment is a comment waiting in the moderation queue with its open reports.
+type ReportedComment struct {
+	Comment
+	ReportCount int64    `json:"report_count" example:"2"`
+	Reasons     []string `json:"reasons" example:"spam"`
+}
+
+func NewReportedComment(r repository.CommentReportsGetQueueRow) ReportedComment {
+	comment := NewComment(repository.Comment{
+		ID:        r.ID,
+		PostID:    r.PostID,
+		UserID:    r.UserID,
+		Comment:   r.Comment,
+		CreatedAt: r.CreatedAt,
+		ParentID:  r.ParentID,
+	})
+	return ReportedComment{
+		Comment:     comment,
+		ReportCount: r.ReportCount,
+		Reasons:     strings.Split(r.Reasons, "\n"),
+	}
+}
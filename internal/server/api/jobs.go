@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// Job is a background job with the outcome of its last attempt.
+type Job struct {
+	ID          int64           `json:"id" example:"1"`
+	Kind        string          `json:"kind" example:"outbox.cleanup"`
+	Payload     json.RawMessage `json:"payload" swaggertype:"object"`
+	Priority    int64           `json:"priority" example:"0"`
+	UniqueKey   *string         `json:"unique_key" example:"schedule:outbox.cleanup" extensions:"x-nullable"`
+	Status      string          `json:"status" example:"pending" enums:"pending,running,succeeded,failed,cancelled"`
+	Attempts    int64           `json:"attempts" example:"1"`
+	MaxAttempts int64           `json:"max_attempts" example:"5"`
+	RunAt       *time.Time      `json:"run_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	LockedBy    *string         `json:"locked_by" example:"api-1:4242:1f2e-17" extensions:"x-nullable"`
+	LockedUntil *time.Time      `json:"locked_until" example:"2025-01-31T12:01:00Z" format:"date-time" extensions:"x-nullable"`
+	LastError   *string         `json:"last_error" example:"database is locked" extensions:"x-nullable"`
+	CreatedAt   *time.Time      `json:"created_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	UpdatedAt   *time.Time      `json:"updated_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	FinishedAt  *time.Time      `json:"finished_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+}
+
+func NewJob(j repository.Job) Job {
+	return Job{
+		ID:          j.ID,
+		Kind:        j.Kind,
+		Payload:     json.RawMessage(j.Payload),
+		Priority:    j.Priority,
+		UniqueKey:   String(j.UniqueKey),
+		Status:      j.Status,
+		Attempts:    j.Attempts,
+		MaxAttempts: j.MaxAttempts,
+		RunAt:       Time(j.RunAt),
+		LockedBy:    String(j.LockedBy),
+		LockedUntil: Time(j.LockedUntil),
+		LastError:   String(j.LastError),
+		CreatedAt:   Time(j.CreatedAt),
+		UpdatedAt:   Time(j.UpdatedAt),
+		FinishedAt:  Time(j.FinishedAt),
+	}
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// Log is every column of a logged request.
+type Log struct {
+	ID           int64      `json:"id" example:"1"`
+	Timestamp    *time.Time `json:"timestamp" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	RequestID    *string    `json:"request_id" example:"3mJ6x0Qz5yVbR8cT1kLw" extensions:"x-nullable"`
+	RemoteIP     *string    `json:"remote_ip" example:"192.0.2.1" extensions:"x-nullable"`
+	Host         *string    `json:"host" example:"localhost:8080" extensions:"x-nullable"`
+	Method       *string    `json:"method" example:"GET" extensions:"x-nullable"`
+	URI          *string    `json:"uri" example:"/users/id/1" extensions:"x-nullable"`
+	UserAgent    *string    `json:"user_agent" example:"curl/8.5.0" extensions:"x-nullable"`
+	Status       *int64     `json:"status" example:"200" extensions:"x-nullable"`
+	Error        *string    `json:"error" extensions:"x-nullable"`
+	Latency      *int64     `json:"latency" example:"182000" extensions:"x-nullable"`
+	LatencyHuman *string    `json:"latency_human" example:"182µs" extensions:"x-nullable"`
+	BytesIn      *int64     `json:"bytes_in" example:"0" extensions:"x-nullable"`
+	BytesOut     *int64     `json:"bytes_out" example:"96" extensions:"x-nullable"`
+}
+
+func NewLog(l repository.Log) Log {
+	return Log{
+		ID:           l.ID,
+		Timestamp:    Time(l.Timestamp),
+		RequestID:    String(l.RequestID),
+		RemoteIP:     String(l.RemoteIp),
+		Host:         String(l.Host),
+		Method:       String(l.Method),
+		URI:          String(l.Uri),
+		UserAgent:    String(l.UserAgent),
+		Status:       Int64(l.Status),
+		Error:        String(l.Error),
+		Latency:      Int64(l.Latency),
+		LatencyHuman: String(l.LatencyHuman),
+		BytesIn:      Int64(l.BytesIn),
+		BytesOut:     Int64(l.BytesOut),
+	}
+}
+
+// LogPayload is the redacted headers and bodies captured for a request (see LOG_PAYLOADS).
+type LogPayload struct {
+	RequestHeaders        http.Header `json:"request_headers" extensions:"x-nullable"`
+	RequestBody           *string     `json:"request_body" extensions:"x-nullable"`
+	RequestBodyTruncated  bool        `json:"request_body_truncated"`
+	ResponseHeaders       http.Header `json:"response_headers" extensions:"x-nullable"`
+	ResponseBody          *string     `json:"response_body" extensions:"x-nullable"`
+	ResponseBodyTruncated bool        `json:"response_body_truncated"`
+}
+
+func NewLogPayload(p repository.LogPayload) LogPayload {
+	return LogPayload{
+		RequestHeaders:        headers(p.RequestHeaders),
+		RequestBody:           String(p.RequestBody),
+		RequestBodyTruncated:  p.RequestBodyTruncated,
+		ResponseHeaders:       headers(p.ResponseHeaders),
+		ResponseBody:          String(p.ResponseBody),
+		ResponseBodyTruncated: p.ResponseBodyTruncated,
+	}
+}
+
+// headers decodes the headers stored as JSON, they are served as an object rather than a string.
+func headers(s sql.NullString) http.Header {
+	var h http.Header
+	if s.Valid {
+		json.Unmarshal([]byte(s.String), &h)
+	}
+	return h
+}
+
+// LogDetail is a log entry with its captured payload, null when payload capture was off for the request.
+type LogDetail struct {
+	Log
+	Payload *LogPayload `json:"payload" extensions:"x-nullable"`
+}
+
+func NewLogDetail(l repository.Log, p *repository.LogPayload) LogDetail {
+	detail := LogDetail{Log: NewLog(l)}
+	if p != nil {
+		payload := NewLogPayload(*p)
+		detail.Payload = &payload
+	}
+	return detail
+}
+
+// LogEntry is the basic view of a logged request used by the log lists.
+type LogEntry struct {
+	Method       *string    `json:"method" example:"GET" extensions:"x-nullable"`
+	Response     *int64     `json:"response" example:"200" extensions:"x-nullable"`
+	Path         *string    `json:"path" example:"/users/id/1" extensions:"x-nullable"`
+	ResponseTime *string    `json:"response_time" example:"182µs" extensions:"x-nullable"`
+	CreatedAt    *time.Time `json:"created_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+}
+
+func NewLogEntry(r repository.LogsGetAllRow) LogEntry {
+	return LogEntry{
+		Method:       String(r.Method),
+		Response:     Int64(r.Response),
+		Path:         String(r.Path),
+		ResponseTime: String(r.ResponseTime),
+		CreatedAt:    Time(r.CreatedAt),
+	}
+}
+
+// NewLogEntryFromPage maps the rows of the paginated logs, they have the columns of LogsGetAllRow.
+func NewLogEntryFromPage(r repository.LogsGetBasicViewWithOffsetLimitRow) LogEntry {
+	return NewLogEntry(repository.LogsGetAllRow(r))
+}
+
+// NewLogEntryFromFilter maps the rows of the filtered logs, they have the columns of LogsGetAllRow.
+func NewLogEntryFromFilter(r repository.LogsGetBasicViewWithOffsetLimitAdvancedRow) LogEntry {
+	return NewLogEntry(repository.LogsGetAllRow(r))
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// Notification tells a user what another user (the actor) did: commented on their post, replied to
+// their comment, followed them or reacted to their post or comment.
+type Notification struct {
+	ID        int64      `json:"id" example:"1"`
+	Type      string     `json:"type" example:"reply" enums:"comment,reply,follow,reaction"`
+	ActorID   int64      `json:"actor_id" example:"2"`
+	PostID    *int64     `json:"post_id" example:"1" extensions:"x-nullable"`
+	CommentID *int64     `json:"comment_id" example:"3" extensions:"x-nullable"`
+	CreatedAt *time.Time `json:"created_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	ReadAt    *time.Time `json:"read_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+}
+
+func NewNotification(n repository.Notification) Notification {
+	return Notification{
+		ID:        n.ID,
+		Type:      n.Type,
+		ActorID:   n.ActorID,
+		PostID:    Int64(n.PostID),
+		CommentID: Int64(n.CommentID),
+		CreatedAt: Time(n.CreatedAt),
+		ReadAt:    Time(n.ReadAt),
+	}
+}
+
+// UnreadNotifications counts the unread notifications of a user, in total and by type.
+type UnreadNotifications struct {
+	Total  int64            `json:"total" example:"3"`
+	ByType map[string]int64 `json:"by_type"`
+}
+
+// NewUnreadNotifications adds up the unread counts by type, types without any count as 0.
+func NewUnreadNotifications(types []string, counts []repository.NotificationsCountUnreadRow) UnreadNotifications {
+	unread := UnreadNotifications{ByType: make(map[string]int64, len(types))}
+	for _, t := range types {
+		unread.ByType[t] = 0
+	}
+	for _, count := range counts {
+		unread.ByType[count.Type] = count.Count
+		unread.Total += count.Count
+	}
+	return unread
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"encoding/json"
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// DeadLetter is an outbox event a subscriber failed to handle in every attempt.
+type DeadLetter struct {
+	ID            int64           `json:"id" example:"1"`
+	EventID       int64           `json:"event_id" example:"42"`
+	AggregateType string          `json:"aggregate_type" example:"post"`
+	AggregateID   int64           `json:"aggregate_id" example:"1"`
+	Type          string          `json:"type" example:"post.created"`
+	Payload       json.RawMessage `json:"payload" swaggertype:"object"`
+	Subscriber    string          `json:"subscriber" example:"webhooks"`
+	Attempts      int64           `json:"attempts" example:"5"`
+	Error         string          `json:"error" example:"database is locked"`
+	CreatedAt     *time.Time      `json:"created_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+}
+
+func NewDeadLetter(d repository.OutboxDeadLetter) DeadLetter {
+	return DeadLetter{
+		ID:            d.ID,
+		EventID:       d.EventID,
+		AggregateType: d.AggregateType,
+		AggregateID:   d.AggregateID,
+		Type:          d.Type,
+		Payload:       json.RawMessage(d.Payload),
+		Subscriber:    d.Subscriber,
+		Attempts:      d.Attempts,
+		Error:         d.Error,
+		CreatedAt:     Time(d.CreatedAt),
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderLink points keyset paginated lists to their next page (RFC 8288), the list itself stays a plain array.
+const HeaderLink = "Link"
+
+// Limit parses the limit query parameter of the paginated and ranked lists, 20 when it is missing.
+func Limit(c echo.Context) (int64, bool) {
+	if c.QueryParam("limit") == "" {
+		return 20, true
+	}
+	limit, err := strconv.ParseInt(c.QueryParam("limit"), 10, 64)
+	return limit, err == nil && limit >= 1 && limit <= 100
+}
+
+// EncodeCursor packs the sort key of the last row of a page into the opaque cursor parameter of the next one.
+func EncodeCursor(key ...string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strings.Join(key, "\n")))
+}
+
+// DecodeCursor unpacks a cursor of EncodeCursor, ok is false when it isn't one of n parts.
+func DecodeCursor(cursor string, n int) (key []string, ok bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, false
+	}
+	key = strings.Split(string(raw), "\n")
+	return key, len(key) == n
+}
+
+// SetNextPage sets the Link header to the request URL with the cursor parameter of the next page.
+func SetNextPage(c echo.Context, cursor string) {
+	u := *c.Request().URL
+	query := u.Query()
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+	c.Response().Header().Set(HeaderLink, "<"+u.RequestURI()+`>; rel="next"`)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// Post is a post as served by the API. Its status is draft, scheduled, published or archived,
+// published_at is when it went live, or is due to for scheduled posts. Reactions counts the
+// reactions by type, types nobody used are left out.
+type Post struct {
+	ID          int64            `json:"id" example:"1"`
+	UserID      int64            `json:"user_id" example:"1"`
+	Title       string           `json:"title" example:"Hello World"`
+	Content     string           `json:"content" example:"My first post"`
+	Status      string           `json:"status" example:"published" enums:"draft,scheduled,published,archived"`
+	PublishedAt *time.Time       `json:"published_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	CreatedAt   *time.Time       `json:"created_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	UpdatedAt   *time.Time       `json:"updated_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	Reactions   map[string]int64 `json:"reactions"`
+}
+
+func NewPost(p repository.Post) Post {
+	return Post{
+		ID:          p.ID,
+		UserID:      p.UserID,
+		Title:       p.Title,
+		Content:     p.Content,
+		Status:      p.Status,
+		PublishedAt: Time(p.PublishedAt),
+		CreatedAt:   Time(p.CreatedAt),
+		UpdatedAt:   Time(p.UpdatedAt),
+		Reactions:   map[string]int64{},
+	}
+}
+
+// NewPostWithReactions returns NewPost filling in the reaction counts, by post ID and reaction type.
+func NewPostWithReactions(reactions map[int64]map[string]int64) func(repository.Post) Post {
+	return func(p repository.Post) Post {
+		post := NewPost(p)
+		if counts, ok := reactions[p.ID]; ok {
+			post.Reactions = counts
+		}
+		return post
+	}
+}
+
+// PostRevision is a title and content a post had, revisions are numbered from 1 per post.
+type PostRevision struct {
+	ID        int64      `json:"id" example:"1"`
+	PostID    int64      `json:"post_id" example:"1"`
+	Revision  int64      `json:"revision" example:"1"`
+	Title     string     `json:"title" example:"Hello World"`
+	Content   string     `json:"content" example:"My first post"`
+	CreatedAt *time.Time `json:"created_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+}
+
+func NewPostRevision(r repository.PostRevision) PostRevision {
+	return PostRevision{
+		ID:        r.ID,
+		PostID:    r.PostID,
+		Revision:  r.Revision,
+		Title:     r.Title,
+		Content:   r.Content,
+		CreatedAt: Time(r.CreatedAt),
+	}
+}
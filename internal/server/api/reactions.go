@@ -0,0 +1,28 @@
+package api
+
+import (
+	"backendT/internal/database/repository"
+)
+
+// ReactionCount is how many users left a reaction type, and whether the user making the request is one of them.
+type ReactionCount struct {
+	Type  string `json:"type" example:"like" enums:"like,love,laugh,wow,sad,angry"`
+	Count int64  `json:"count" example:"3"`
+	Mine  bool   `json:"mine" example:"true"`
+}
+
+func NewReactionCount(r repository.PostReactionsSummaryRow) ReactionCount {
+	return ReactionCount(r)
+}
+
+func NewReactionCountFromComment(r repository.CommentReactionsSummaryRow) ReactionCount {
+	return ReactionCount(r)
+}
+
+// TrendingPost is a post with the engagement it is ranked by, see GET /posts/trending.
+type TrendingPost struct {
+	Post
+	ReactionCount int64   `json:"reaction_count" example:"12"`
+	CommentCount  int64   `json:"comment_count" example:"4"`
+	Score         float64 `json:"score" example:"0.42"`
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// Tag is a tag as attached to posts.
+type Tag struct {
+	ID        int64      `json:"id" example:"1"`
+	Name      string     `json:"name" example:"golang"`
+	CreatedAt *time.Time `json:"created_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+}
+
+func NewTag(t repository.Tag) Tag {
+	return Tag{
+		ID:        t.ID,
+		Name:      t.Name,
+		CreatedAt: Time(t.CreatedAt),
+	}
+}
+
+// TagCount is a tag with the number of published posts carrying it.
+type TagCount struct {
+	ID        int64  `json:"id" example:"1"`
+	Name      string `json:"name" example:"golang"`
+	PostCount int64  `json:"post_count" example:"3"`
+}
+
+func NewTagCount(t repository.TagsGetCountsRow) TagCount {
+	return TagCount(t)
+}
+
+func NewTagCountFromAutocomplete(t repository.TagsAutocompleteRow) TagCount {
+	return TagCount(t)
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"time"
+
+	"backendT/internal/avatar"
+	"backendT/internal/database/repository"
+)
+
+// AvatarPath is where the avatar files are served from.
+const AvatarPath = "/avatars/"
+
+// User is a user as served by the API.
+type User struct {
+	ID                 int64      `json:"id" example:"1"`
+	Username           string     `json:"username" example:"test"`
+	Email              string     `json:"email" example:"test@example.com"`
+	DisplayName        *string    `json:"display_name" example:"Test User" extensions:"x-nullable"`
+	Bio                *string    `json:"bio" example:"Writes the hello world posts" extensions:"x-nullable"`
+	AvatarURL          *string    `json:"avatar_url" example:"/avatars/5f2b8c1e9d0a4b7c8e6f1a2b3c4d5e6f.png" extensions:"x-nullable"`
+	AvatarThumbnailURL *string    `json:"avatar_thumbnail_url" example:"/avatars/5f2b8c1e9d0a4b7c8e6f1a2b3c4d5e6f_thumb.png" extensions:"x-nullable"`
+	CreatedAt          *time.Time `json:"created_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	UpdatedAt          *time.Time `json:"updated_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+}
+
+func NewUser(u repository.User) User {
+	user := User{
+		ID:          u.ID,
+		Username:    u.Username,
+		Email:       u.Email,
+		DisplayName: String(u.DisplayName),
+		Bio:         String(u.Bio),
+		CreatedAt:   Time(u.CreatedAt),
+		UpdatedAt:   Time(u.UpdatedAt),
+	}
+	if u.Avatar.Valid {
+		url, thumbnail := AvatarPath+u.Avatar.String, AvatarPath+avatar.ThumbnailName(u.Avatar.String)
+		user.AvatarURL, user.AvatarThumbnailURL = &url, &thumbnail
+	}
+	return user
+}
@@ -0,0 +1,19 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderUserID names the user making the request. There is no authentication yet, the header is trusted.
+const HeaderUserID = "X-User-ID"
+
+// ViewerID returns the id of the user making the request, false for anonymous requests.
+func ViewerID(c echo.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Request().Header.Get(HeaderUserID), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
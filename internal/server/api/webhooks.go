@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// Webhook is a subscription of another service to events. Its secret is only shown when it is created.
+type Webhook struct {
+	ID        int64      `json:"id" example:"1"`
+	URL       string     `json:"url" example:"https://example.com/hooks"`
+	Events    []string   `json:"events" example:"post.created"`
+	Active    bool       `json:"active" example:"true"`
+	Secret    string     `json:"secret,omitempty" example:"whsec_0123456789abcdef"`
+	CreatedAt *time.Time `json:"created_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	UpdatedAt *time.Time `json:"updated_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+}
+
+func NewWebhook(w repository.Webhook) Webhook {
+	return Webhook{
+		ID:        w.ID,
+		URL:       w.Url,
+		Events:    strings.Split(w.Events, ","),
+		Active:    w.Active,
+		CreatedAt: Time(w.CreatedAt),
+		UpdatedAt: Time(w.UpdatedAt),
+	}
+}
+
+// WebhookDelivery is one event sent (or to be sent) to a webhook, with the outcome of its last attempt.
+type WebhookDelivery struct {
+	ID             int64           `json:"id" example:"1"`
+	WebhookID      int64           `json:"webhook_id" example:"1"`
+	EventID        *int64          `json:"event_id" example:"42" extensions:"x-nullable"`
+	RedeliveryOf   *int64          `json:"redelivery_of" example:"1" extensions:"x-nullable"`
+	Event          string          `json:"event" example:"post.created"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status" example:"succeeded" enums:"pending,succeeded,failed"`
+	Attempts       int64           `json:"attempts" example:"1"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+	ResponseStatus *int64          `json:"response_status" example:"200" extensions:"x-nullable"`
+	ResponseBody   *string         `json:"response_body" example:"ok" extensions:"x-nullable"`
+	Error          *string         `json:"error" example:"receiver answered 503" extensions:"x-nullable"`
+	CreatedAt      *time.Time      `json:"created_at" example:"2025-01-31T12:00:00Z" format:"date-time" extensions:"x-nullable"`
+}
+
+func NewWebhookDelivery(d repository.WebhookDelivery) WebhookDelivery {
+	delivery := WebhookDelivery{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventID:        Int64(d.EventID),
+		RedeliveryOf:   Int64(d.RedeliveryOf),
+		Event:          d.Event,
+		Payload:        json.RawMessage(d.Payload),
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastAttemptAt:  Time(d.LastAttemptAt),
+		ResponseStatus: Int64(d.ResponseStatus),
+		ResponseBody:   String(d.ResponseBody),
+		Error:          String(d.Error),
+		CreatedAt:      Time(d.CreatedAt),
+	}
+	// Only pending deliveries are attempted again
+	if d.Status == "pending" {
+		delivery.NextAttemptAt = Time(d.NextAttemptAt)
+	}
+	return delivery
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/avatar"
+	"backendT/internal/server/api"
+)
+
+// avatars returns the store of the user avatars, configured with AVATAR_* (see avatar.ConfigFromEnv).
+func (s *Server) avatars() *avatar.Store {
+	if s.avatarStore == nil {
+		s.avatarStore = avatar.NewStore(avatar.ConfigFromEnv())
+	}
+	return s.avatarStore
+}
+
+// registerAvatarRoutes serves the avatar files. Every upload gets a new name,
+// so a served file never changes and clients may keep it for good.
+func (s *Server) registerAvatarRoutes(e *echo.Echo) {
+	g := e.Group(strings.TrimSuffix(api.AvatarPath, "/"), func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Before(func() {
+				if c.Response().Status == 200 {
+					c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=31536000, immutable")
+				}
+			})
+			return next(c)
+		}
+	})
+	g.Static("/", s.avatars().Dir())
+}
+
+// runAvatarSweeper removes the avatar files no user refers to anymore: replaced or deleted
+// avatars whose removal failed, and uploads that never made it to their user.
+func (s *Server) runAvatarSweeper(ctx context.Context) {
+	inUse := func(ctx context.Context) (map[string]bool, error) {
+		avatars, err := s.db.GetRepositoryRO().UsersGetAvatars(ctx)
+		if err != nil {
+			return nil, err
+		}
+		names := make(map[string]bool, len(avatars))
+		for _, name := range avatars {
+			names[name.String] = true
+		}
+		return names, nil
+	}
+
+	s.avatars().RunSweeper(ctx, inUse, func(err error) {
+		log.Printf("Error sweeping avatars: %v", err)
+	})
+}
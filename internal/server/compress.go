@@ -0,0 +1,234 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/labstack/echo/v4"
+)
+
+// compression decides which responses are compressed and how.
+type compression struct {
+	// supported encodings in order of preference
+	encodings    []string
+	minLength    int
+	contentTypes []string
+}
+
+// compressionFromEnv returns nil when COMPRESS_ENCODINGS is off. Responses are compressed with the
+// COMPRESS_ENCODINGS the client accepts (br,gzip by default) once they reach COMPRESS_MIN_BYTES (1024),
+// for the COMPRESS_CONTENT_TYPES prefixes only (images, archives... are compressed already).
+func compressionFromEnv() *compression {
+	cfg := &compression{
+		encodings:    []string{"br", "gzip"},
+		minLength:    1024,
+		contentTypes: []string{echo.MIMEApplicationJSON, "text/", echo.MIMEApplicationXML, echo.MIMEApplicationJavaScript, "image/svg+xml"},
+	}
+
+	if encodings := os.Getenv("COMPRESS_ENCODINGS"); encodings == "off" {
+		return nil
+	} else if encodings != "" {
+		cfg.encodings = nil
+		for _, encoding := range strings.Split(encodings, ",") {
+			switch encoding = strings.TrimSpace(encoding); encoding {
+			case "br", "gzip":
+				cfg.encodings = append(cfg.encodings, encoding)
+			}
+		}
+	}
+	if minLength, err := strconv.Atoi(os.Getenv("COMPRESS_MIN_BYTES")); err == nil && minLength >= 0 {
+		cfg.minLength = minLength
+	}
+	if types := os.Getenv("COMPRESS_CONTENT_TYPES"); types != "" {
+		cfg.contentTypes = strings.Split(types, ",")
+	}
+	return cfg
+}
+
+// CompressionMiddleware compresses responses with the best encoding the client accepts.
+// Responses are buffered until they reach the minimum size, smaller ones are sent as they are.
+func (s *Server) CompressionMiddleware() echo.MiddlewareFunc {
+	cfg := compressionFromEnv()
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if cfg == nil {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			c.Response().Header().Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+			encoding := cfg.negotiate(c.Request().Header.Get(echo.HeaderAcceptEncoding))
+			if encoding == "" || c.Request().Method == http.MethodHead {
+				return next(c)
+			}
+
+			w := &compressWriter{ResponseWriter: c.Response().Writer, cfg: cfg, encoding: encoding}
+			c.Response().Writer = w
+			defer func() {
+				w.Close()
+				c.Response().Writer = w.ResponseWriter
+			}()
+			return next(c)
+		}
+	}
+}
+
+// negotiate picks the supported encoding with the highest q value in Accept-Encoding,
+// ties go to the first one in our order of preference.
+func (cfg *compression) negotiate(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range cfg.encodings {
+		q, ok := accepted[encoding]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+func (cfg *compression) allowed(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range cfg.contentTypes {
+		if prefix = strings.TrimSpace(prefix); prefix != "" && strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// encoder is implemented by both gzip.Writer and brotli.Writer.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	"gzip": {New: func() any { return gzip.NewWriter(io.Discard) }},
+	"br":   {New: func() any { return brotli.NewWriterLevel(io.Discard, 4) }},
+}
+
+// compressWriter holds back the start of the response until it knows whether it is worth compressing.
+type compressWriter struct {
+	http.ResponseWriter
+	cfg      *compression
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     encoder
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if !w.decided {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.cfg.minLength {
+			return len(b), nil
+		}
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide sends the status and headers, compressing from here on when the response qualifies.
+func (w *compressWriter) decide() error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	h := w.Header()
+	if len(w.buf) >= w.cfg.minLength && h.Get(echo.HeaderContentEncoding) == "" &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified && w.cfg.allowed(h.Get(echo.HeaderContentType)) {
+		h.Set(echo.HeaderContentEncoding, w.encoding)
+		h.Del(echo.HeaderContentLength)
+		w.enc = encoderPools[w.encoding].Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if w.enc != nil {
+		_, err := w.enc.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush sends what was written so far, streamed responses flush regularly.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Close sends a response that stayed under the minimum size and ends the compressed stream.
+func (w *compressWriter) Close() error {
+	if !w.decided && (w.status != 0 || len(w.buf) > 0) {
+		if err := w.decide(); err != nil {
+			return err
+		}
+	}
+	if w.enc == nil {
+		return nil
+	}
+
+	err := w.enc.Close()
+	w.enc.Reset(io.Discard)
+	encoderPools[w.encoding].Put(w.enc)
+	w.enc = nil
+	return err
+}
+
+// Unwrap lets http.ResponseController reach the original writer.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
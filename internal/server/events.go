@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/outbox"
+	"backendT/internal/server/api"
+	"backendT/internal/webhook"
+)
+
+// events returns the dispatcher handing the outbox events to the in-process subscribers: the notifications,
+// the webhooks and the cache of read responses.
+func (s *Server) events() *outbox.Dispatcher {
+	if s.dispatcher == nil {
+		s.dispatcher = outbox.NewDispatcher(s.db.GetRepositoryRW(), outbox.ConfigFromEnv())
+		s.dispatcher.Subscribe("notifications", s.notifications().HandleEvent)
+		s.dispatcher.Subscribe("webhooks", webhook.NewEmitter(s.db.GetRepositoryRW()).HandleEvent)
+		s.dispatcher.Subscribe("cache", s.invalidateCache)
+	}
+	return s.dispatcher
+}
+
+// invalidateCache is the outbox subscriber dropping the cached read responses an event makes stale. The write
+// routes drop them right away already, it catches the writes made elsewhere, like the publication of scheduled posts.
+func (s *Server) invalidateCache(ctx context.Context, event outbox.Event) error {
+	switch event.AggregateType {
+	case outbox.AggregateUser:
+		s.httpCache().Invalidate("users")
+		// Their posts went with them
+		if event.Type == outbox.EventUserDeleted {
+			s.httpCache().Invalidate("posts")
+		}
+	case outbox.AggregatePost:
+		s.httpCache().Invalidate("posts")
+	}
+	return nil
+}
+
+// deadLettersHandler lists the outbox events the subscribers failed to handle.
+// @Summary Get outbox dead letters
+// @Description Returns the outbox events a subscriber (notifications, webhooks or cache) failed to handle in every attempt (OUTBOX_MAX_ATTEMPTS), newest first.
+// @Description When there are more, the Link header points to the next page. Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param limit query int false "Number of dead letters, 1 to 100 (default 20)"
+// @Param cursor query string false "Cursor of the page, from the Link header of the previous one"
+// @Success 200 {array} api.DeadLetter "Dead letters"
+// @Header 200 {string} Link "<next page>; rel=\"next\""
+// @Failure 400 {object} map[string]string "Bad request - invalid limit or cursor"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/outbox/dead-letters [get]
+func (s *Server) deadLettersHandler(c echo.Context) error {
+	limit, ok := api.Limit(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit parameter, expected 1 to 100",
+		})
+	}
+	before := int64(math.MaxInt64)
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		key, ok := api.DecodeCursor(cursor, 1)
+		var err error
+		if ok {
+			before, err = strconv.ParseInt(key[0], 10, 64)
+		}
+		if !ok || err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid cursor parameter",
+			})
+		}
+	}
+
+	// One more than asked tells whether there is a next page
+	deadLetters, err := s.db.GetRepositoryRO().OutboxDeadLettersGetAll(c.Request().Context(), repository.OutboxDeadLettersGetAllParams{
+		Before: before,
+		Limit:  limit + 1,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch dead letters",
+		})
+	}
+	if int64(len(deadLetters)) > limit {
+		deadLetters = deadLetters[:limit]
+		api.SetNextPage(c, api.EncodeCursor(strconv.FormatInt(deadLetters[limit-1].ID, 10)))
+	}
+	body := make([]api.DeadLetter, len(deadLetters))
+	for i, d := range deadLetters {
+		body[i] = api.NewDeadLetter(d)
+	}
+	return c.JSON(http.StatusOK, body)
+}
@@ -1,23 +1,39 @@
 package handlers
 
 import (
+	"context"
+
 	"backendT/internal/database/repository"
+	"backendT/internal/database/rows"
+	"backendT/internal/notify"
 	logs "backendT/internal/server/handlers/logs"
+	notifications "backendT/internal/server/handlers/notifications"
 	posts "backendT/internal/server/handlers/posts"
 	users "backendT/internal/server/handlers/users"
 	// add other handler packages here, e.g.
 )
 
 type Handlers struct {
-	Users *users.UsersHandler
-	Posts *posts.PostsHandler
-	Logs  *logs.LogsHandler
+	Users         *users.UsersHandler
+	Posts         *posts.PostsHandler
+	Logs          *logs.LogsHandler
+	Notifications *notifications.NotificationsHandler
+}
+
+// TxRunner runs fn in a transaction, database.Service implements it.
+type TxRunner interface {
+	WithTx(ctx context.Context, fn func(q *repository.Queries) error) error
 }
 
-func New(repo *repository.Queries) *Handlers {
+// New creates the handlers reading through conn. The writes go through db in transactions that store
+// their outbox events with them, and notifier serves the live notifications. Read only handlers can go
+// without both.
+func New(conn repository.DBTX, db TxRunner, notifier *notify.Notifier) *Handlers {
+	repo, streams := repository.New(conn), rows.New(conn)
 	return &Handlers{
-		Users: users.NewUsersHandler(repo),
-		Posts: posts.NewPostsHandler(repo),
-		Logs:  logs.NewLogsHandler(repo),
+		Users:         users.NewUsersHandler(repo, streams, db),
+		Posts:         posts.NewPostsHandler(repo, db),
+		Logs:          logs.NewLogsHandler(repo, streams),
+		Notifications: notifications.NewNotificationsHandler(repo, notifier),
 	}
 }
@@ -0,0 +1,200 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/database/repository"
+	jobqueue "backendT/internal/jobs"
+	"backendT/internal/server/api"
+)
+
+type Repo interface {
+	JobsCancel(ctx context.Context, params repository.JobsCancelParams) (repository.Job, error)
+	JobsGetAll(ctx context.Context, params repository.JobsGetAllParams) ([]repository.Job, error)
+	JobsGetByID(ctx context.Context, id int64) (repository.Job, error)
+	JobsRetry(ctx context.Context, params repository.JobsRetryParams) (repository.Job, error)
+}
+
+// JobsHandler lets the admins look after the background jobs.
+type JobsHandler struct {
+	repo Repo
+}
+
+func NewJobsHandler(r *repository.Queries) *JobsHandler {
+	return &JobsHandler{
+		repo: r,
+	}
+}
+
+// GetJobs handles HTTP GET requests listing the background jobs.
+// @Summary Get jobs
+// @Description Returns the background jobs, newest first, optionally only those of a status or kind.
+// @Description When there are more, the Link header points to the next page. Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param status query string false "Only jobs of this status" Enums(pending, running, succeeded, failed, cancelled)
+// @Param kind query string false "Only jobs of this kind"
+// @Param limit query int false "Number of jobs, 1 to 100 (default 20)"
+// @Param cursor query string false "Cursor of the page, from the Link header of the previous one"
+// @Success 200 {array} api.Job "Jobs"
+// @Header 200 {string} Link "<next page>; rel=\"next\""
+// @Failure 400 {object} map[string]string "Bad request - invalid status, limit or cursor"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/jobs [get]
+func (h *JobsHandler) GetJobs(c echo.Context) error {
+	status := c.QueryParam("status")
+	if status != "" && !slices.Contains(jobqueue.Statuses, status) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid status parameter, expected one of " + strings.Join(jobqueue.Statuses, ", "),
+		})
+	}
+	limit, ok := api.Limit(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit parameter, expected 1 to 100",
+		})
+	}
+	before := int64(math.MaxInt64)
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		key, ok := api.DecodeCursor(cursor, 1)
+		var err error
+		if ok {
+			before, err = strconv.ParseInt(key[0], 10, 64)
+		}
+		if !ok || err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid cursor parameter",
+			})
+		}
+	}
+
+	// One more than asked tells whether there is a next page
+	jobs, err := h.repo.JobsGetAll(c.Request().Context(), repository.JobsGetAllParams{
+		Status: status,
+		Kind:   c.QueryParam("kind"),
+		Before: before,
+		Limit:  limit + 1,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch jobs",
+		})
+	}
+	if int64(len(jobs)) > limit {
+		jobs = jobs[:limit]
+		api.SetNextPage(c, api.EncodeCursor(strconv.FormatInt(jobs[limit-1].ID, 10)))
+	}
+	body := make([]api.Job, len(jobs))
+	for i, j := range jobs {
+		body[i] = api.NewJob(j)
+	}
+	return c.JSON(http.StatusOK, body)
+}
+
+// RetryJob handles HTTP POST requests running a failed or cancelled job again.
+// @Summary Retry job
+// @Description Queues a failed or cancelled job to run right away, with all its attempts again. Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "Job ID"
+// @Success 202 {object} api.Job "Queued job"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 404 {object} map[string]string "Job not found"
+// @Failure 409 {object} map[string]string "Job neither failed nor cancelled, or a job with the same unique key is queued"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/jobs/{id}/retry [post]
+func (h *JobsHandler) RetryJob(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid job ID format",
+		})
+	}
+
+	job, err := h.repo.JobsRetry(c.Request().Context(), repository.JobsRetryParams{
+		Now: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:  id,
+	})
+	if err == sql.ErrNoRows {
+		return h.conflict(c, id, "Only failed or cancelled jobs can be retried", jobqueue.StatusFailed, jobqueue.StatusCancelled)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to retry job",
+		})
+	}
+	return c.JSON(http.StatusAccepted, api.NewJob(job))
+}
+
+// CancelJob handles HTTP POST requests cancelling a job.
+// @Summary Cancel job
+// @Description Cancels a pending or running job. A running job is stopped when its worker renews the lease,
+// @Description within half of JOBS_LEASE. Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "Job ID"
+// @Success 200 {object} api.Job "Cancelled job"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 404 {object} map[string]string "Job not found"
+// @Failure 409 {object} map[string]string "Job finished already"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/jobs/{id}/cancel [post]
+func (h *JobsHandler) CancelJob(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid job ID format",
+		})
+	}
+
+	job, err := h.repo.JobsCancel(c.Request().Context(), repository.JobsCancelParams{
+		Now: sql.NullTime{Time: time.Now().UTC(), Valid: true},
+		ID:  id,
+	})
+	if err == sql.ErrNoRows {
+		return h.conflict(c, id, "Only pending or running jobs can be cancelled")
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to cancel job",
+		})
+	}
+	return c.JSON(http.StatusOK, api.NewJob(job))
+}
+
+// conflict answers a retry or cancellation that changed nothing: 404 for an unknown job, 409 otherwise, with
+// message unless the job is in one of the retryable statuses and so another job holds its unique key.
+func (h *JobsHandler) conflict(c echo.Context, id int64, message string, retryable ...string) error {
+	job, err := h.repo.JobsGetByID(c.Request().Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Job not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch job",
+		})
+	}
+	if slices.Contains(retryable, job.Status) {
+		message = "A job with the same unique key is queued already"
+	}
+	return c.JSON(http.StatusConflict, map[string]string{
+		"error": message,
+	})
+}
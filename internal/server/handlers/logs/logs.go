@@ -9,21 +9,33 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"backendT/internal/database/repository"
+	"backendT/internal/database/rows"
+	"backendT/internal/server/api"
+	"backendT/internal/server/handlers/stream"
 )
 
 type Repo interface {
-	LogsGetAll(ctx context.Context) ([]repository.LogsGetAllRow, error)
 	LogsGetBasicViewWithOffsetLimit(ctx context.Context, params repository.LogsGetBasicViewWithOffsetLimitParams) ([]repository.LogsGetBasicViewWithOffsetLimitRow, error)
 	LogsGetBasicViewWithOffsetLimitAdvanced(ctx context.Context, params repository.LogsGetBasicViewWithOffsetLimitAdvancedParams) ([]repository.LogsGetBasicViewWithOffsetLimitAdvancedRow, error)
+	LogsGetByID(ctx context.Context, id int64) (repository.Log, error)
+	LogsGetByRequestID(ctx context.Context, requestID sql.NullString) (repository.Log, error)
+	LogPayloadsGetByLogID(ctx context.Context, logID int64) (repository.LogPayload, error)
+}
+
+// Streams are the queries streamed row by row, rows.Queries implements them.
+type Streams interface {
+	LogsGetAllEach(ctx context.Context, fn func(repository.LogsGetAllRow) error) error
 }
 
 type LogsHandler struct {
-	repo Repo
+	repo    Repo
+	streams Streams
 }
 
-func NewLogsHandler(r *repository.Queries) *LogsHandler {
+func NewLogsHandler(r *repository.Queries, s *rows.Queries) *LogsHandler {
 	return &LogsHandler{
-		repo: r,
+		repo:    r,
+		streams: s,
 	}
 }
 
@@ -32,17 +44,18 @@ func NewLogsHandler(r *repository.Queries) *LogsHandler {
 // @Description Returns a list of all logs from the database.
 // @Tags logs
 // @Produce json
-// @Success 200 {array} repository.Log "List of logs"
+// @Success 200 {array} api.LogEntry "List of logs"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /logs [get]
+// @Router /v2/logs [get]
 func (h *LogsHandler) GetAllLogs(c echo.Context) error {
-	logs, err := h.repo.LogsGetAll(c.Request().Context())
+	// Streamed row by row, the logs table gets big
+	err := stream.JSONArray(c, api.Each(c, h.streams.LogsGetAllEach, api.NewLogEntry))
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to fetch logs",
 		})
 	}
-	return c.JSON(http.StatusOK, logs)
+	return nil
 }
 
 // GetLogsWithPagination handles HTTP GET requests to retrieve paginated logs.
@@ -52,10 +65,10 @@ func (h *LogsHandler) GetAllLogs(c echo.Context) error {
 // @Produce json
 // @Param offset query int false "Offset for pagination"
 // @Param limit query int false "Limit for pagination"
-// @Success 200 {array} repository.LogsGetBasicViewWithOffsetLimitRow
+// @Success 200 {array} api.LogEntry
 // @Failure 400 {object} map[string]string "Invalid parameters"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /logs/paginated [get]
+// @Router /v2/logs/paginated [get]
 func (h *LogsHandler) GetLogsWithPagination(c echo.Context) error {
 	var params repository.LogsGetBasicViewWithOffsetLimitParams
 
@@ -89,7 +102,7 @@ func (h *LogsHandler) GetLogsWithPagination(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, logs)
+	return c.JSON(http.StatusOK, api.RenderAll(c, logs, api.NewLogEntryFromPage))
 }
 
 // GetLogsAdvanced handles HTTP GET requests to retrieve filtered logs.
@@ -102,10 +115,10 @@ func (h *LogsHandler) GetLogsWithPagination(c echo.Context) error {
 // @Param timeRange query string true "Time range (e.g. '-1 hour', '-24 hours', '-7 days'). Required parameter."
 // @Param offset query int true "Offset for pagination. Required parameter."
 // @Param limit query int true "Limit for pagination. Required parameter."
-// @Success 200 {array} repository.LogsGetBasicViewWithOffsetLimitAdvancedRow
+// @Success 200 {array} api.LogEntry
 // @Failure 400 {object} map[string]string "Invalid parameters"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /logs/filtered [get]
+// @Router /v2/logs/filtered [get]
 func (h *LogsHandler) GetLogsAdvanced(c echo.Context) error {
 	var params repository.LogsGetBasicViewWithOffsetLimitAdvancedParams
 
@@ -171,5 +184,101 @@ func (h *LogsHandler) GetLogsAdvanced(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, logs)
+	return c.JSON(http.StatusOK, api.RenderAll(c, logs, api.NewLogEntryFromFilter))
+}
+
+// GetLogByID handles HTTP GET requests to retrieve a single log entry with all its details.
+// @Summary Get log by ID
+// @Description Returns every column of a log entry. The captured payload is only served to admins, see /admin/logs/{id}.
+// @Tags logs
+// @Produce json
+// @Param id path int true "Log ID"
+// @Success 200 {object} api.Log "Found log"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 404 {object} map[string]string "Log not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/logs/{id} [get]
+func (h *LogsHandler) GetLogByID(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid log ID format",
+		})
+	}
+
+	log, err := h.repo.LogsGetByID(c.Request().Context(), id)
+	if err != nil {
+		return lookupError(c, err)
+	}
+	return c.JSON(http.StatusOK, api.Render(c, log, api.NewLog))
+}
+
+// GetLogByRequestID handles HTTP GET requests to retrieve a log entry by its X-Request-ID.
+// @Summary Get log by request ID
+// @Description Returns the log entry of the request that was answered with the given X-Request-ID header.
+// @Tags logs
+// @Produce json
+// @Param request_id path string true "Request ID"
+// @Success 200 {object} api.Log "Found log"
+// @Failure 404 {object} map[string]string "Log not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/logs/request/{request_id} [get]
+func (h *LogsHandler) GetLogByRequestID(c echo.Context) error {
+	requestID := c.Param("request_id")
+	log, err := h.repo.LogsGetByRequestID(c.Request().Context(), sql.NullString{String: requestID, Valid: true})
+	if err != nil {
+		return lookupError(c, err)
+	}
+	return c.JSON(http.StatusOK, api.Render(c, log, api.NewLog))
+}
+
+// GetLogDetail handles HTTP GET requests retrieving a log entry together with its captured payload.
+// @Summary Get log with payload
+// @Description Returns every column of a log entry and, when payload capture was enabled, the redacted request/response headers and bodies.
+// @Description Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "Log ID"
+// @Success 200 {object} api.LogDetail "Found log"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 404 {object} map[string]string "Log not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/logs/{id} [get]
+func (h *LogsHandler) GetLogDetail(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid log ID format",
+		})
+	}
+
+	log, err := h.repo.LogsGetByID(c.Request().Context(), id)
+	if err != nil {
+		return lookupError(c, err)
+	}
+	var payload *repository.LogPayload
+	p, err := h.repo.LogPayloadsGetByLogID(c.Request().Context(), log.ID)
+	switch {
+	case err == nil:
+		payload = &p
+	case err != sql.ErrNoRows:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch log payload",
+		})
+	}
+	return c.JSON(http.StatusOK, api.NewLogDetail(log, payload))
+}
+
+// lookupError writes the error of a failed log lookup.
+func lookupError(c echo.Context, err error) error {
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Log not found",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Failed to fetch log",
+	})
 }
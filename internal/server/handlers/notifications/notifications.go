@@ -0,0 +1,280 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/notify"
+	"backendT/internal/server/api"
+)
+
+// keepAlive is how often an idle stream gets a comment, so proxies don't close it.
+const keepAlive = 25 * time.Second
+
+type Repo interface {
+	NotificationsCountUnread(ctx context.Context, userID int64) ([]repository.NotificationsCountUnreadRow, error)
+	NotificationsGetByUserID(ctx context.Context, params repository.NotificationsGetByUserIDParams) ([]repository.Notification, error)
+	NotificationsMarkAllRead(ctx context.Context, userID int64) (int64, error)
+	NotificationsMarkRead(ctx context.Context, params repository.NotificationsMarkReadParams) (int64, error)
+	UsersGetByID(ctx context.Context, userID int64) (repository.User, error)
+}
+
+type NotificationsHandler struct {
+	repo     Repo
+	notifier *notify.Notifier
+}
+
+func NewNotificationsHandler(r *repository.Queries, notifier *notify.Notifier) *NotificationsHandler {
+	return &NotificationsHandler{
+		repo:     r,
+		notifier: notifier,
+	}
+}
+
+// GetNotifications handles HTTP GET requests listing the notifications of a user.
+// @Summary Get notifications
+// @Description Returns the notifications of the user named in X-User-ID, newest first. When there are more, the Link header points to the next page.
+// @Tags notifications
+// @Produce json
+// @Param X-User-ID header int true "User notified"
+// @Param unread query bool false "Only the unread notifications"
+// @Param limit query int false "Number of notifications, 1 to 100 (default 20)"
+// @Param cursor query string false "Cursor of the page, from the Link header of the previous one"
+// @Success 200 {array} api.Notification "Notifications"
+// @Header 200 {string} Link "<next page>; rel=\"next\""
+// @Failure 400 {object} map[string]string "Bad request - invalid unread, limit or cursor"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/notifications [get]
+func (h *NotificationsHandler) GetNotifications(c echo.Context) error {
+	userID, ok, err := h.requestUser(c)
+	if !ok {
+		return err
+	}
+	var unreadOnly bool
+	if unread := c.QueryParam("unread"); unread != "" {
+		if unreadOnly, err = strconv.ParseBool(unread); err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid unread parameter, expected true or false",
+			})
+		}
+	}
+	limit, ok := api.Limit(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit parameter, expected 1 to 100",
+		})
+	}
+	before := int64(math.MaxInt64)
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		key, ok := api.DecodeCursor(cursor, 1)
+		if ok {
+			before, err = strconv.ParseInt(key[0], 10, 64)
+		}
+		if !ok || err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid cursor parameter",
+			})
+		}
+	}
+
+	// One more than asked tells whether there is a next page
+	notifications, err := h.repo.NotificationsGetByUserID(c.Request().Context(), repository.NotificationsGetByUserIDParams{
+		UserID:     userID,
+		Before:     before,
+		UnreadOnly: unreadOnly,
+		Limit:      limit + 1,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch notifications",
+		})
+	}
+	if int64(len(notifications)) > limit {
+		notifications = notifications[:limit]
+		api.SetNextPage(c, api.EncodeCursor(strconv.FormatInt(notifications[limit-1].ID, 10)))
+	}
+	return c.JSON(http.StatusOK, api.RenderAll(c, notifications, api.NewNotification))
+}
+
+// GetUnreadCount handles HTTP GET requests counting the unread notifications of a user.
+// @Summary Count unread notifications
+// @Description Returns how many notifications of the user named in X-User-ID are unread, in total and by type.
+// @Tags notifications
+// @Produce json
+// @Param X-User-ID header int true "User notified"
+// @Success 200 {object} api.UnreadNotifications "Unread notifications"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/notifications/unread [get]
+func (h *NotificationsHandler) GetUnreadCount(c echo.Context) error {
+	userID, ok, err := h.requestUser(c)
+	if !ok {
+		return err
+	}
+
+	counts, err := h.repo.NotificationsCountUnread(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to count notifications",
+		})
+	}
+	return c.JSON(http.StatusOK, api.NewUnreadNotifications(notify.Types, counts))
+}
+
+// MarkRead handles HTTP POST requests marking a notification as read.
+// @Summary Mark notification read
+// @Description Marks a notification of the user named in X-User-ID as read. Marking it again changes nothing.
+// @Tags notifications
+// @Param id path int true "Notification ID"
+// @Param X-User-ID header int true "User notified"
+// @Success 204 "Marked as read"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 404 {object} map[string]string "Notification not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/notifications/id/{id}/read [post]
+func (h *NotificationsHandler) MarkRead(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid notification ID format",
+		})
+	}
+	userID, ok, err := h.requestUser(c)
+	if !ok {
+		return err
+	}
+
+	// Notifications of other users are as good as missing
+	marked, err := h.repo.NotificationsMarkRead(c.Request().Context(), repository.NotificationsMarkReadParams{ID: id, UserID: userID})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to mark notification as read",
+		})
+	}
+	if marked == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Notification not found",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// MarkAllRead handles HTTP POST requests marking every notification of a user as read.
+// @Summary Mark all notifications read
+// @Description Marks every unread notification of the user named in X-User-ID as read.
+// @Tags notifications
+// @Param X-User-ID header int true "User notified"
+// @Success 204 "Marked as read"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/notifications/read [post]
+func (h *NotificationsHandler) MarkAllRead(c echo.Context) error {
+	userID, ok, err := h.requestUser(c)
+	if !ok {
+		return err
+	}
+
+	if _, err := h.repo.NotificationsMarkAllRead(c.Request().Context(), userID); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to mark notifications as read",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// StreamNotifications handles HTTP GET requests following the new notifications of a user live.
+// @Summary Stream notifications
+// @Description Server-sent events: every new notification of the user named in X-User-ID is sent as a "notification" event
+// @Description with the notification as data and its ID as event ID. Idle streams get a comment every 25 seconds.
+// @Description Notifications sent while disconnected aren't replayed, list them with GET /notifications.
+// @Tags notifications
+// @Produce text/event-stream
+// @Param X-User-ID header int true "User notified"
+// @Success 200 {object} api.Notification "Stream of notification events"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Router /v2/notifications/stream [get]
+func (h *NotificationsHandler) StreamNotifications(c echo.Context) error {
+	userID, ok, err := h.requestUser(c)
+	if !ok {
+		return err
+	}
+
+	notifications, cancel := h.notifier.Subscribe(userID)
+	defer cancel()
+
+	// The stream outlives the write timeout of the server
+	_ = http.NewResponseController(c.Response()).SetWriteDeadline(time.Time{})
+
+	resp := c.Response()
+	resp.Header().Set(echo.HeaderContentType, "text/event-stream")
+	resp.Header().Set(echo.HeaderCacheControl, "no-cache")
+	// Keeps nginx from buffering the events
+	resp.Header().Set("X-Accel-Buffering", "no")
+	resp.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprint(resp, ": connected\n\n"); err != nil {
+		return nil
+	}
+	resp.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		var event string
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case notification, open := <-notifications:
+			if !open {
+				// The server is shutting down
+				return nil
+			}
+			data, err := json.Marshal(api.Render(c, notification, api.NewNotification))
+			if err != nil {
+				return err
+			}
+			event = fmt.Sprintf("id: %d\nevent: notification\ndata: %s\n\n", notification.ID, data)
+		case <-ticker.C:
+			event = ": keep-alive\n\n"
+		}
+
+		// A failed write means the client is gone
+		if _, err := fmt.Fprint(resp, event); err != nil {
+			return nil
+		}
+		resp.Flush()
+	}
+}
+
+// requestUser returns the user named in X-User-ID. When ok is false the response was written
+// already (401 without X-User-ID or for an unknown user).
+func (h *NotificationsHandler) requestUser(c echo.Context) (userID int64, ok bool, err error) {
+	userID, ok = api.ViewerID(c)
+	if !ok {
+		return 0, false, c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "The " + api.HeaderUserID + " header is required",
+		})
+	}
+
+	if _, err := h.repo.UsersGetByID(c.Request().Context(), userID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "The " + api.HeaderUserID + " header names no user",
+			})
+		}
+		return 0, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch user",
+		})
+	}
+	return userID, true, nil
+}
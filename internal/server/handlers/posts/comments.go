@@ -0,0 +1,371 @@
+package posts
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/outbox"
+	"backendT/internal/server/api"
+)
+
+const (
+	// MaxCommentDepth is how deep replies nest, top level comments are at depth 0
+	MaxCommentDepth = 5
+	// MaxReportReason is the longest reason a report can give
+	MaxReportReason = 500
+)
+
+// CommentsRepo stores the comment threads of posts and their moderation.
+type CommentsRepo interface {
+	CommentReportsAdd(ctx context.Context, params repository.CommentReportsAddParams) error
+	CommentReportsDismiss(ctx context.Context, commentID int64) (int64, error)
+	CommentReportsGetQueue(ctx context.Context, limit int64) ([]repository.CommentReportsGetQueueRow, error)
+	CommentsGetByID(ctx context.Context, id int64) (repository.Comment, error)
+	CommentsGetVisibleByPostID(ctx context.Context, postID int64) ([]repository.Comment, error)
+	CommentsHide(ctx context.Context, id int64) (repository.Comment, error)
+	CommentsThreadHidden(ctx context.Context, id int64) (bool, error)
+	CommentsUnhide(ctx context.Context, id int64) (repository.Comment, error)
+}
+
+// CreateCommentRequest is the body of CreateComment, parent_id makes it a reply.
+type CreateCommentRequest struct {
+	Comment  string `json:"comment" example:"Nice post!"`
+	ParentID *int64 `json:"parent_id" example:"1"`
+}
+
+// ReportCommentRequest is the body of ReportComment.
+type ReportCommentRequest struct {
+	Reason string `json:"reason" example:"spam"`
+}
+
+// GetPostComments handles HTTP GET requests for the comments of a post.
+// @Summary Get post comments
+// @Description Returns the comments of a post as a tree, replies nested under the comment they answer, oldest first.
+// @Description Hidden comments are left out with their replies. Posts that are not published are only found by their author.
+// @Tags comments
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {array} api.Comment "Top level comments with their replies"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/comments [get]
+func (h *PostsHandler) GetPostComments(c echo.Context) error {
+	post, ok, err := h.lookupPost(c)
+	if !ok {
+		return err
+	}
+
+	comments, err := h.comments.CommentsGetVisibleByPostID(c.Request().Context(), post.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch comments",
+		})
+	}
+	comments = visibleThreads(comments)
+	if api.Legacy(c) {
+		return c.JSON(http.StatusOK, comments)
+	}
+	return c.JSON(http.StatusOK, api.CommentTree(comments))
+}
+
+// CreateComment handles HTTP POST requests commenting on a post.
+// @Summary Create comment
+// @Description Comments on the post as the user named in X-User-ID, or replies to a comment of the post with parent_id.
+// @Description Replies nest up to 5 levels deep.
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param X-User-ID header int true "User commenting"
+// @Param comment body CreateCommentRequest true "Comment"
+// @Success 201 {object} api.Comment "Created comment"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or payload, unknown or hidden parent, or too deep"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/comments [post]
+func (h *PostsHandler) CreateComment(c echo.Context) error {
+	var req CreateCommentRequest
+	if err := c.Bind(&req); err != nil || strings.TrimSpace(req.Comment) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request payload, comment is required",
+		})
+	}
+	userID, ok, err := h.requestUser(c)
+	if !ok {
+		return err
+	}
+	post, ok, err := h.lookupPost(c)
+	if !ok {
+		return err
+	}
+
+	params := repository.CommentsCreateParams{
+		PostID:  post.ID,
+		UserID:  userID,
+		Comment: req.Comment,
+	}
+	var parentUserID int64
+	if req.ParentID != nil {
+		parent, err := h.comments.CommentsGetByID(c.Request().Context(), *req.ParentID)
+		if err != nil && err != sql.ErrNoRows {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to fetch comment",
+			})
+		}
+		// Replies anywhere under a hidden comment are hidden with it, so they can't be added either
+		var hidden bool
+		if err == nil && parent.PostID == post.ID {
+			hidden, err = h.comments.CommentsThreadHidden(c.Request().Context(), parent.ID)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{
+					"error": "Failed to fetch comment",
+				})
+			}
+		}
+		if err == sql.ErrNoRows || parent.PostID != post.ID || hidden {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "The parent comment is not a comment of the post",
+			})
+		}
+		if parent.Depth >= MaxCommentDepth {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Replies can't be nested more than " + strconv.Itoa(MaxCommentDepth) + " levels deep",
+			})
+		}
+		params.ParentID = sql.NullInt64{Int64: parent.ID, Valid: true}
+		params.Depth = parent.Depth + 1
+		parentUserID = parent.UserID
+	}
+
+	ctx := c.Request().Context()
+	var comment repository.Comment
+	err = h.db.WithTx(ctx, func(q *repository.Queries) (err error) {
+		comment, err = q.CommentsCreate(ctx, params)
+		if err != nil {
+			return err
+		}
+		return outbox.Append(ctx, q, outbox.AggregatePost, post.ID, outbox.EventCommentCreated, outbox.CommentCreated{
+			CommentID:      comment.ID,
+			PostID:         post.ID,
+			UserID:         userID,
+			PostAuthorID:   post.UserID,
+			ParentAuthorID: parentUserID,
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create comment",
+		})
+	}
+	return c.JSON(http.StatusCreated, api.Render(c, comment, api.NewComment))
+}
+
+// ReportComment handles HTTP POST requests flagging a comment for the moderators.
+// @Summary Report comment
+// @Description Reports the comment as the user named in X-User-ID, it shows up in the moderation queue. Reporting a comment twice changes nothing.
+// @Tags comments
+// @Accept json
+// @Param id path int true "Comment ID"
+// @Param X-User-ID header int true "User reporting"
+// @Param report body ReportCommentRequest true "Why the comment is reported"
+// @Success 204 "Reported"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or payload"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/comments/id/{id}/report [post]
+func (h *PostsHandler) ReportComment(c echo.Context) error {
+	var req ReportCommentRequest
+	if err := c.Bind(&req); err != nil || strings.TrimSpace(req.Reason) == "" || len(req.Reason) > MaxReportReason {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request payload, a reason of up to " + strconv.Itoa(MaxReportReason) + " characters is required",
+		})
+	}
+	userID, ok, err := h.requestUser(c)
+	if !ok {
+		return err
+	}
+	comment, ok, err := h.lookupComment(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.comments.CommentReportsAdd(c.Request().Context(), repository.CommentReportsAddParams{
+		CommentID: comment.ID,
+		UserID:    userID,
+		Reason:    strings.TrimSpace(req.Reason),
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to report comment",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetCommentReports handles HTTP GET requests for the moderation queue.
+// @Summary Get reported comments
+// @Description Returns the comments with open reports, the most reported first. Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param limit query int false "Number of comments, 1 to 100 (default 20)"
+// @Success 200 {array} api.ReportedComment "Reported comments"
+// @Failure 400 {object} map[string]string "Bad request - invalid limit"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/comments/reports [get]
+func (h *PostsHandler) GetCommentReports(c echo.Context) error {
+	limit, ok := api.Limit(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit parameter, expected 1 to 100",
+		})
+	}
+
+	queue, err := h.comments.CommentReportsGetQueue(c.Request().Context(), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch reports",
+		})
+	}
+	reported := make([]api.ReportedComment, len(queue))
+	for i, row := range queue {
+		reported[i] = api.NewReportedComment(row)
+	}
+	return c.JSON(http.StatusOK, reported)
+}
+
+// HideComment handles HTTP POST requests hiding a comment.
+// @Summary Hide comment
+// @Description Hides the comment and its replies from the public responses and settles its open reports. Requires the admin token.
+// @Tags admin
+// @Security AdminToken
+// @Param id path int true "Comment ID"
+// @Success 204 "Hidden"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/comments/{id}/hide [post]
+func (h *PostsHandler) HideComment(c echo.Context) error {
+	return h.moderateComment(c, func(ctx context.Context, id int64) error {
+		_, err := h.comments.CommentsHide(ctx, id)
+		return err
+	})
+}
+
+// UnhideComment handles HTTP POST requests showing a hidden comment again.
+// @Summary Unhide comment
+// @Description Shows a hidden comment and its replies again. Requires the admin token.
+// @Tags admin
+// @Security AdminToken
+// @Param id path int true "Comment ID"
+// @Success 204 "Shown again"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/comments/{id}/unhide [post]
+func (h *PostsHandler) UnhideComment(c echo.Context) error {
+	return h.moderateComment(c, func(ctx context.Context, id int64) error {
+		_, err := h.comments.CommentsUnhide(ctx, id)
+		return err
+	})
+}
+
+// DismissCommentReports handles HTTP POST requests dismissing the reports of a comment.
+// @Summary Dismiss comment reports
+// @Description Settles the open reports of the comment without hiding it, which takes it out of the queue. Requires the admin token.
+// @Tags admin
+// @Security AdminToken
+// @Param id path int true "Comment ID"
+// @Success 204 "Dismissed"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/comments/{id}/dismiss [post]
+func (h *PostsHandler) DismissCommentReports(c echo.Context) error {
+	return h.moderateComment(c, func(ctx context.Context, id int64) error {
+		if _, err := h.comments.CommentsGetByID(ctx, id); err != nil {
+			return err
+		}
+		_, err := h.comments.CommentReportsDismiss(ctx, id)
+		return err
+	})
+}
+
+func (h *PostsHandler) moderateComment(c echo.Context, moderate func(ctx context.Context, id int64) error) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid comment ID format",
+		})
+	}
+
+	if err := moderate(c.Request().Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Comment not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to moderate comment",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// visibleThreads drops the replies to comments that are missing from comments, because they are hidden.
+// Replies always come after the comment they answer when sorted by ID.
+func visibleThreads(comments []repository.Comment) []repository.Comment {
+	kept := map[int64]bool{}
+	threads := []repository.Comment{}
+	for _, comment := range comments {
+		if !comment.ParentID.Valid || kept[comment.ParentID.Int64] {
+			kept[comment.ID] = true
+			threads = append(threads, comment)
+		}
+	}
+	return threads
+}
+
+// lookupComment fetches the comment of the request, if it isn't hidden and the user making it may see its post.
+// When ok is false the response was written already (400 or 404) and err is its result.
+func (h *PostsHandler) lookupComment(c echo.Context) (comment repository.Comment, ok bool, err error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return comment, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid comment ID format",
+		})
+	}
+
+	comment, err = h.comments.CommentsGetByID(c.Request().Context(), id)
+	if err == nil {
+		var post repository.Post
+		post, err = h.repo.PostsGetByID(c.Request().Context(), comment.PostID)
+		if err == nil && (!visible(c, post) || comment.HiddenAt.Valid) {
+			err = sql.ErrNoRows
+		}
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return comment, false, c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Comment not found",
+			})
+		}
+		return comment, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch comment",
+		})
+	}
+	return comment, true, nil
+}
@@ -0,0 +1,99 @@
+package posts
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/server/api"
+)
+
+// feedStart is the key of the first feed page, after every stored published_at. It has to stay
+// a date: SQLite would compare a plain number to the published_at column as a number.
+const feedStart = "9999-12-31"
+
+// FeedRepo finds the posts of followed users.
+type FeedRepo interface {
+	PostsGetFeed(ctx context.Context, params repository.PostsGetFeedParams) ([]repository.PostsGetFeedRow, error)
+}
+
+// GetFeed handles HTTP GET requests for the feed of the user making the request.
+// @Summary Get feed
+// @Description Returns the published posts of the users followed by the user named in X-User-ID, newest first.
+// @Description When there are more, the Link header points to the next page.
+// @Tags follows
+// @Produce json
+// @Param X-User-ID header int true "User whose feed it is"
+// @Param limit query int false "Number of posts, 1 to 100 (default 20)"
+// @Param cursor query string false "Cursor of the page, from the Link header of the previous one"
+// @Success 200 {array} api.Post "Posts, newest first"
+// @Header 200 {string} Link "<next page>; rel=\"next\""
+// @Failure 400 {object} map[string]string "Bad request - invalid limit or cursor"
+// @Failure 401 {object} map[string]string "X-User-ID is missing"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/feed [get]
+func (h *PostsHandler) GetFeed(c echo.Context) error {
+	viewerID, ok := api.ViewerID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "The " + api.HeaderUserID + " header is required",
+		})
+	}
+	limit, ok := api.Limit(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit parameter, expected 1 to 100",
+		})
+	}
+	params := repository.PostsGetFeedParams{
+		Before:     feedStart,
+		BeforeID:   math.MaxInt64,
+		FollowerID: viewerID,
+		Limit:      limit + 1,
+	}
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		key, ok := api.DecodeCursor(cursor, 2)
+		var err error
+		if ok {
+			params.Before = key[0]
+			params.BeforeID, err = strconv.ParseInt(key[1], 10, 64)
+		}
+		if !ok || err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid cursor parameter",
+			})
+		}
+	}
+
+	rows, err := h.feed.PostsGetFeed(c.Request().Context(), params)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch feed",
+		})
+	}
+	// One more than asked tells whether there is a next page
+	if int64(len(rows)) > limit {
+		rows = rows[:limit]
+		last := rows[limit-1]
+		api.SetNextPage(c, api.EncodeCursor(last.PublishedAtKey, strconv.FormatInt(last.ID, 10)))
+	}
+
+	posts := make([]repository.Post, len(rows))
+	for i, row := range rows {
+		posts[i] = repository.Post{
+			ID:          row.ID,
+			UserID:      row.UserID,
+			Title:       row.Title,
+			Content:     row.Content,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			Status:      row.Status,
+			PublishedAt: row.PublishedAt,
+		}
+	}
+	return h.respondWithPosts(c, posts)
+}
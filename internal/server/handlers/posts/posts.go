@@ -2,63 +2,132 @@ package posts
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
 	"backendT/internal/database/repository"
+	"backendT/internal/httpcache"
+	"backendT/internal/outbox"
+	"backendT/internal/server/api"
+)
+
+// Post statuses, only published posts are shown to other users than their author.
+const (
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
 )
 
 type Repo interface {
-	PostsCreate(ctx context.Context, params repository.PostsCreateParams) (repository.Post, error)
-	PostsGetAll(ctx context.Context) ([]repository.Post, error)
+	PostsGetVisible(ctx context.Context, viewerID int64) ([]repository.Post, error)
 	PostsGetByID(ctx context.Context, userID int64) (repository.Post, error)
-	PostsGetByUserID(ctx context.Context, userID int64) ([]repository.Post, error)
+	PostsGetVisibleByUserID(ctx context.Context, params repository.PostsGetVisibleByUserIDParams) ([]repository.Post, error)
+}
+
+// TxRunner runs fn in a transaction, database.Service implements it. The writes go through it so their
+// outbox events are stored with them.
+type TxRunner interface {
+	WithTx(ctx context.Context, fn func(q *repository.Queries) error) error
+}
+
+// CreatePostRequest is the body of CreatePost. Posts are published right away unless the status says
+// otherwise, a publish_at in the future schedules them.
+type CreatePostRequest struct {
+	UserID    int64      `json:"user_id" example:"1"`
+	Title     string     `json:"title" example:"Hello World"`
+	Content   string     `json:"content" example:"My first post"`
+	Status    string     `json:"status" example:"draft" enums:"draft,scheduled,published"`
+	PublishAt *time.Time `json:"publish_at" example:"2025-01-31T12:00:00Z" format:"date-time"`
+}
+
+// PublishPostRequest is the optional body of PublishPost, a publish_at in the future schedules the post.
+type PublishPostRequest struct {
+	PublishAt *time.Time `json:"publish_at" example:"2025-01-31T12:00:00Z" format:"date-time"`
+}
+
+// UpdatePostRequest is the body of UpdatePost, fields left empty keep their current value.
+type UpdatePostRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
 }
 
 type PostsHandler struct {
-	repo Repo
+	repo      Repo
+	revisions RevisionsRepo
+	tags      TagsRepo
+	reactions ReactionsRepo
+	feed      FeedRepo
+	comments  CommentsRepo
+	db        TxRunner
 }
 
-func NewPostsHandler(r *repository.Queries) *PostsHandler {
+func NewPostsHandler(r *repository.Queries, db TxRunner) *PostsHandler {
 	return &PostsHandler{
-		repo: r,
+		repo:      r,
+		revisions: r,
+		tags:      r,
+		reactions: r,
+		feed:      r,
+		comments:  r,
+		db:        db,
 	}
 }
 
 // GetAllPosts handles HTTP GET requests to retrieve all posts.
 // @Summary Get all posts
-// @Description Returns a list of all posts from the database.
+// @Description Returns the published posts, and every post of the user named in X-User-ID.
+// @Description With tags, only the posts with any (match=any, default) or all (match=all) of them.
 // @Tags posts
 // @Produce json
-// @Success 200 {array} repository.Post "List of posts"
+// @Param tags query string false "Comma separated tags, e.g. golang,sqlite"
+// @Param match query string false "any or all of the tags" Enums(any, all)
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {array} api.Post "List of posts"
+// @Failure 400 {object} map[string]string "Bad request - invalid tags or match"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /posts [get]
+// @Router /v2/posts [get]
 func (h *PostsHandler) GetAllPosts(c echo.Context) error {
-	posts, err := h.repo.PostsGetAll(c.Request().Context())
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to fetch posts",
-		})
+	viewerID, _ := api.ViewerID(c)
+	var posts []repository.Post
+	if c.QueryParam("tags") != "" {
+		var ok bool
+		var err error
+		if posts, ok, err = h.getPostsByTags(c, viewerID); !ok {
+			return err
+		}
+	} else {
+		var err error
+		if posts, err = h.repo.PostsGetVisible(c.Request().Context(), viewerID); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to fetch posts",
+			})
+		}
 	}
-	return c.JSON(http.StatusOK, posts)
+
+	httpcache.SetLastModified(c, lastModified(posts)...)
+	return h.respondWithPosts(c, posts)
 }
 
 // CreatePost handles HTTP POST requests to create a new post.
 // @Summary Create a new post
 // @Description Creates a new post in the database. Expects a JSON body with the required fields.
+// @Description The post is published right away, unless its status is draft or scheduled (with a publish_at in the future).
 // @Tags posts
 // @Accept json
 // @Produce json
-// @Param post body repository.PostsCreateParams true "New post payload"
-// @Success 201 {object} repository.Post "Created post"
+// @Param post body CreatePostRequest true "New post payload"
+// @Success 201 {object} api.Post "Created post"
 // @Failure 400 {object} map[string]string "Bad request - invalid payload"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /posts [post]
+// @Router /v2/posts [post]
 func (h *PostsHandler) CreatePost(c echo.Context) error {
-	var newPost repository.Post
+	var newPost CreatePostRequest
 	if err := c.Bind(&newPost); err != nil {
 		return c.JSON(http.StatusBadRequest, map[string]string{
 			"error": "Invalid request payload" + err.Error(),
@@ -66,9 +135,40 @@ func (h *PostsHandler) CreatePost(c echo.Context) error {
 		})
 	}
 
-	createdUser, err := h.repo.PostsCreate(c.Request().Context(), repository.PostsCreateParams{
-		Title:   newPost.Title,
-		Content: newPost.Content,
+	now := time.Now().UTC().Truncate(time.Second)
+	if newPost.Status == "" {
+		newPost.Status = StatusPublished
+		if newPost.PublishAt != nil && newPost.PublishAt.After(now) {
+			newPost.Status = StatusScheduled
+		}
+	}
+	var publishedAt sql.NullTime
+	switch newPost.Status {
+	case StatusDraft:
+	case StatusPublished:
+		publishedAt = sql.NullTime{Time: now, Valid: true}
+	case StatusScheduled:
+		if newPost.PublishAt == nil || !newPost.PublishAt.After(now) {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Scheduled posts need a publish_at in the future",
+			})
+		}
+		publishedAt = sql.NullTime{Time: newPost.PublishAt.UTC().Truncate(time.Second), Valid: true}
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid status, expected draft, scheduled or published",
+		})
+	}
+
+	ctx := c.Request().Context()
+	createdUser, err := h.writePost(ctx, outbox.EventPostCreated, func(q *repository.Queries) (repository.Post, error) {
+		return q.PostsCreateWithStatus(ctx, repository.PostsCreateWithStatusParams{
+			UserID:      newPost.UserID,
+			Title:       newPost.Title,
+			Content:     newPost.Content,
+			Status:      newPost.Status,
+			PublishedAt: publishedAt,
+		})
 	})
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -76,20 +176,21 @@ func (h *PostsHandler) CreatePost(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusCreated, createdUser)
+	return c.JSON(http.StatusCreated, api.Render(c, createdUser, api.NewPost))
 }
 
 // GetPostByID handles HTTP GET requests to retrieve a post by their ID.
 // @Summary Get post by ID
-// @Description Fetches a single post by numeric ID.
+// @Description Fetches a single post by numeric ID. Posts that are not published are only found by their author.
 // @Tags posts
 // @Produce json
 // @Param id path int true "Post ID"
-// @Success 200 {object} repository.Post "Found post"
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {object} api.Post "Found post"
 // @Failure 400 {object} map[string]string "Bad request - invalid ID"
 // @Failure 404 {object} map[string]string "Post not found"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /posts/id/{id} [get]
+// @Router /v2/posts/id/{id} [get]
 func (h *PostsHandler) GetPostByID(c echo.Context) error {
 	idstr := c.Param("id")
 	id, err := strconv.ParseInt(idstr, 10, 64)
@@ -100,27 +201,37 @@ func (h *PostsHandler) GetPostByID(c echo.Context) error {
 	}
 
 	post, err := h.repo.PostsGetByID(c.Request().Context(), id)
+	if err == nil && !visible(c, post) {
+		err = sql.ErrNoRows
+	}
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Post not found",
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to fetch user",
 		})
 	}
 
-	return c.JSON(http.StatusOK, post)
+	httpcache.SetLastModified(c, post.UpdatedAt.Time)
+	return h.respondWithPost(c, post)
 
 }
 
 // GetPostByUserID handles HTTP GET requests to retrieve a post by user ID.
 // @Summary Get post by user ID
-// @Description Fetches a single post by its user ID.
+// @Description Fetches the posts of a user, only the published ones unless X-User-ID is that user.
 // @Tags posts
 // @Produce json
 // @Param userid path int true "User ID"
-// @Success 200 {object} repository.Post "Found post"
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {array} api.Post "Posts of the user"
 // @Failure 400 {object} map[string]string "Bad request - invalid user ID"
 // @Failure 404 {object} map[string]string "Post not found"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /posts/userid/{userid} [get]
+// @Router /v2/posts/userid/{userid} [get]
 func (h *PostsHandler) GetPostByUserID(c echo.Context) error {
 	userID := c.Param("userid")
 
@@ -131,13 +242,342 @@ func (h *PostsHandler) GetPostByUserID(c echo.Context) error {
 		})
 	}
 
-	user, err := h.repo.PostsGetByUserID(c.Request().Context(), userIDInt)
+	viewerID, _ := api.ViewerID(c)
+	user, err := h.repo.PostsGetVisibleByUserID(c.Request().Context(), repository.PostsGetVisibleByUserIDParams{
+		UserID:   userIDInt,
+		ViewerID: viewerID,
+	})
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to fetch user",
 		})
 	}
 
-	return c.JSON(http.StatusOK, user)
+	httpcache.SetLastModified(c, lastModified(user)...)
+	return h.respondWithPosts(c, user)
+
+}
+
+// UpdatePost handles HTTP PUT requests to change the title and content of a post.
+// @Summary Update post
+// @Description Changes the title and/or content of a post. Send the ETag of the post as last fetched in If-Match to make sure nobody changed it in the meantime.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param If-Match header string false "ETag of the post as last fetched"
+// @Param post body UpdatePostRequest true "New title and content"
+// @Success 200 {object} api.Post "Updated post"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or payload"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 412 {object} map[string]string "The post was modified since it was fetched"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id} [put]
+func (h *PostsHandler) UpdatePost(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid post ID format",
+		})
+	}
+
+	var req UpdatePostRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request payload",
+		})
+	}
+
+	current, err := h.repo.PostsGetByID(c.Request().Context(), id)
+	if err == nil && !visible(c, current) {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Post not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch post",
+		})
+	}
+	if !httpcache.IfMatch(c, current) {
+		return c.JSON(http.StatusPreconditionFailed, map[string]string{
+			"error": "Post was modified since it was fetched",
+		})
+	}
+
+	params := repository.PostsUpdateByIDParams{
+		Title:      current.Title,
+		Content:    current.Content,
+		ID:         id,
+		OldTitle:   current.Title,
+		OldContent: current.Content,
+	}
+	if req.Title != "" {
+		params.Title = req.Title
+	}
+	if req.Content != "" {
+		params.Content = req.Content
+	}
+
+	// Only updates if the post is still the one checked above
+	ctx := c.Request().Context()
+	post, err := h.writePost(ctx, outbox.EventPostUpdated, func(q *repository.Queries) (repository.Post, error) {
+		return q.PostsUpdateByID(ctx, params)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusPreconditionFailed, map[string]string{
+				"error": "Post was modified since it was fetched",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update post",
+		})
+	}
+
+	body, err := h.renderPost(c, post)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch reactions",
+		})
+	}
+	httpcache.SetLastModified(c, post.UpdatedAt.Time)
+	return c.JSON(http.StatusOK, body)
+}
+
+// PublishPost handles HTTP POST requests publishing a post, now or at publish_at.
+// @Summary Publish post
+// @Description Publishes a post of the user named in X-User-ID. With a publish_at in the future the post is scheduled instead, and published by the server when it is due.
+// @Description Publishing a post that is already published changes nothing.
+// @Tags posts
+// @Accept json
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param X-User-ID header int true "Author of the post"
+// @Param publish body PublishPostRequest false "When to publish"
+// @Success 200 {object} api.Post "Published or scheduled post"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or payload"
+// @Failure 401 {object} map[string]string "X-User-ID is missing"
+// @Failure 403 {object} map[string]string "Not the author of the post"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/publish [post]
+func (h *PostsHandler) PublishPost(c echo.Context) error {
+	var req PublishPostRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request payload",
+		})
+	}
+
+	post, ok, err := h.lookupOwnPost(c)
+	if !ok {
+		return err
+	}
 
+	now := time.Now().UTC().Truncate(time.Second)
+	params := repository.PostsUpdateStatusByIDParams{
+		Status:      StatusPublished,
+		PublishedAt: sql.NullTime{Time: now, Valid: true},
+		ID:          post.ID,
+	}
+	if req.PublishAt != nil && req.PublishAt.After(now) {
+		params.Status = StatusScheduled
+		params.PublishedAt.Time = req.PublishAt.UTC().Truncate(time.Second)
+	} else if post.Status == StatusPublished {
+		return h.respondWithPost(c, post)
+	}
+
+	return h.updateStatus(c, params)
+}
+
+// UnpublishPost handles HTTP POST requests turning a post back into a draft.
+// @Summary Unpublish post
+// @Description Turns a published, scheduled or archived post of the user named in X-User-ID back into a draft.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param X-User-ID header int true "Author of the post"
+// @Success 200 {object} api.Post "Draft"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "X-User-ID is missing"
+// @Failure 403 {object} map[string]string "Not the author of the post"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/unpublish [post]
+func (h *PostsHandler) UnpublishPost(c echo.Context) error {
+	post, ok, err := h.lookupOwnPost(c)
+	if !ok {
+		return err
+	}
+
+	return h.updateStatus(c, repository.PostsUpdateStatusByIDParams{
+		Status: StatusDraft,
+		ID:     post.ID,
+	})
+}
+
+// ArchivePost handles HTTP POST requests archiving a post.
+// @Summary Archive post
+// @Description Archives a post of the user named in X-User-ID, it is hidden from other users but keeps its published_at.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param X-User-ID header int true "Author of the post"
+// @Success 200 {object} api.Post "Archived post"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "X-User-ID is missing"
+// @Failure 403 {object} map[string]string "Not the author of the post"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/archive [post]
+func (h *PostsHandler) ArchivePost(c echo.Context) error {
+	post, ok, err := h.lookupOwnPost(c)
+	if !ok {
+		return err
+	}
+
+	publishedAt := post.PublishedAt
+	if post.Status == StatusScheduled {
+		// It never went live
+		publishedAt = sql.NullTime{}
+	}
+	return h.updateStatus(c, repository.PostsUpdateStatusByIDParams{
+		Status:      StatusArchived,
+		PublishedAt: publishedAt,
+		ID:          post.ID,
+	})
+}
+
+// lookupOwnPost fetches the post of the request for a change by its author. When ok is false the
+// response was written already (400, 401 without X-User-ID, 404, or 403 for other users) and err is its result.
+func (h *PostsHandler) lookupOwnPost(c echo.Context) (post repository.Post, ok bool, err error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return post, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid post ID format",
+		})
+	}
+	viewerID, ok := api.ViewerID(c)
+	if !ok {
+		return post, false, c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "The " + api.HeaderUserID + " header is required",
+		})
+	}
+
+	post, err = h.repo.PostsGetByID(c.Request().Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return post, false, c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Post not found",
+			})
+		}
+		return post, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch post",
+		})
+	}
+	if post.UserID != viewerID {
+		if !visible(c, post) {
+			return post, false, c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Post not found",
+			})
+		}
+		return post, false, c.JSON(http.StatusForbidden, map[string]string{
+			"error": "Only the author can change the status of a post",
+		})
+	}
+	return post, true, nil
+}
+
+func (h *PostsHandler) updateStatus(c echo.Context, params repository.PostsUpdateStatusByIDParams) error {
+	ctx := c.Request().Context()
+	post, err := h.writePost(ctx, outbox.EventPostUpdated, func(q *repository.Queries) (repository.Post, error) {
+		return q.PostsUpdateStatusByID(ctx, params)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Post not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update post",
+		})
+	}
+
+	httpcache.SetLastModified(c, post.UpdatedAt.Time)
+	return h.respondWithPost(c, post)
+}
+
+// writePost runs write in a transaction, together with storing the outbox event of the post it returns.
+func (h *PostsHandler) writePost(ctx context.Context, eventType string, write func(q *repository.Queries) (repository.Post, error)) (repository.Post, error) {
+	var post repository.Post
+	err := h.db.WithTx(ctx, func(q *repository.Queries) (err error) {
+		post, err = write(q)
+		if err != nil {
+			return err
+		}
+		return outbox.Append(ctx, q, outbox.AggregatePost, post.ID, eventType, api.NewPost(post))
+	})
+	return post, err
+}
+
+// visible reports whether the user making the request may see the post: it is published, or theirs.
+func visible(c echo.Context, post repository.Post) bool {
+	if post.Status == StatusPublished {
+		return true
+	}
+	viewerID, ok := api.ViewerID(c)
+	return ok && viewerID == post.UserID
+}
+
+// respondWithPost writes the post, from v2 on with its reaction counts.
+func (h *PostsHandler) respondWithPost(c echo.Context, post repository.Post) error {
+	body, err := h.renderPost(c, post)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch reactions",
+		})
+	}
+	return c.JSON(http.StatusOK, body)
+}
+
+// respondWithPosts writes the posts, from v2 on with their reaction counts.
+func (h *PostsHandler) respondWithPosts(c echo.Context, posts []repository.Post) error {
+	if api.Legacy(c) {
+		return c.JSON(http.StatusOK, posts)
+	}
+	reactions, err := h.reactionCounts(c.Request().Context(), posts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch reactions",
+		})
+	}
+	return c.JSON(http.StatusOK, api.RenderAll(c, posts, api.NewPostWithReactions(reactions)))
+}
+
+// renderPost is api.Render for a post, with its reaction counts from v2 on. It sets the ETag of the post,
+// the reactions are its volatile part: reacting to a post doesn't fail the If-Match of an update.
+func (h *PostsHandler) renderPost(c echo.Context, post repository.Post) (any, error) {
+	if api.Legacy(c) {
+		httpcache.SetETag(c, post)
+		return post, nil
+	}
+	reactions, err := h.reactionCounts(c.Request().Context(), []repository.Post{post})
+	if err != nil {
+		return nil, err
+	}
+	httpcache.SetETag(c, post, reactions[post.ID])
+	return api.Render(c, post, api.NewPostWithReactions(reactions)), nil
+}
+
+func lastModified(posts []repository.Post) []time.Time {
+	modified := make([]time.Time, len(posts))
+	for i, post := range posts {
+		modified[i] = post.UpdatedAt.Time
+	}
+	return modified
 }
@@ -0,0 +1,434 @@
+package posts
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/outbox"
+	"backendT/internal/server/api"
+)
+
+// ReactionTypes are the reactions users can leave on posts and comments.
+var ReactionTypes = []string{"like", "love", "laugh", "wow", "sad", "angry"}
+
+const (
+	// TrendingGravity is how fast posts sink in GET /posts/trending as they get older
+	TrendingGravity = 1.8
+	// Comments take more effort than reactions, they count double
+	trendingCommentWeight = 2
+	// Posts per PostReactionsCountByPostIDs query, sqlite limits the number of parameters
+	reactionCountsBatch = 500
+)
+
+// ReactionsRepo stores the reactions to posts and comments.
+type ReactionsRepo interface {
+	CommentReactionsSummary(ctx context.Context, params repository.CommentReactionsSummaryParams) ([]repository.CommentReactionsSummaryRow, error)
+	PostReactionsCountByPostIDs(ctx context.Context, postIDs []int64) ([]repository.PostReactionsCountByPostIDsRow, error)
+	PostReactionsSummary(ctx context.Context, params repository.PostReactionsSummaryParams) ([]repository.PostReactionsSummaryRow, error)
+	PostsGetEngagementSince(ctx context.Context, publishedAt sql.NullTime) ([]repository.PostsGetEngagementSinceRow, error)
+	UsersGetByID(ctx context.Context, id int64) (repository.User, error)
+}
+
+// TrendingRow is a post of GET /posts/trending as served by v1.
+type TrendingRow struct {
+	repository.PostsGetEngagementSinceRow
+	Score float64 `json:"score"`
+}
+
+// TrendingScore is the engagement of a post (its reactions, and its comments counting double)
+// decayed by its age like on Hacker News: engagement / (age in hours + 2) ^ TrendingGravity.
+func TrendingScore(reactions, comments int64, age time.Duration) float64 {
+	engagement := float64(reactions + trendingCommentWeight*comments)
+	return engagement / math.Pow(math.Max(age.Hours(), 0)+2, TrendingGravity)
+}
+
+// GetPostReactions handles HTTP GET requests for the reactions to a post.
+// @Summary Get post reactions
+// @Description Returns how many users left each reaction type on the post, and whether the user named in X-User-ID is one of them.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {array} api.ReactionCount "Reactions by type"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/reactions [get]
+func (h *PostsHandler) GetPostReactions(c echo.Context) error {
+	post, ok, err := h.lookupPost(c)
+	if !ok {
+		return err
+	}
+	return h.respondWithPostReactions(c, post.ID)
+}
+
+// AddPostReaction handles HTTP PUT requests reacting to a post.
+// @Summary Add post reaction
+// @Description Leaves a reaction of the user named in X-User-ID on the post. Adding a reaction twice changes nothing.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param type path string true "Reaction type" Enums(like, love, laugh, wow, sad, angry)
+// @Param X-User-ID header int true "User reacting"
+// @Success 200 {array} api.ReactionCount "Reactions by type"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or reaction type"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/reactions/{type} [put]
+func (h *PostsHandler) AddPostReaction(c echo.Context) error {
+	return h.changePostReaction(c, func(ctx context.Context, q *repository.Queries, post repository.Post, params repository.PostReactionsAddParams) error {
+		if err := q.PostReactionsAdd(ctx, params); err != nil {
+			return err
+		}
+		return outbox.Append(ctx, q, outbox.AggregatePost, post.ID, outbox.EventReactionAdded, outbox.ReactionAdded{
+			UserID:   params.UserID,
+			Type:     params.Type,
+			PostID:   post.ID,
+			AuthorID: post.UserID,
+		})
+	})
+}
+
+// RemovePostReaction handles HTTP DELETE requests taking back a reaction to a post.
+// @Summary Remove post reaction
+// @Description Takes back a reaction of the user named in X-User-ID. Removing a reaction that isn't there changes nothing.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param type path string true "Reaction type" Enums(like, love, laugh, wow, sad, angry)
+// @Param X-User-ID header int true "User reacting"
+// @Success 200 {array} api.ReactionCount "Reactions by type"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or reaction type"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/reactions/{type} [delete]
+func (h *PostsHandler) RemovePostReaction(c echo.Context) error {
+	return h.changePostReaction(c, func(ctx context.Context, q *repository.Queries, _ repository.Post, params repository.PostReactionsAddParams) error {
+		return q.PostReactionsRemove(ctx, repository.PostReactionsRemoveParams(params))
+	})
+}
+
+// GetCommentReactions handles HTTP GET requests for the reactions to a comment.
+// @Summary Get comment reactions
+// @Description Returns how many users left each reaction type on the comment, and whether the user named in X-User-ID is one of them.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {array} api.ReactionCount "Reactions by type"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/comments/id/{id}/reactions [get]
+func (h *PostsHandler) GetCommentReactions(c echo.Context) error {
+	comment, ok, err := h.lookupComment(c)
+	if !ok {
+		return err
+	}
+	return h.respondWithCommentReactions(c, comment.ID)
+}
+
+// AddCommentReaction handles HTTP PUT requests reacting to a comment.
+// @Summary Add comment reaction
+// @Description Leaves a reaction of the user named in X-User-ID on the comment. Adding a reaction twice changes nothing.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param type path string true "Reaction type" Enums(like, love, laugh, wow, sad, angry)
+// @Param X-User-ID header int true "User reacting"
+// @Success 200 {array} api.ReactionCount "Reactions by type"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or reaction type"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/comments/id/{id}/reactions/{type} [put]
+func (h *PostsHandler) AddCommentReaction(c echo.Context) error {
+	return h.changeCommentReaction(c, func(ctx context.Context, q *repository.Queries, comment repository.Comment, params repository.CommentReactionsAddParams) error {
+		if err := q.CommentReactionsAdd(ctx, params); err != nil {
+			return err
+		}
+		return outbox.Append(ctx, q, outbox.AggregatePost, comment.PostID, outbox.EventReactionAdded, outbox.ReactionAdded{
+			UserID:    params.UserID,
+			Type:      params.Type,
+			PostID:    comment.PostID,
+			CommentID: comment.ID,
+			AuthorID:  comment.UserID,
+		})
+	})
+}
+
+// RemoveCommentReaction handles HTTP DELETE requests taking back a reaction to a comment.
+// @Summary Remove comment reaction
+// @Description Takes back a reaction of the user named in X-User-ID. Removing a reaction that isn't there changes nothing.
+// @Tags reactions
+// @Produce json
+// @Param id path int true "Comment ID"
+// @Param type path string true "Reaction type" Enums(like, love, laugh, wow, sad, angry)
+// @Param X-User-ID header int true "User reacting"
+// @Success 200 {array} api.ReactionCount "Reactions by type"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or reaction type"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 404 {object} map[string]string "Comment not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/comments/id/{id}/reactions/{type} [delete]
+func (h *PostsHandler) RemoveCommentReaction(c echo.Context) error {
+	return h.changeCommentReaction(c, func(ctx context.Context, q *repository.Queries, _ repository.Comment, params repository.CommentReactionsAddParams) error {
+		return q.CommentReactionsRemove(ctx, repository.CommentReactionsRemoveParams(params))
+	})
+}
+
+// GetTrendingPosts handles HTTP GET requests for the most engaging recent posts.
+// @Summary Get trending posts
+// @Description Returns the posts published within window, ranked by their reactions and comments (counting double) decayed by their age:
+// @Description score = engagement / (age in hours + 2) ^ 1.8. Posts without any engagement are left out.
+// @Tags posts
+// @Produce json
+// @Param window query string false "How far back to look, as a duration up to 720h (default 168h)"
+// @Param limit query int false "Number of posts, 1 to 100 (default 20)"
+// @Success 200 {array} api.TrendingPost "Posts, best first"
+// @Failure 400 {object} map[string]string "Bad request - invalid window or limit"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/trending [get]
+func (h *PostsHandler) GetTrendingPosts(c echo.Context) error {
+	window := 7 * 24 * time.Hour
+	if c.QueryParam("window") != "" {
+		var err error
+		window, err = time.ParseDuration(c.QueryParam("window"))
+		if err != nil || window <= 0 || window > 30*24*time.Hour {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid window parameter, expected a duration up to 720h",
+			})
+		}
+	}
+	limit, ok := api.Limit(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit parameter, expected 1 to 100",
+		})
+	}
+
+	now := time.Now().UTC()
+	rows, err := h.reactions.PostsGetEngagementSince(c.Request().Context(), sql.NullTime{
+		Time:  now.Add(-window).Truncate(time.Second),
+		Valid: true,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch posts",
+		})
+	}
+
+	trending := []TrendingRow{}
+	for _, row := range rows {
+		if row.ReactionCount+row.CommentCount == 0 {
+			continue
+		}
+		trending = append(trending, TrendingRow{
+			PostsGetEngagementSinceRow: row,
+			Score:                      TrendingScore(row.ReactionCount, row.CommentCount, now.Sub(row.PublishedAt.Time)),
+		})
+	}
+	sort.SliceStable(trending, func(i, j int) bool {
+		if trending[i].Score != trending[j].Score {
+			return trending[i].Score > trending[j].Score
+		}
+		return trending[i].ID > trending[j].ID
+	})
+	if int64(len(trending)) > limit {
+		trending = trending[:limit]
+	}
+
+	if api.Legacy(c) {
+		return c.JSON(http.StatusOK, trending)
+	}
+	posts := make([]repository.Post, len(trending))
+	for i, row := range trending {
+		posts[i] = trendingPost(row)
+	}
+	reactions, err := h.reactionCounts(c.Request().Context(), posts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch reactions",
+		})
+	}
+	render := api.NewPostWithReactions(reactions)
+	models := make([]api.TrendingPost, len(trending))
+	for i, row := range trending {
+		models[i] = api.TrendingPost{
+			Post:          render(posts[i]),
+			ReactionCount: row.ReactionCount,
+			CommentCount:  row.CommentCount,
+			Score:         row.Score,
+		}
+	}
+	return c.JSON(http.StatusOK, models)
+}
+
+func trendingPost(row TrendingRow) repository.Post {
+	return repository.Post{
+		ID:          row.ID,
+		UserID:      row.UserID,
+		Title:       row.Title,
+		Content:     row.Content,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+		Status:      row.Status,
+		PublishedAt: row.PublishedAt,
+	}
+}
+
+// reactionCounts returns the reaction counts of the posts by post ID and reaction type.
+func (h *PostsHandler) reactionCounts(ctx context.Context, posts []repository.Post) (map[int64]map[string]int64, error) {
+	counts := map[int64]map[string]int64{}
+	for start := 0; start < len(posts); start += reactionCountsBatch {
+		batch := posts[start:min(start+reactionCountsBatch, len(posts))]
+		ids := make([]int64, len(batch))
+		for i, post := range batch {
+			ids[i] = post.ID
+		}
+
+		rows, err := h.reactions.PostReactionsCountByPostIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if counts[row.PostID] == nil {
+				counts[row.PostID] = map[string]int64{}
+			}
+			counts[row.PostID][row.Type] = row.Count
+		}
+	}
+	return counts, nil
+}
+
+// changePostReaction runs change in a transaction, so the outbox events it stores go with it.
+func (h *PostsHandler) changePostReaction(c echo.Context, change func(context.Context, *repository.Queries, repository.Post, repository.PostReactionsAddParams) error) error {
+	reaction, userID, ok, err := h.reactionRequest(c)
+	if !ok {
+		return err
+	}
+	post, ok, err := h.lookupPost(c)
+	if !ok {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.db.WithTx(ctx, func(q *repository.Queries) error {
+		return change(ctx, q, post, repository.PostReactionsAddParams{
+			PostID: post.ID,
+			UserID: userID,
+			Type:   reaction,
+		})
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update reactions",
+		})
+	}
+	return h.respondWithPostReactions(c, post.ID)
+}
+
+// changeCommentReaction runs change in a transaction, so the outbox events it stores go with it.
+func (h *PostsHandler) changeCommentReaction(c echo.Context, change func(context.Context, *repository.Queries, repository.Comment, repository.CommentReactionsAddParams) error) error {
+	reaction, userID, ok, err := h.reactionRequest(c)
+	if !ok {
+		return err
+	}
+	comment, ok, err := h.lookupComment(c)
+	if !ok {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	if err := h.db.WithTx(ctx, func(q *repository.Queries) error {
+		return change(ctx, q, comment, repository.CommentReactionsAddParams{
+			CommentID: comment.ID,
+			UserID:    userID,
+			Type:      reaction,
+		})
+	}); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update reactions",
+		})
+	}
+	return h.respondWithCommentReactions(c, comment.ID)
+}
+
+// reactionRequest checks the reaction type and the user of a request changing a reaction. When ok is false
+// the response was written already (400 for an unknown type, 401 without X-User-ID or for an unknown user).
+func (h *PostsHandler) reactionRequest(c echo.Context) (reaction string, userID int64, ok bool, err error) {
+	reaction = c.Param("type")
+	known := false
+	for _, t := range ReactionTypes {
+		known = known || t == reaction
+	}
+	if !known {
+		return "", 0, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid reaction type, expected like, love, laugh, wow, sad or angry",
+		})
+	}
+
+	userID, ok, err = h.requestUser(c)
+	if !ok {
+		return "", 0, false, err
+	}
+	return reaction, userID, true, nil
+}
+
+// requestUser returns the user named in X-User-ID of a request changing something. When ok is false
+// the response was written already (401 without X-User-ID or for an unknown user).
+func (h *PostsHandler) requestUser(c echo.Context) (userID int64, ok bool, err error) {
+	userID, ok = api.ViewerID(c)
+	if !ok {
+		return 0, false, c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "The " + api.HeaderUserID + " header is required",
+		})
+	}
+	if _, err := h.reactions.UsersGetByID(c.Request().Context(), userID); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, false, c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "The " + api.HeaderUserID + " header names no user",
+			})
+		}
+		return 0, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch user",
+		})
+	}
+	return userID, true, nil
+}
+
+func (h *PostsHandler) respondWithPostReactions(c echo.Context, postID int64) error {
+	viewerID, _ := api.ViewerID(c)
+	summary, err := h.reactions.PostReactionsSummary(c.Request().Context(), repository.PostReactionsSummaryParams{
+		ViewerID: viewerID,
+		PostID:   postID,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch reactions",
+		})
+	}
+	return c.JSON(http.StatusOK, api.RenderAll(c, summary, api.NewReactionCount))
+}
+
+func (h *PostsHandler) respondWithCommentReactions(c echo.Context, commentID int64) error {
+	viewerID, _ := api.ViewerID(c)
+	summary, err := h.reactions.CommentReactionsSummary(c.Request().Context(), repository.CommentReactionsSummaryParams{
+		ViewerID:  viewerID,
+		CommentID: commentID,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch reactions",
+		})
+	}
+	return c.JSON(http.StatusOK, api.RenderAll(c, summary, api.NewReactionCountFromComment))
+}
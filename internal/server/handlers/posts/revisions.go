@@ -0,0 +1,273 @@
+package posts
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/httpcache"
+	"backendT/internal/outbox"
+	"backendT/internal/server/api"
+	"backendT/internal/textdiff"
+)
+
+// RevisionsRepo reads the revisions of the posts, the database records them on every change of a title or content.
+type RevisionsRepo interface {
+	PostRevisionsGetByPostID(ctx context.Context, postID int64) ([]repository.PostRevision, error)
+	PostRevisionsGetByRevision(ctx context.Context, params repository.PostRevisionsGetByRevisionParams) (repository.PostRevision, error)
+	PostRevisionsGetLatest(ctx context.Context, postID int64) (repository.PostRevision, error)
+}
+
+// RevisionDiff is the difference between two revisions of a post, see textdiff.Chunk.
+type RevisionDiff struct {
+	PostID  int64            `json:"post_id" example:"1"`
+	From    int64            `json:"from" example:"1"`
+	To      int64            `json:"to" example:"2"`
+	Mode    string           `json:"mode" example:"line" enums:"line,word"`
+	Title   []textdiff.Chunk `json:"title"`
+	Content []textdiff.Chunk `json:"content"`
+}
+
+// GetPostRevisions handles HTTP GET requests listing the revisions of a post.
+// @Summary Get post revisions
+// @Description Returns every title and content the post had, oldest first. Posts that are not published are only found by their author.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {array} api.PostRevision "Revisions of the post"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/revisions [get]
+func (h *PostsHandler) GetPostRevisions(c echo.Context) error {
+	post, ok, err := h.lookupPost(c)
+	if !ok {
+		return err
+	}
+
+	revisions, err := h.revisions.PostRevisionsGetByPostID(c.Request().Context(), post.ID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch revisions",
+		})
+	}
+
+	httpcache.SetLastModified(c, post.UpdatedAt.Time)
+	return c.JSON(http.StatusOK, api.RenderAll(c, revisions, api.NewPostRevision))
+}
+
+// GetPostRevision handles HTTP GET requests for a single revision of a post.
+// @Summary Get post revision
+// @Description Returns the title and content a post had in the given revision.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param revision path int true "Revision number, from 1"
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {object} api.PostRevision "Revision"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or revision"
+// @Failure 404 {object} map[string]string "Post or revision not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/revisions/{revision} [get]
+func (h *PostsHandler) GetPostRevision(c echo.Context) error {
+	post, ok, err := h.lookupPost(c)
+	if !ok {
+		return err
+	}
+	revision, ok, err := h.lookupRevision(c, post.ID, c.Param("revision"))
+	if !ok {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, api.Render(c, revision, api.NewPostRevision))
+}
+
+// DiffPostRevisions handles HTTP GET requests comparing two revisions of a post.
+// @Summary Diff post revisions
+// @Description Compares the title and content of two revisions, line by line (default) or word by word.
+// @Description from defaults to the revision before to, to to the latest revision. Joining the equal and delete chunks gives the old text, the equal and insert chunks the new one.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param from query int false "Old revision"
+// @Param to query int false "New revision"
+// @Param mode query string false "line or word" Enums(line, word)
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {object} RevisionDiff "Difference"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID, revision or mode"
+// @Failure 404 {object} map[string]string "Post or revision not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/revisions/diff [get]
+func (h *PostsHandler) DiffPostRevisions(c echo.Context) error {
+	mode := c.QueryParam("mode")
+	diff := textdiff.Lines
+	switch mode {
+	case "", "line":
+		mode = "line"
+	case "word":
+		diff = textdiff.Words
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid mode, expected line or word",
+		})
+	}
+
+	post, ok, err := h.lookupPost(c)
+	if !ok {
+		return err
+	}
+
+	var to repository.PostRevision
+	if c.QueryParam("to") == "" {
+		to, err = h.revisions.PostRevisionsGetLatest(c.Request().Context(), post.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to fetch revision",
+			})
+		}
+	} else if to, ok, err = h.lookupRevision(c, post.ID, c.QueryParam("to")); !ok {
+		return err
+	}
+
+	from := to
+	if c.QueryParam("from") != "" {
+		if from, ok, err = h.lookupRevision(c, post.ID, c.QueryParam("from")); !ok {
+			return err
+		}
+	} else if to.Revision > 1 {
+		if from, ok, err = h.lookupRevision(c, post.ID, strconv.FormatInt(to.Revision-1, 10)); !ok {
+			return err
+		}
+	}
+
+	httpcache.SetLastModified(c, post.UpdatedAt.Time)
+	return c.JSON(http.StatusOK, RevisionDiff{
+		PostID:  post.ID,
+		From:    from.Revision,
+		To:      to.Revision,
+		Mode:    mode,
+		Title:   diff(from.Title, to.Title),
+		Content: diff(from.Content, to.Content),
+	})
+}
+
+// RestorePostRevision handles HTTP POST requests bringing back an older revision of a post.
+// @Summary Restore post revision
+// @Description Sets the title and content of the post back to the ones of the revision, which records a new revision.
+// @Description Send the ETag of the post as last fetched in If-Match to make sure nobody changed it in the meantime.
+// @Tags posts
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param revision path int true "Revision number, from 1"
+// @Param If-Match header string false "ETag of the post as last fetched"
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {object} api.Post "Updated post"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or revision"
+// @Failure 404 {object} map[string]string "Post or revision not found"
+// @Failure 412 {object} map[string]string "The post was modified since it was fetched"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/revisions/{revision}/restore [post]
+func (h *PostsHandler) RestorePostRevision(c echo.Context) error {
+	current, ok, err := h.lookupPost(c)
+	if !ok {
+		return err
+	}
+	revision, ok, err := h.lookupRevision(c, current.ID, c.Param("revision"))
+	if !ok {
+		return err
+	}
+	if !httpcache.IfMatch(c, current) {
+		return c.JSON(http.StatusPreconditionFailed, map[string]string{
+			"error": "Post was modified since it was fetched",
+		})
+	}
+
+	// Only updates if the post is still the one checked above
+	ctx := c.Request().Context()
+	post, err := h.writePost(ctx, outbox.EventPostUpdated, func(q *repository.Queries) (repository.Post, error) {
+		return q.PostsUpdateByID(ctx, repository.PostsUpdateByIDParams{
+			Title:      revision.Title,
+			Content:    revision.Content,
+			ID:         current.ID,
+			OldTitle:   current.Title,
+			OldContent: current.Content,
+		})
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusPreconditionFailed, map[string]string{
+				"error": "Post was modified since it was fetched",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update post",
+		})
+	}
+
+	body, err := h.renderPost(c, post)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch reactions",
+		})
+	}
+	httpcache.SetLastModified(c, post.UpdatedAt.Time)
+	return c.JSON(http.StatusOK, body)
+}
+
+// lookupPost fetches the post of the request if the user making it may see it. When ok is false
+// the response was written already (400 or 404) and err is its result.
+func (h *PostsHandler) lookupPost(c echo.Context) (post repository.Post, ok bool, err error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return post, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid post ID format",
+		})
+	}
+
+	post, err = h.repo.PostsGetByID(c.Request().Context(), id)
+	if err == nil && !visible(c, post) {
+		err = sql.ErrNoRows
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return post, false, c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Post not found",
+			})
+		}
+		return post, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch post",
+		})
+	}
+	return post, true, nil
+}
+
+// lookupRevision fetches revision number param of the post, like lookupPost.
+func (h *PostsHandler) lookupRevision(c echo.Context, postID int64, param string) (revision repository.PostRevision, ok bool, err error) {
+	number, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return revision, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid revision format",
+		})
+	}
+
+	revision, err = h.revisions.PostRevisionsGetByRevision(c.Request().Context(), repository.PostRevisionsGetByRevisionParams{
+		PostID:   postID,
+		Revision: number,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return revision, false, c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Revision not found",
+			})
+		}
+		return revision, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch revision",
+		})
+	}
+	return revision, true, nil
+}
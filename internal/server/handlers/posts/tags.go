@@ -0,0 +1,302 @@
+package posts
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/server/api"
+)
+
+const (
+	// MaxTagLength is the longest tag name
+	MaxTagLength = 32
+	// MaxPostTags is how many tags a post can have, and a filter can ask for
+	MaxPostTags = 10
+)
+
+// errTooManyTags rolls back adding a tag to a post that already has MaxPostTags.
+var errTooManyTags = errors.New("too many tags")
+
+// TagsRepo reads and detaches the tags of posts and finds posts by them, tags are attached in a transaction.
+type TagsRepo interface {
+	PostTagsRemove(ctx context.Context, params repository.PostTagsRemoveParams) (int64, error)
+	PostsGetVisibleByAllTags(ctx context.Context, params repository.PostsGetVisibleByAllTagsParams) ([]repository.Post, error)
+	PostsGetVisibleByAnyTag(ctx context.Context, params repository.PostsGetVisibleByAnyTagParams) ([]repository.Post, error)
+	TagsAutocomplete(ctx context.Context, params repository.TagsAutocompleteParams) ([]repository.TagsAutocompleteRow, error)
+	TagsGetByPostID(ctx context.Context, postID int64) ([]repository.Tag, error)
+	TagsGetCounts(ctx context.Context, limit int64) ([]repository.TagsGetCountsRow, error)
+}
+
+// NormalizeTag lowercases and trims a tag name, ok is false when it isn't a valid tag:
+// 1 to MaxTagLength lowercase letters, digits and dashes.
+func NormalizeTag(name string) (tag string, ok bool) {
+	tag = strings.ToLower(strings.TrimSpace(name))
+	if tag == "" || len(tag) > MaxTagLength {
+		return tag, false
+	}
+	for _, r := range tag {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' {
+			return tag, false
+		}
+	}
+	return tag, true
+}
+
+// parseTags splits the comma separated tags query parameter into distinct normalized names.
+func parseTags(param string) ([]string, bool) {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(param, ",") {
+		tag, ok := NormalizeTag(name)
+		if !ok {
+			return nil, false
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			names = append(names, tag)
+		}
+	}
+	return names, len(names) <= MaxPostTags
+}
+
+// getPostsByTags answers GetAllPosts when it is filtered by tags.
+func (h *PostsHandler) getPostsByTags(c echo.Context, viewerID int64) ([]repository.Post, bool, error) {
+	names, ok := parseTags(c.QueryParam("tags"))
+	if !ok {
+		return nil, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid tags, expected up to " + strconv.Itoa(MaxPostTags) + " comma separated tags of lowercase letters, digits and dashes",
+		})
+	}
+
+	var posts []repository.Post
+	var err error
+	switch c.QueryParam("match") {
+	case "", "any":
+		posts, err = h.tags.PostsGetVisibleByAnyTag(c.Request().Context(), repository.PostsGetVisibleByAnyTagParams{
+			ViewerID: viewerID,
+			Names:    names,
+		})
+	case "all":
+		posts, err = h.tags.PostsGetVisibleByAllTags(c.Request().Context(), repository.PostsGetVisibleByAllTagsParams{
+			ViewerID: viewerID,
+			TagCount: int64(len(names)),
+			Names:    names,
+		})
+	default:
+		return nil, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid match, expected any or all",
+		})
+	}
+	if err != nil {
+		return nil, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch posts",
+		})
+	}
+	return posts, true, nil
+}
+
+// GetPostTags handles HTTP GET requests listing the tags of a post.
+// @Summary Get post tags
+// @Description Returns the tags of a post, sorted by name. Posts that are not published are only found by their author.
+// @Tags tags
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {array} api.Tag "Tags of the post"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/tags [get]
+func (h *PostsHandler) GetPostTags(c echo.Context) error {
+	post, ok, err := h.lookupPost(c)
+	if !ok {
+		return err
+	}
+	return h.respondWithTags(c, post.ID)
+}
+
+// AddPostTag handles HTTP PUT requests attaching a tag to a post.
+// @Summary Add post tag
+// @Description Attaches a tag to a post, creating the tag when it is new. Attaching a tag the post already has changes nothing.
+// @Tags tags
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param tag path string true "Tag, lowercase letters, digits and dashes"
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {array} api.Tag "Tags of the post"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or tag, or too many tags"
+// @Failure 404 {object} map[string]string "Post not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/tags/{tag} [put]
+func (h *PostsHandler) AddPostTag(c echo.Context) error {
+	name, ok := NormalizeTag(c.Param("tag"))
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid tag, expected 1 to " + strconv.Itoa(MaxTagLength) + " lowercase letters, digits and dashes",
+		})
+	}
+	post, ok, err := h.lookupPost(c)
+	if !ok {
+		return err
+	}
+
+	// The count and the insert share a transaction, so concurrent requests can't go past MaxPostTags
+	ctx := c.Request().Context()
+	err = h.db.WithTx(ctx, func(q *repository.Queries) error {
+		tags, err := q.TagsGetByPostID(ctx, post.ID)
+		if err != nil {
+			return err
+		}
+		for _, tag := range tags {
+			if tag.Name == name {
+				return nil
+			}
+		}
+		if len(tags) >= MaxPostTags {
+			return errTooManyTags
+		}
+
+		tag, err := q.TagsUpsert(ctx, name)
+		if err != nil {
+			return err
+		}
+		return q.PostTagsAdd(ctx, repository.PostTagsAddParams{
+			PostID: post.ID,
+			TagID:  tag.ID,
+		})
+	})
+	if errors.Is(err, errTooManyTags) {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "A post can have at most " + strconv.Itoa(MaxPostTags) + " tags",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to add tag",
+		})
+	}
+	return h.respondWithTags(c, post.ID)
+}
+
+// RemovePostTag handles HTTP DELETE requests detaching a tag from a post.
+// @Summary Remove post tag
+// @Description Detaches a tag from a post, the tag itself is kept.
+// @Tags tags
+// @Produce json
+// @Param id path int true "Post ID"
+// @Param tag path string true "Tag"
+// @Param X-User-ID header int false "User making the request"
+// @Success 200 {array} api.Tag "Tags of the post"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or tag"
+// @Failure 404 {object} map[string]string "Post not found, or it doesn't have the tag"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/posts/id/{id}/tags/{tag} [delete]
+func (h *PostsHandler) RemovePostTag(c echo.Context) error {
+	name, ok := NormalizeTag(c.Param("tag"))
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid tag, expected 1 to " + strconv.Itoa(MaxTagLength) + " lowercase letters, digits and dashes",
+		})
+	}
+	post, ok, err := h.lookupPost(c)
+	if !ok {
+		return err
+	}
+
+	removed, err := h.tags.PostTagsRemove(c.Request().Context(), repository.PostTagsRemoveParams{
+		PostID: post.ID,
+		Name:   name,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to remove tag",
+		})
+	}
+	if removed == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Post doesn't have the tag",
+		})
+	}
+	return h.respondWithTags(c, post.ID)
+}
+
+// GetTags handles HTTP GET requests for the tag usage counts.
+// @Summary Get tags
+// @Description Returns the tags with the number of published posts carrying them, most used first.
+// @Tags tags
+// @Produce json
+// @Param limit query int false "Number of tags, 1 to 100 (default 20)"
+// @Success 200 {array} api.TagCount "Tags"
+// @Failure 400 {object} map[string]string "Bad request - invalid limit"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/tags [get]
+func (h *PostsHandler) GetTags(c echo.Context) error {
+	limit, ok := api.Limit(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit parameter, expected 1 to 100",
+		})
+	}
+
+	tags, err := h.tags.TagsGetCounts(c.Request().Context(), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch tags",
+		})
+	}
+	return c.JSON(http.StatusOK, api.RenderAll(c, tags, api.NewTagCount))
+}
+
+// AutocompleteTags handles HTTP GET requests for the tags starting with a prefix.
+// @Summary Autocomplete tags
+// @Description Returns the tags starting with q, most used first, with the number of published posts carrying them.
+// @Tags tags
+// @Produce json
+// @Param q query string true "Start of the tag"
+// @Param limit query int false "Number of tags, 1 to 100 (default 20)"
+// @Success 200 {array} api.TagCount "Matching tags"
+// @Failure 400 {object} map[string]string "Bad request - invalid q or limit"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/tags/autocomplete [get]
+func (h *PostsHandler) AutocompleteTags(c echo.Context) error {
+	// Tags have no LIKE wildcards, a valid prefix is matched literally
+	prefix, ok := NormalizeTag(c.QueryParam("q"))
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid q parameter, expected the start of a tag",
+		})
+	}
+	limit, ok := api.Limit(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit parameter, expected 1 to 100",
+		})
+	}
+
+	tags, err := h.tags.TagsAutocomplete(c.Request().Context(), repository.TagsAutocompleteParams{
+		Pattern: prefix + "%",
+		Limit:   limit,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch tags",
+		})
+	}
+	return c.JSON(http.StatusOK, api.RenderAll(c, tags, api.NewTagCountFromAutocomplete))
+}
+
+func (h *PostsHandler) respondWithTags(c echo.Context, postID int64) error {
+	tags, err := h.tags.TagsGetByPostID(c.Request().Context(), postID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch tags",
+		})
+	}
+	return c.JSON(http.StatusOK, api.RenderAll(c, tags, api.NewTag))
+}
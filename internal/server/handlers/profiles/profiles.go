@@ -0,0 +1,351 @@
+package profiles
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/avatar"
+	"backendT/internal/database/repository"
+	"backendT/internal/httpcache"
+	"backendT/internal/outbox"
+	"backendT/internal/server/api"
+)
+
+// Longest accepted profile fields, in characters.
+const (
+	maxDisplayNameLength = 64
+	maxBioLength         = 500
+)
+
+// multipartOverhead is what an upload may weigh on top of the avatar itself (boundaries, part headers).
+const multipartOverhead = 64 << 10
+
+type Repo interface {
+	UsersGetByID(ctx context.Context, id int64) (repository.User, error)
+}
+
+// TxRunner runs fn in a transaction, database.Service implements it. The writes go through it so their
+// outbox events are stored with them.
+type TxRunner interface {
+	WithTx(ctx context.Context, fn func(q *repository.Queries) error) error
+}
+
+// UpdateProfileRequest is the body of UpdateProfile, null or empty fields are cleared.
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+}
+
+// ProfilesHandler serves the profile of users: display name, bio and avatar.
+// Deleting a user goes through it as well, the avatar files have to go with the user.
+type ProfilesHandler struct {
+	db      TxRunner
+	repo    Repo
+	avatars *avatar.Store
+}
+
+func NewProfilesHandler(db TxRunner, r *repository.Queries, avatars *avatar.Store) *ProfilesHandler {
+	return &ProfilesHandler{
+		db:      db,
+		repo:    r,
+		avatars: avatars,
+	}
+}
+
+// UpdateProfile handles HTTP PUT requests to change the display name and bio of a user.
+// @Summary Update user profile
+// @Description Replaces the display name and bio of a user, null or empty fields are cleared. Send the ETag of the user as last fetched in If-Match to make sure nobody changed it in the meantime.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param If-Match header string false "ETag of the user as last fetched"
+// @Param profile body UpdateProfileRequest true "New profile"
+// @Success 200 {object} api.User "Updated user"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or payload"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 412 {object} map[string]string "The user was modified since it was fetched"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/users/id/{id}/profile [put]
+func (h *ProfilesHandler) UpdateProfile(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID format",
+		})
+	}
+
+	var req UpdateProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request payload",
+		})
+	}
+	displayName, bio := optional(req.DisplayName), optional(req.Bio)
+	if utf8.RuneCountInString(displayName.String) > maxDisplayNameLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Display name is longer than " + strconv.Itoa(maxDisplayNameLength) + " characters",
+		})
+	}
+	if utf8.RuneCountInString(bio.String) > maxBioLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Bio is longer than " + strconv.Itoa(maxBioLength) + " characters",
+		})
+	}
+
+	current, err := h.repo.UsersGetByID(c.Request().Context(), userID)
+	if err != nil {
+		return userError(c, err)
+	}
+	if !httpcache.IfMatch(c, api.Render(c, current, api.NewUser)) {
+		return c.JSON(http.StatusPreconditionFailed, map[string]string{
+			"error": "User was modified since it was fetched",
+		})
+	}
+
+	ctx := c.Request().Context()
+	user, err := h.updateUser(ctx, func(q *repository.Queries) (repository.User, error) {
+		return q.UsersUpdateProfileByID(ctx, repository.UsersUpdateProfileByIDParams{
+			DisplayName: displayName,
+			Bio:         bio,
+			ID:          userID,
+		})
+	})
+	if err != nil {
+		return userError(c, err)
+	}
+
+	body := api.Render(c, user, api.NewUser)
+	httpcache.SetETag(c, body)
+	httpcache.SetLastModified(c, user.UpdatedAt.Time)
+	return c.JSON(http.StatusOK, body)
+}
+
+// UploadAvatar handles HTTP PUT requests uploading the avatar of a user as multipart/form-data.
+// @Summary Upload user avatar
+// @Description Stores a png, jpeg, gif or webp image (AVATAR_MAX_BYTES at most, 2 MiB by default) as the avatar of a user, with a square thumbnail. The previous avatar is deleted.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "User ID"
+// @Param avatar formData file true "Avatar image"
+// @Success 200 {object} api.User "Updated user, with the urls of the avatar and its thumbnail"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID, missing file or not a valid image"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 413 {object} map[string]string "The image is too large"
+// @Failure 415 {object} map[string]string "The file is not a supported image type"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/users/id/{id}/avatar [put]
+func (h *ProfilesHandler) UploadAvatar(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID format",
+		})
+	}
+
+	tooLarge := map[string]string{
+		"error": "Avatar is larger than " + strconv.FormatInt(h.avatars.MaxBytes(), 10) + " bytes",
+	}
+	// Refuse big uploads while reading them rather than after
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, h.avatars.MaxBytes()+multipartOverhead)
+	file, err := c.FormFile("avatar")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return c.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+		}
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "The avatar file is required (multipart field avatar)",
+		})
+	}
+	if file.Size > h.avatars.MaxBytes() {
+		return c.JSON(http.StatusRequestEntityTooLarge, tooLarge)
+	}
+
+	current, err := h.repo.UsersGetByID(c.Request().Context(), userID)
+	if err != nil {
+		return userError(c, err)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to read avatar",
+		})
+	}
+	defer f.Close()
+
+	name, err := h.avatars.Save(f)
+	switch {
+	case errors.Is(err, avatar.ErrTooLarge):
+		return c.JSON(http.StatusRequestEntityTooLarge, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, avatar.ErrUnsupported):
+		return c.JSON(http.StatusUnsupportedMediaType, map[string]string{
+			"error": err.Error(),
+		})
+	case errors.Is(err, avatar.ErrInvalid):
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+	case err != nil:
+		log.Printf("Error saving avatar of user %d: %v", userID, err)
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to save avatar",
+		})
+	}
+
+	ctx := c.Request().Context()
+	user, err := h.updateUser(ctx, func(q *repository.Queries) (repository.User, error) {
+		return q.UsersUpdateAvatarByID(ctx, repository.UsersUpdateAvatarByIDParams{
+			Avatar: sql.NullString{String: name, Valid: true},
+			ID:     userID,
+		})
+	})
+	if err != nil {
+		h.removeAvatar(name)
+		return userError(c, err)
+	}
+	h.removeAvatar(current.Avatar.String)
+
+	return c.JSON(http.StatusOK, api.Render(c, user, api.NewUser))
+}
+
+// DeleteAvatar handles HTTP DELETE requests removing the avatar of a user.
+// @Summary Delete user avatar
+// @Description Removes the avatar of a user and its files.
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} api.User "Updated user"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/users/id/{id}/avatar [delete]
+func (h *ProfilesHandler) DeleteAvatar(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID format",
+		})
+	}
+
+	current, err := h.repo.UsersGetByID(c.Request().Context(), userID)
+	if err != nil {
+		return userError(c, err)
+	}
+	ctx := c.Request().Context()
+	user, err := h.updateUser(ctx, func(q *repository.Queries) (repository.User, error) {
+		return q.UsersUpdateAvatarByID(ctx, repository.UsersUpdateAvatarByIDParams{
+			ID: userID,
+		})
+	})
+	if err != nil {
+		return userError(c, err)
+	}
+	h.removeAvatar(current.Avatar.String)
+
+	return c.JSON(http.StatusOK, api.Render(c, user, api.NewUser))
+}
+
+// DeleteUser handles HTTP DELETE requests to delete a user.
+// @Summary Delete user
+// @Description Deletes a user together with their posts, their comments and the comments on their posts, and removes their avatar files.
+// @Tags users
+// @Param id path int true "User ID"
+// @Success 204 "User deleted"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/users/id/{id} [delete]
+func (h *ProfilesHandler) DeleteUser(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID format",
+		})
+	}
+
+	ctx := c.Request().Context()
+	var user repository.User
+	err = h.db.WithTx(ctx, func(q *repository.Queries) error {
+		user, err = q.UsersGetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := q.CommentsDeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if err := q.PostsDeleteByUserID(ctx, userID); err != nil {
+			return err
+		}
+		if _, err := q.UsersDeleteByID(ctx, userID); err != nil {
+			return err
+		}
+		// Their posts go with them, no post event is stored for those
+		return outbox.Append(ctx, q, outbox.AggregateUser, userID, outbox.EventUserDeleted, api.NewUser(user))
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "User not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete user",
+		})
+	}
+
+	// Files that can't be removed now are left to the avatar sweeper
+	h.removeAvatar(user.Avatar.String)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// updateUser runs update in a transaction, together with storing the outbox event of the user it returns.
+func (h *ProfilesHandler) updateUser(ctx context.Context, update func(q *repository.Queries) (repository.User, error)) (repository.User, error) {
+	var user repository.User
+	err := h.db.WithTx(ctx, func(q *repository.Queries) (err error) {
+		user, err = update(q)
+		if err != nil {
+			return err
+		}
+		return outbox.Append(ctx, q, outbox.AggregateUser, user.ID, outbox.EventUserUpdated, api.NewUser(user))
+	})
+	return user, err
+}
+
+func (h *ProfilesHandler) removeAvatar(name string) {
+	if err := h.avatars.Remove(name); err != nil {
+		log.Printf("Error removing avatar %s: %v", name, err)
+	}
+}
+
+// userError answers a failed lookup or update of a user.
+func userError(c echo.Context, err error) error {
+	if err == sql.ErrNoRows {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "User not found",
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{
+		"error": "Failed to update user",
+	})
+}
+
+// optional turns a missing or empty field into NULL.
+func optional(s *string) sql.NullString {
+	if s == nil || *s == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: *s, Valid: true}
+}
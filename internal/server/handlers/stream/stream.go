@@ -0,0 +1,58 @@
+// Package stream writes big collections as JSON arrays item by item, so the handlers never hold them in memory.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// flushEvery is how many items are written between flushes to the client.
+const flushEvery = 500
+
+// JSONArray writes the items handed out by each as a JSON array with status 200.
+// The response only starts with the first item, so an error before it (e.g. a failing query) is returned
+// untouched and the handler can still answer with an error. Once the array has started the 200 can't be
+// taken back, so an error aborts the connection (panic with http.ErrAbortHandler) and the client sees
+// a broken response instead of a short array that looks complete.
+func JSONArray[T any](c echo.Context, each func(ctx context.Context, fn func(T) error) error) error {
+	resp := c.Response()
+	enc := json.NewEncoder(resp)
+	count := 0
+
+	err := each(c.Request().Context(), func(item T) error {
+		sep := []byte(",")
+		if count == 0 {
+			resp.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			resp.WriteHeader(http.StatusOK)
+			sep = []byte("[")
+		}
+		if _, err := resp.Write(sep); err != nil {
+			return err
+		}
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+
+		count++
+		if count%flushEvery == 0 {
+			resp.Flush()
+		}
+		return nil
+	})
+	if err != nil && count > 0 {
+		c.Logger().Errorf("aborting response after %d items: %v", count, err)
+		panic(http.ErrAbortHandler)
+	}
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return c.JSONBlob(http.StatusOK, []byte("[]"))
+	}
+	_, err = resp.Write([]byte("]\n"))
+	return err
+}
@@ -0,0 +1,220 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/outbox"
+	"backendT/internal/server/api"
+)
+
+// FollowsRepo stores who follows whom.
+type FollowsRepo interface {
+	FollowsGetFollowers(ctx context.Context, params repository.FollowsGetFollowersParams) ([]repository.User, error)
+	FollowsGetFollowing(ctx context.Context, params repository.FollowsGetFollowingParams) ([]repository.User, error)
+	FollowsRemove(ctx context.Context, params repository.FollowsRemoveParams) error
+}
+
+// FollowUser handles HTTP PUT requests following a user.
+// @Summary Follow user
+// @Description The user named in X-User-ID follows the user, whose published posts show up in their feed. Following a user twice changes nothing.
+// @Tags follows
+// @Param id path int true "ID of the user to follow"
+// @Param X-User-ID header int true "User following"
+// @Success 204 "Followed"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID, or following yourself"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/users/id/{id}/follow [put]
+func (h *UsersHandler) FollowUser(c echo.Context) error {
+	params, ok, err := h.followRequest(c)
+	if !ok {
+		return err
+	}
+	if params.FollowerID == params.FolloweeID {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Users can't follow themselves",
+		})
+	}
+
+	ctx := c.Request().Context()
+	err = h.db.WithTx(ctx, func(q *repository.Queries) error {
+		if err := q.FollowsAdd(ctx, params); err != nil {
+			return err
+		}
+		return outbox.Append(ctx, q, outbox.AggregateUser, params.FolloweeID, outbox.EventUserFollowed, outbox.Followed{
+			UserID:     params.FolloweeID,
+			FollowerID: params.FollowerID,
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to follow user",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UnfollowUser handles HTTP DELETE requests unfollowing a user.
+// @Summary Unfollow user
+// @Description The user named in X-User-ID stops following the user. Unfollowing a user that isn't followed changes nothing.
+// @Tags follows
+// @Param id path int true "ID of the user to unfollow"
+// @Param X-User-ID header int true "User following"
+// @Success 204 "Unfollowed"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "X-User-ID is missing or not a user"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/users/id/{id}/follow [delete]
+func (h *UsersHandler) UnfollowUser(c echo.Context) error {
+	params, ok, err := h.followRequest(c)
+	if !ok {
+		return err
+	}
+
+	if err := h.follows.FollowsRemove(c.Request().Context(), repository.FollowsRemoveParams(params)); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to unfollow user",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetFollowers handles HTTP GET requests listing the followers of a user.
+// @Summary Get followers
+// @Description Returns the users following the user, by ID. When there are more, the Link header points to the next page.
+// @Tags follows
+// @Produce json
+// @Param id path int true "User ID"
+// @Param limit query int false "Number of users, 1 to 100 (default 20)"
+// @Param cursor query string false "Cursor of the page, from the Link header of the previous one"
+// @Success 200 {array} api.User "Followers"
+// @Header 200 {string} Link "<next page>; rel=\"next\""
+// @Failure 400 {object} map[string]string "Bad request - invalid ID, limit or cursor"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/users/id/{id}/followers [get]
+func (h *UsersHandler) GetFollowers(c echo.Context) error {
+	return h.respondWithFollows(c, func(ctx context.Context, userID, after, limit int64) ([]repository.User, error) {
+		return h.follows.FollowsGetFollowers(ctx, repository.FollowsGetFollowersParams{UserID: userID, After: after, Limit: limit})
+	})
+}
+
+// GetFollowing handles HTTP GET requests listing the users a user follows.
+// @Summary Get followed users
+// @Description Returns the users the user follows, by ID. When there are more, the Link header points to the next page.
+// @Tags follows
+// @Produce json
+// @Param id path int true "User ID"
+// @Param limit query int false "Number of users, 1 to 100 (default 20)"
+// @Param cursor query string false "Cursor of the page, from the Link header of the previous one"
+// @Success 200 {array} api.User "Followed users"
+// @Header 200 {string} Link "<next page>; rel=\"next\""
+// @Failure 400 {object} map[string]string "Bad request - invalid ID, limit or cursor"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/users/id/{id}/following [get]
+func (h *UsersHandler) GetFollowing(c echo.Context) error {
+	return h.respondWithFollows(c, func(ctx context.Context, userID, after, limit int64) ([]repository.User, error) {
+		return h.follows.FollowsGetFollowing(ctx, repository.FollowsGetFollowingParams{UserID: userID, After: after, Limit: limit})
+	})
+}
+
+// followRequest checks the users of a request following or unfollowing. When ok is false the response
+// was written already (400, 401 without X-User-ID or for an unknown user, 404 for an unknown followee).
+func (h *UsersHandler) followRequest(c echo.Context) (params repository.FollowsAddParams, ok bool, err error) {
+	followeeID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return params, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID format",
+		})
+	}
+	followerID, ok := api.ViewerID(c)
+	if !ok {
+		return params, false, c.JSON(http.StatusUnauthorized, map[string]string{
+			"error": "The " + api.HeaderUserID + " header is required",
+		})
+	}
+
+	if _, err := h.repo.UsersGetByID(c.Request().Context(), followerID); err != nil {
+		if err == sql.ErrNoRows {
+			return params, false, c.JSON(http.StatusUnauthorized, map[string]string{
+				"error": "The " + api.HeaderUserID + " header names no user",
+			})
+		}
+		return params, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch user",
+		})
+	}
+	if _, err := h.repo.UsersGetByID(c.Request().Context(), followeeID); err != nil {
+		if err == sql.ErrNoRows {
+			return params, false, c.JSON(http.StatusNotFound, map[string]string{
+				"error": "User not found",
+			})
+		}
+		return params, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch user",
+		})
+	}
+	return repository.FollowsAddParams{FollowerID: followerID, FolloweeID: followeeID}, true, nil
+}
+
+// respondWithFollows writes a page of the followers or followed users of the user, as fetched by list.
+// The cursor of the next page is the last user ID.
+func (h *UsersHandler) respondWithFollows(c echo.Context, list func(ctx context.Context, userID, after, limit int64) ([]repository.User, error)) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID format",
+		})
+	}
+	limit, ok := api.Limit(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit parameter, expected 1 to 100",
+		})
+	}
+	var after int64
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		key, ok := api.DecodeCursor(cursor, 1)
+		if ok {
+			after, err = strconv.ParseInt(key[0], 10, 64)
+		}
+		if !ok || err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid cursor parameter",
+			})
+		}
+	}
+
+	if _, err := h.repo.UsersGetByID(c.Request().Context(), userID); err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "User not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch user",
+		})
+	}
+
+	// One more than asked tells whether there is a next page
+	users, err := list(c.Request().Context(), userID, after, limit+1)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch users",
+		})
+	}
+	if int64(len(users)) > limit {
+		users = users[:limit]
+		api.SetNextPage(c, api.EncodeCursor(strconv.FormatInt(users[limit-1].ID, 10)))
+	}
+	return c.JSON(http.StatusOK, api.RenderAll(c, users, api.NewUser))
+}
@@ -3,6 +3,7 @@ package users
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,23 +11,51 @@ import (
 	"github.com/labstack/echo/v4"
 
 	"backendT/internal/database/repository"
+	"backendT/internal/database/rows"
+	"backendT/internal/httpcache"
+	"backendT/internal/outbox"
+	"backendT/internal/server/api"
+	"backendT/internal/server/handlers/stream"
 )
 
 type Repo interface {
-	UsersCreate(ctx context.Context, params repository.UsersCreateParams) (repository.User, error)
-	UsersGetAll(ctx context.Context) ([]repository.User, error)
 	UsersGetByID(ctx context.Context, userID int64) (repository.User, error)
 	UsersGetByUsername(ctx context.Context, username string) (repository.User, error)
 	UsersGetByEmail(ctx context.Context, email string) (repository.User, error)
 }
 
+// Streams are the queries streamed row by row, rows.Queries implements them.
+type Streams interface {
+	UsersGetAllEach(ctx context.Context, fn func(repository.User) error) error
+}
+
+// TxRunner runs fn in a transaction, database.Service implements it. The writes go through it so their
+// outbox events are stored with them.
+type TxRunner interface {
+	WithTx(ctx context.Context, fn func(q *repository.Queries) error) error
+}
+
+// errModified rolls back an update whose If-Match doesn't match the user anymore.
+var errModified = errors.New("user was modified")
+
+// UpdateUserRequest is the body of UpdateUser.
+type UpdateUserRequest struct {
+	Email string `json:"email"`
+}
+
 type UsersHandler struct {
-	repo Repo
+	repo    Repo
+	streams Streams
+	follows FollowsRepo
+	db      TxRunner
 }
 
-func NewUsersHandler(r *repository.Queries) *UsersHandler {
+func NewUsersHandler(r *repository.Queries, s *rows.Queries, db TxRunner) *UsersHandler {
 	return &UsersHandler{
-		repo: r,
+		repo:    r,
+		streams: s,
+		follows: r,
+		db:      db,
 	}
 }
 
@@ -35,17 +64,18 @@ func NewUsersHandler(r *repository.Queries) *UsersHandler {
 // @Description Returns a list of all users from the database.
 // @Tags users
 // @Produce json
-// @Success 200 {array} repository.User "List of users"
+// @Success 200 {array} api.User "List of users"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /users [get]
+// @Router /v2/users [get]
 func (h *UsersHandler) GetAllUsers(c echo.Context) error {
-	users, err := h.repo.UsersGetAll(c.Request().Context())
+	// Streamed row by row so memory stays flat however many users there are
+	err := stream.JSONArray(c, api.Each(c, h.streams.UsersGetAllEach, api.NewUser))
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to fetch users",
 		})
 	}
-	return c.JSON(http.StatusOK, users)
+	return nil
 }
 
 // CreateUser handles HTTP POST requests to create a new user.
@@ -55,10 +85,10 @@ func (h *UsersHandler) GetAllUsers(c echo.Context) error {
 // @Accept json
 // @Produce json
 // @Param user body repository.UsersCreateParams true "New user payload"
-// @Success 201 {object} repository.User "Created user"
+// @Success 201 {object} api.User "Created user"
 // @Failure 400 {object} map[string]string "Bad request - invalid payload"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /users [post]
+// @Router /v2/users [post]
 func (h *UsersHandler) CreateUser(c echo.Context) error {
 	var newUser repository.User
 	if err := c.Bind(&newUser); err != nil {
@@ -68,9 +98,17 @@ func (h *UsersHandler) CreateUser(c echo.Context) error {
 		})
 	}
 
-	createdUser, err := h.repo.UsersCreate(c.Request().Context(), repository.UsersCreateParams{
-		Username: newUser.Username,
-		Email:    newUser.Email,
+	ctx := c.Request().Context()
+	var createdUser repository.User
+	err := h.db.WithTx(ctx, func(q *repository.Queries) (err error) {
+		createdUser, err = q.UsersCreate(ctx, repository.UsersCreateParams{
+			Username: newUser.Username,
+			Email:    newUser.Email,
+		})
+		if err != nil {
+			return err
+		}
+		return outbox.Append(ctx, q, outbox.AggregateUser, createdUser.ID, outbox.EventUserCreated, api.NewUser(createdUser))
 	})
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{
@@ -78,7 +116,7 @@ func (h *UsersHandler) CreateUser(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusCreated, createdUser)
+	return c.JSON(http.StatusCreated, api.Render(c, createdUser, api.NewUser))
 }
 
 // GetUserByID handles HTTP GET requests to retrieve a user by their ID.
@@ -87,11 +125,11 @@ func (h *UsersHandler) CreateUser(c echo.Context) error {
 // @Tags users
 // @Produce json
 // @Param id path int true "User ID"
-// @Success 200 {object} repository.User "Found user"
+// @Success 200 {object} api.User "Found user"
 // @Failure 400 {object} map[string]string "Bad request - invalid ID"
 // @Failure 404 {object} map[string]string "User not found"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /users/id/{id} [get]
+// @Router /v2/users/id/{id} [get]
 func (h *UsersHandler) GetUserByID(c echo.Context) error {
 	userIDStr := c.Param("id")
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
@@ -103,12 +141,18 @@ func (h *UsersHandler) GetUserByID(c echo.Context) error {
 
 	user, err := h.repo.UsersGetByID(c.Request().Context(), userID)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "User not found",
+			})
+		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{
 			"error": "Failed to fetch user",
 		})
 	}
 
-	return c.JSON(http.StatusOK, user)
+	httpcache.SetLastModified(c, user.UpdatedAt.Time)
+	return c.JSON(http.StatusOK, api.Render(c, user, api.NewUser))
 
 }
 
@@ -118,11 +162,11 @@ func (h *UsersHandler) GetUserByID(c echo.Context) error {
 // @Tags users
 // @Produce json
 // @Param username path string true "Username"
-// @Success 200 {object} repository.User "Found user"
+// @Success 200 {object} api.User "Found user"
 // @Failure 400 {object} map[string]string "Bad request - invalid username"
 // @Failure 404 {object} map[string]string "User not found"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /users/username/{username} [get]
+// @Router /v2/users/username/{username} [get]
 func (h *UsersHandler) GetUserByUsername(c echo.Context) error {
 	username := c.Param("username")
 	if username == "" {
@@ -143,7 +187,8 @@ func (h *UsersHandler) GetUserByUsername(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, user)
+	httpcache.SetLastModified(c, user.UpdatedAt.Time)
+	return c.JSON(http.StatusOK, api.Render(c, user, api.NewUser))
 }
 
 // GetUserByEmail handles HTTP GET requests to retrieve a user by email.
@@ -152,11 +197,11 @@ func (h *UsersHandler) GetUserByUsername(c echo.Context) error {
 // @Tags users
 // @Produce json
 // @Param email path string true "Email address"
-// @Success 200 {object} repository.User "Found user"
+// @Success 200 {object} api.User "Found user"
 // @Failure 400 {object} map[string]string "Bad request - invalid email"
 // @Failure 404 {object} map[string]string "User not found"
 // @Failure 500 {object} map[string]string "Internal server error"
-// @Router /users/email/{email} [get]
+// @Router /v2/users/email/{email} [get]
 func (h *UsersHandler) GetUserByEmail(c echo.Context) error {
 	email := c.Param("email")
 	if email == "" {
@@ -177,5 +222,77 @@ func (h *UsersHandler) GetUserByEmail(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, user)
+	httpcache.SetLastModified(c, user.UpdatedAt.Time)
+	return c.JSON(http.StatusOK, api.Render(c, user, api.NewUser))
+}
+
+// UpdateUser handles HTTP PUT requests to change the email of a user.
+// @Summary Update user
+// @Description Changes the email of a user. Send the ETag of the user as last fetched in If-Match to make sure nobody changed it in the meantime.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param If-Match header string false "ETag of the user as last fetched"
+// @Param user body UpdateUserRequest true "New email"
+// @Success 200 {object} api.User "Updated user"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or payload"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 412 {object} map[string]string "The user was modified since it was fetched"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /v2/users/id/{id} [put]
+func (h *UsersHandler) UpdateUser(c echo.Context) error {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid user ID format",
+		})
+	}
+
+	var req UpdateUserRequest
+	if err := c.Bind(&req); err != nil || req.Email == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request payload, email is required",
+		})
+	}
+
+	// Checked and updated in one transaction, so nobody changes the user in between
+	ctx := c.Request().Context()
+	var user repository.User
+	err = h.db.WithTx(ctx, func(q *repository.Queries) error {
+		current, err := q.UsersGetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !httpcache.IfMatch(c, api.Render(c, current, api.NewUser)) {
+			return errModified
+		}
+		user, err = q.UsersUpdateEmailByID(ctx, repository.UsersUpdateEmailByIDParams{
+			Email: req.Email,
+			ID:    userID,
+		})
+		if err != nil {
+			return err
+		}
+		return outbox.Append(ctx, q, outbox.AggregateUser, user.ID, outbox.EventUserUpdated, api.NewUser(user))
+	})
+	switch {
+	case err == sql.ErrNoRows:
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "User not found",
+		})
+	case err == errModified:
+		return c.JSON(http.StatusPreconditionFailed, map[string]string{
+			"error": "User was modified since it was fetched",
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update user",
+		})
+	}
+
+	body := api.Render(c, user, api.NewUser)
+	httpcache.SetETag(c, body)
+	httpcache.SetLastModified(c, user.UpdatedAt.Time)
+	return c.JSON(http.StatusOK, body)
 }
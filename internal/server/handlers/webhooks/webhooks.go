@@ -0,0 +1,348 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/server/api"
+	"backendT/internal/webhook"
+)
+
+type Repo interface {
+	WebhookDeliveriesGetByWebhookID(ctx context.Context, params repository.WebhookDeliveriesGetByWebhookIDParams) ([]repository.WebhookDelivery, error)
+	WebhookDeliveriesRedeliver(ctx context.Context, id int64) (repository.WebhookDelivery, error)
+	WebhooksCreate(ctx context.Context, params repository.WebhooksCreateParams) (repository.Webhook, error)
+	WebhooksDeleteByID(ctx context.Context, id int64) (int64, error)
+	WebhooksGetAll(ctx context.Context) ([]repository.Webhook, error)
+	WebhooksGetByID(ctx context.Context, id int64) (repository.Webhook, error)
+	WebhooksUpdateByID(ctx context.Context, params repository.WebhooksUpdateByIDParams) (repository.Webhook, error)
+}
+
+// CreateWebhookRequest is the body of CreateWebhook, a secret is generated when none is given.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" example:"https://example.com/hooks"`
+	Secret string   `json:"secret" example:"whsec_0123456789abcdef"`
+	Events []string `json:"events" example:"post.created"`
+}
+
+// UpdateWebhookRequest is the body of UpdateWebhook, fields left out keep their current value.
+type UpdateWebhookRequest struct {
+	URL    string   `json:"url" example:"https://example.com/hooks"`
+	Events []string `json:"events" example:"post.created"`
+	Active *bool    `json:"active" example:"false"`
+}
+
+// WebhooksHandler manages the webhook subscriptions and their deliveries, for the admins.
+type WebhooksHandler struct {
+	repo Repo
+}
+
+func NewWebhooksHandler(r *repository.Queries) *WebhooksHandler {
+	return &WebhooksHandler{
+		repo: r,
+	}
+}
+
+// CreateWebhook handles HTTP POST requests subscribing a URL to events.
+// @Summary Create webhook
+// @Description Subscribes the URL to the event types (user.created, user.updated, user.deleted, post.created, post.updated, or * for all).
+// @Description Deliveries are POSTed with the X-Webhook-Signature header, see the README. The secret is only returned here. Requires the admin token.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param webhook body CreateWebhookRequest true "Subscription"
+// @Success 201 {object} api.Webhook "Created webhook, with its secret"
+// @Failure 400 {object} map[string]string "Bad request - invalid URL or event types"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/webhooks [post]
+func (h *WebhooksHandler) CreateWebhook(c echo.Context) error {
+	var req CreateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request payload",
+		})
+	}
+	params, ok, err := subscription(c, req.URL, req.Events)
+	if !ok {
+		return err
+	}
+	params.Secret = req.Secret
+	if params.Secret == "" {
+		if params.Secret, err = webhook.NewSecret(); err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{
+				"error": "Failed to generate secret",
+			})
+		}
+	}
+
+	created, err := h.repo.WebhooksCreate(c.Request().Context(), params)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to create webhook",
+		})
+	}
+	body := api.NewWebhook(created)
+	body.Secret = created.Secret
+	return c.JSON(http.StatusCreated, body)
+}
+
+// GetWebhooks handles HTTP GET requests listing the webhooks.
+// @Summary Get webhooks
+// @Description Returns every webhook, without their secrets. Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {array} api.Webhook "Webhooks"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/webhooks [get]
+func (h *WebhooksHandler) GetWebhooks(c echo.Context) error {
+	webhooks, err := h.repo.WebhooksGetAll(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch webhooks",
+		})
+	}
+	body := make([]api.Webhook, len(webhooks))
+	for i, w := range webhooks {
+		body[i] = api.NewWebhook(w)
+	}
+	return c.JSON(http.StatusOK, body)
+}
+
+// UpdateWebhook handles HTTP PUT requests changing a webhook.
+// @Summary Update webhook
+// @Description Changes the URL, event types or active flag of a webhook. Inactive webhooks get no new deliveries and their pending ones wait. Requires the admin token.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "Webhook ID"
+// @Param webhook body UpdateWebhookRequest true "Changes"
+// @Success 200 {object} api.Webhook "Updated webhook"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID, URL or event types"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/webhooks/{id} [put]
+func (h *WebhooksHandler) UpdateWebhook(c echo.Context) error {
+	current, ok, err := h.lookupWebhook(c)
+	if !ok {
+		return err
+	}
+	var req UpdateWebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid request payload",
+		})
+	}
+	if req.URL == "" {
+		req.URL = current.Url
+	}
+	if req.Events == nil {
+		req.Events = webhook.SplitEvents(current.Events)
+	}
+	params, ok, err := subscription(c, req.URL, req.Events)
+	if !ok {
+		return err
+	}
+	active := current.Active
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	updated, err := h.repo.WebhooksUpdateByID(c.Request().Context(), repository.WebhooksUpdateByIDParams{
+		Url:    params.Url,
+		Events: params.Events,
+		Active: active,
+		ID:     current.ID,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to update webhook",
+		})
+	}
+	return c.JSON(http.StatusOK, api.NewWebhook(updated))
+}
+
+// DeleteWebhook handles HTTP DELETE requests removing a webhook.
+// @Summary Delete webhook
+// @Description Deletes a webhook together with its deliveries. Requires the admin token.
+// @Tags admin
+// @Security AdminToken
+// @Param id path int true "Webhook ID"
+// @Success 204 "Webhook deleted"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/webhooks/{id} [delete]
+func (h *WebhooksHandler) DeleteWebhook(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid webhook ID format",
+		})
+	}
+
+	deleted, err := h.repo.WebhooksDeleteByID(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to delete webhook",
+		})
+	}
+	if deleted == 0 {
+		return c.JSON(http.StatusNotFound, map[string]string{
+			"error": "Webhook not found",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetDeliveries handles HTTP GET requests for the delivery log of a webhook.
+// @Summary Get webhook deliveries
+// @Description Returns the deliveries of a webhook, newest first, with the status and response of their last attempt.
+// @Description When there are more, the Link header points to the next page. Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "Webhook ID"
+// @Param limit query int false "Number of deliveries, 1 to 100 (default 20)"
+// @Param cursor query string false "Cursor of the page, from the Link header of the previous one"
+// @Success 200 {array} api.WebhookDelivery "Deliveries"
+// @Header 200 {string} Link "<next page>; rel=\"next\""
+// @Failure 400 {object} map[string]string "Bad request - invalid ID, limit or cursor"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 404 {object} map[string]string "Webhook not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/webhooks/{id}/deliveries [get]
+func (h *WebhooksHandler) GetDeliveries(c echo.Context) error {
+	current, ok, err := h.lookupWebhook(c)
+	if !ok {
+		return err
+	}
+	limit, ok := api.Limit(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid limit parameter, expected 1 to 100",
+		})
+	}
+	before := int64(math.MaxInt64)
+	if cursor := c.QueryParam("cursor"); cursor != "" {
+		key, ok := api.DecodeCursor(cursor, 1)
+		if ok {
+			before, err = strconv.ParseInt(key[0], 10, 64)
+		}
+		if !ok || err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{
+				"error": "Invalid cursor parameter",
+			})
+		}
+	}
+
+	// One more than asked tells whether there is a next page
+	deliveries, err := h.repo.WebhookDeliveriesGetByWebhookID(c.Request().Context(), repository.WebhookDeliveriesGetByWebhookIDParams{
+		WebhookID: current.ID,
+		Before:    before,
+		Limit:     limit + 1,
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch deliveries",
+		})
+	}
+	if int64(len(deliveries)) > limit {
+		deliveries = deliveries[:limit]
+		api.SetNextPage(c, api.EncodeCursor(strconv.FormatInt(deliveries[limit-1].ID, 10)))
+	}
+	body := make([]api.WebhookDelivery, len(deliveries))
+	for i, d := range deliveries {
+		body[i] = api.NewWebhookDelivery(d)
+	}
+	return c.JSON(http.StatusOK, body)
+}
+
+// Redeliver handles HTTP POST requests sending a delivery again.
+// @Summary Redeliver webhook delivery
+// @Description Queues a new delivery of the same event and payload to the same webhook, whatever became of the original one. Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "Delivery ID"
+// @Success 202 {object} api.WebhookDelivery "Queued delivery"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 404 {object} map[string]string "Delivery not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/webhooks/deliveries/{id}/redeliver [post]
+func (h *WebhooksHandler) Redeliver(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid delivery ID format",
+		})
+	}
+
+	delivery, err := h.repo.WebhookDeliveriesRedeliver(c.Request().Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Delivery not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to queue delivery",
+		})
+	}
+	return c.JSON(http.StatusAccepted, api.NewWebhookDelivery(delivery))
+}
+
+// lookupWebhook fetches the webhook of the request. When ok is false the response was written already.
+func (h *WebhooksHandler) lookupWebhook(c echo.Context) (w repository.Webhook, ok bool, err error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return w, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid webhook ID format",
+		})
+	}
+
+	w, err = h.repo.WebhooksGetByID(c.Request().Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return w, false, c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Webhook not found",
+			})
+		}
+		return w, false, c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch webhook",
+		})
+	}
+	return w, true, nil
+}
+
+// subscription checks the URL and event types of a webhook. When ok is false the 400 was written already.
+func subscription(c echo.Context, rawURL string, events []string) (params repository.WebhooksCreateParams, ok bool, err error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return params, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid url, expected an absolute http or https URL",
+		})
+	}
+	joined, ok := webhook.JoinEvents(events)
+	if !ok {
+		return params, false, c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid events, expected some of " + strings.Join(webhook.Events, ", ") + " or " + webhook.AllEvents,
+		})
+	}
+	return repository.WebhooksCreateParams{Url: u.String(), Events: joined}, true, nil
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/health"
+)
+
+// Number of log rows waiting for the rw connection above which the service is reported as degraded.
+const logBacklogWarn = 100
+
+// healthRegistry returns the readiness checks of the server, creating them on first use.
+func (s *Server) healthRegistry() *health.Registry {
+	// Probes run concurrently, the first ones must not create the registry twice
+	s.healthOnce.Do(func() {
+		s.health = health.NewRegistry(2 * time.Second)
+		s.db.RegisterHealthChecks(s.health)
+		s.health.Register("log_writer", false, s.logWriterCheck)
+	})
+	return s.health
+}
+
+// logWriterStatus is how the writes of the logs sink went lately.
+type logWriterStatus struct {
+	mu        sync.Mutex
+	lastWrite time.Time
+	lastError error
+	errorAt   time.Time
+}
+
+// record notes the outcome of a write.
+func (l *logWriterStatus) record(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		l.lastError, l.errorAt = err, time.Now()
+		return
+	}
+	l.lastWrite = time.Now()
+}
+
+// logWriterCheck reports the log writer as degraded while its last write failed, or too many log rows wait
+// for the rw connection.
+func (s *Server) logWriterCheck(ctx context.Context) health.Result {
+	backlog := s.pendingLogWrites.Load()
+	details := map[string]string{"backlog": strconv.FormatInt(backlog, 10)}
+
+	s.logWriter.mu.Lock()
+	lastWrite, lastError, errorAt := s.logWriter.lastWrite, s.logWriter.lastError, s.logWriter.errorAt
+	s.logWriter.mu.Unlock()
+	if !lastWrite.IsZero() {
+		details["last_write"] = lastWrite.UTC().Format(time.RFC3339)
+	}
+	if lastError != nil {
+		details["last_error"] = lastError.Error()
+		details["last_error_at"] = errorAt.UTC().Format(time.RFC3339)
+	}
+
+	if lastError != nil && !errorAt.Before(lastWrite) {
+		return health.Degraded(details, fmt.Errorf("writing logs failed: %w", lastError))
+	}
+	if backlog > logBacklogWarn {
+		return health.Degraded(details, fmt.Errorf("more than %d log entries are waiting to be written", logBacklogWarn))
+	}
+	return health.Up(details)
+}
+
+// healthHandler returns the database statistics.
+// @Summary Database health
+// @Description Pings both database pools and returns connection pool statistics.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string "Database is up"
+// @Failure 503 {object} map[string]string "Database is down"
+// @Router /health [get]
+func (s *Server) healthHandler(c echo.Context) error {
+	stats := s.db.Health()
+	if stats["status"] != "up" {
+		return c.JSON(http.StatusServiceUnavailable, stats)
+	}
+	return c.JSON(http.StatusOK, stats)
+}
+
+// liveHandler reports whether the process is running and able to serve requests.
+// @Summary Liveness probe
+// @Description Always returns 200 while the server is able to handle requests, does not touch any dependency.
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string "Server is alive"
+// @Router /health/live [get]
+func (s *Server) liveHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": string(health.StatusUp),
+	})
+}
+
+// readyHandler runs all registered checks.
+// @Summary Readiness probe
+// @Description Runs the dependency checks (database pools, schema version, wal size, free disk space, log writer). Returns 503 when a critical check is down, degraded checks still return 200.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report "Ready, possibly degraded"
+// @Failure 503 {object} health.Report "Not ready"
+// @Router /health/ready [get]
+func (s *Server) readyHandler(c echo.Context) error {
+	report := s.healthRegistry().Run(c.Request().Context())
+	if report.Status == health.StatusDown {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"backendT/internal/jobs"
+)
+
+// Kinds of the background jobs the server runs, besides the cleanup of the jobs themselves.
+const (
+	// jobOutboxCleanup deletes the dispatched outbox events older than OUTBOX_RETENTION, every hour
+	jobOutboxCleanup = "outbox.cleanup"
+)
+
+// jobQueue returns the queue of background jobs, with the handlers of every kind of job and the recurring ones.
+func (s *Server) jobQueue() *jobs.Queue {
+	if s.queue == nil {
+		s.queue = jobs.NewQueue(s.db.GetRepositoryRW(), jobs.ConfigFromEnv())
+		s.queue.Handle(jobOutboxCleanup, func(ctx context.Context, job jobs.Job) error {
+			_, err := s.events().Cleanup(ctx, time.Now())
+			return err
+		})
+		if err := s.queue.Schedule(jobOutboxCleanup, "@hourly", nil); err != nil {
+			panic(err)
+		}
+	}
+	return s.queue
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/redact"
+)
+
+// RequestPayload holds the captured (redacted, size capped) headers and bodies of a request.
+type RequestPayload struct {
+	RequestHeaders        http.Header `json:"request_headers"`
+	RequestBody           string      `json:"request_body,omitempty"`
+	RequestBodyTruncated  bool        `json:"request_body_truncated,omitempty"`
+	ResponseHeaders       http.Header `json:"response_headers"`
+	ResponseBody          string      `json:"response_body,omitempty"`
+	ResponseBodyTruncated bool        `json:"response_body_truncated,omitempty"`
+}
+
+// payloadCapture decides which request and response bodies are captured.
+type payloadCapture struct {
+	maxBytes     int
+	contentTypes []string
+}
+
+// payloadCaptureFromEnv returns nil unless LOG_PAYLOADS is enabled. Bodies are capped at
+// LOG_PAYLOADS_MAX_BYTES and only captured for the LOG_PAYLOADS_CONTENT_TYPES prefixes.
+func payloadCaptureFromEnv() *payloadCapture {
+	if enabled, _ := strconv.ParseBool(os.Getenv("LOG_PAYLOADS")); !enabled {
+		return nil
+	}
+
+	p := &payloadCapture{
+		maxBytes:     64 << 10,
+		contentTypes: []string{echo.MIMEApplicationJSON, "text/", echo.MIMEApplicationForm, echo.MIMEApplicationXML},
+	}
+	if maxBytes, err := strconv.Atoi(os.Getenv("LOG_PAYLOADS_MAX_BYTES")); err == nil && maxBytes >= 0 {
+		p.maxBytes = maxBytes
+	}
+	if types := os.Getenv("LOG_PAYLOADS_CONTENT_TYPES"); types != "" {
+		p.contentTypes = strings.Split(types, ",")
+	}
+	return p
+}
+
+func (p *payloadCapture) allowed(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range p.contentTypes {
+		if prefix = strings.TrimSpace(prefix); prefix != "" && strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// capturing is the state of a capture in progress, created before the handler runs.
+type capturing struct {
+	p             *payloadCapture
+	requestBody   []byte
+	requestCut    bool
+	captureBody   bool
+	response      *captureWriter
+	originalWrite http.ResponseWriter
+}
+
+// start buffers up to maxBytes of the request body (the handler still reads all of it)
+// and tees the response into a capped buffer.
+func (p *payloadCapture) start(c echo.Context) *capturing {
+	cp := &capturing{p: p, originalWrite: c.Response().Writer}
+
+	req := c.Request()
+	if req.Body != nil && p.allowed(req.Header.Get(echo.HeaderContentType)) {
+		cp.captureBody = true
+		buf, _ := io.ReadAll(io.LimitReader(req.Body, int64(p.maxBytes)+1))
+		req.Body = readCloser{io.MultiReader(bytes.NewReader(buf), req.Body), req.Body}
+		if len(buf) > p.maxBytes {
+			buf, cp.requestCut = buf[:p.maxBytes], true
+		}
+		cp.requestBody = buf
+	}
+
+	cp.response = &captureWriter{ResponseWriter: c.Response().Writer, limit: p.maxBytes}
+	c.Response().Writer = cp.response
+	return cp
+}
+
+// finish restores the response writer and returns the redacted payload.
+func (cp *capturing) finish(c echo.Context, r *redact.Redactor) *RequestPayload {
+	c.Response().Writer = cp.originalWrite
+
+	payload := &RequestPayload{
+		RequestHeaders:  r.Headers(c.Request().Header),
+		ResponseHeaders: r.Headers(c.Response().Header()),
+	}
+	if cp.captureBody {
+		payload.RequestBody = string(r.Body(cp.requestBody))
+		payload.RequestBodyTruncated = cp.requestCut
+	}
+	if cp.p.allowed(c.Response().Header().Get(echo.HeaderContentType)) {
+		body := cp.response.buf.Bytes()
+		// A truncated JSON body can't be parsed, so only the patterns get applied to it
+		payload.ResponseBody = string(r.Body(body))
+		payload.ResponseBodyTruncated = cp.response.truncated
+	}
+	return payload
+}
+
+// captureWriter copies up to limit bytes of everything written to the response.
+type captureWriter struct {
+	http.ResponseWriter
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	if room := w.limit - w.buf.Len(); room > 0 {
+		if len(b) > room {
+			w.buf.Write(b[:room])
+			w.truncated = true
+		} else {
+			w.buf.Write(b)
+		}
+	} else if len(b) > 0 {
+		w.truncated = true
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the original writer (for Flush and friends).
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
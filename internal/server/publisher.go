@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"time"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/outbox"
+	"backendT/internal/server/api"
+)
+
+// runPostPublisher publishes the scheduled posts once their published_at has passed, every
+// POST_PUBLISH_INTERVAL (30s by default, 0 disables it).
+func (s *Server) runPostPublisher(ctx context.Context) {
+	interval := 30 * time.Second
+	if v, err := time.ParseDuration(os.Getenv("POST_PUBLISH_INTERVAL")); err == nil {
+		interval = v
+	}
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.publishDuePosts(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("Error publishing scheduled posts: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDuePosts publishes the posts scheduled at or before now and returns how many there were.
+func (s *Server) publishDuePosts(ctx context.Context, now time.Time) (int, error) {
+	var posts []repository.Post
+	err := s.db.WithTx(ctx, func(q *repository.Queries) (err error) {
+		posts, err = q.PostsPublishDue(ctx, sql.NullTime{Time: now.UTC(), Valid: true})
+		if err != nil {
+			return err
+		}
+		// Cached lists and posts still showing them as scheduled are dropped by the outbox subscriber
+		for _, post := range posts {
+			if err := outbox.Append(ctx, q, outbox.AggregatePost, post.ID, outbox.EventPostUpdated, api.NewPost(post)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(posts), nil
+}
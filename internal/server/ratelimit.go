@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/ratelimit"
+)
+
+// Rate limited route groups and their default rules, each can be changed with RATE_LIMIT_<GROUP>
+// (e.g. RATE_LIMIT_LOGS="10/m burst=20 by=api_key" or RATE_LIMIT_DEFAULT=off).
+var rateLimitDefaults = map[string]string{
+	// every route
+	"default": "300/m",
+	// the /logs endpoints scan the whole logs table
+	"logs": "30/m",
+	// endpoints creating rows
+	"writes": "60/m",
+}
+
+// Paths never rate limited, so probes and the docs keep working for throttled clients.
+var rateLimitExempt = []string{"/health", "/swagger"}
+
+// Identity used by the api_key rule, for the keys listed in RATE_LIMIT_API_KEYS
+const headerAPIKey = "X-Api-Key"
+
+type rateLimiter struct {
+	store ratelimit.Store
+	rules map[string]ratelimit.Rule
+	// Hashes of the issued api keys, any other key is counted against the client ip
+	apiKeys map[string]bool
+}
+
+// rateLimiter returns the limiter configured from the env, creating it on first use.
+// RATE_LIMIT_STORE selects where buckets are kept: memory (default) or sqlite to survive restarts, at the cost
+// of a write on the rw connection for every request.
+func (s *Server) rateLimiter() *rateLimiter {
+	if s.limiter != nil {
+		return s.limiter
+	}
+
+	l := &rateLimiter{rules: make(map[string]ratelimit.Rule, len(rateLimitDefaults)), apiKeys: make(map[string]bool)}
+	for _, key := range strings.Split(os.Getenv("RATE_LIMIT_API_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			l.apiKeys[hashAPIKey(key)] = true
+		}
+	}
+	for group, def := range rateLimitDefaults {
+		value, set := os.LookupEnv("RATE_LIMIT_" + strings.ToUpper(group))
+		if !set {
+			value = def
+		}
+		rule, err := ratelimit.ParseRule(value)
+		if err != nil {
+			log.Printf("Invalid RATE_LIMIT_%s, using %q: %v", strings.ToUpper(group), def, err)
+			rule, _ = ratelimit.ParseRule(def)
+		}
+		l.rules[group] = rule
+	}
+
+	switch store := os.Getenv("RATE_LIMIT_STORE"); store {
+	case "sqlite":
+		l.store = ratelimit.NewSQLiteStore(s.db)
+	case "", "memory":
+		l.store = ratelimit.NewMemoryStore()
+	default:
+		log.Printf("Unknown RATE_LIMIT_STORE %q, using memory", store)
+		l.store = ratelimit.NewMemoryStore()
+	}
+
+	s.limiter = l
+	return l
+}
+
+// idle is how long a bucket has to be unused before it is full for every rule, and can be forgotten.
+func (l *rateLimiter) idle() time.Duration {
+	idle := time.Minute
+	for _, rule := range l.rules {
+		idle = max(idle, rule.RefillTime())
+	}
+	return idle
+}
+
+// runSweeper forgets unused buckets until ctx is cancelled.
+func (l *rateLimiter) runSweeper(ctx context.Context) {
+	ratelimit.RunSweeper(ctx, l.store, time.Minute, l.idle(), func(err error) {
+		log.Printf("Error sweeping rate limit buckets: %v", err)
+	})
+}
+
+// RateLimit limits the requests of every client to the routes of a group.
+// Responses carry the RateLimit-* headers, rejected requests get a 429 with Retry-After and a JSON error, on
+// every route of the group. The handlers don't document the 429 themselves.
+// When the store fails requests are let through.
+func (s *Server) RateLimit(group string) echo.MiddlewareFunc {
+	l := s.rateLimiter()
+	rule, ok := l.rules[group]
+	if !ok {
+		log.Printf("Unknown rate limit group %q, not limited", group)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if !rule.Enabled() {
+			return next
+		}
+
+		return func(c echo.Context) error {
+			for _, prefix := range rateLimitExempt {
+				if strings.HasPrefix(c.Path(), prefix) {
+					return next(c)
+				}
+			}
+
+			key := group + ":" + l.identity(c, rule.By)
+			res, err := l.store.Take(c.Request().Context(), key, rule.Limit, time.Now())
+			if err != nil {
+				log.Printf("Error checking rate limit of %s: %v", key, err)
+				return next(c)
+			}
+
+			h := c.Response().Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+			h.Set("RateLimit-Policy", rule.Policy())
+
+			if !res.Allowed {
+				h.Set(echo.HeaderRetryAfter, ceilSeconds(res.RetryAfter))
+				return c.JSON(http.StatusTooManyRequests, map[string]string{
+					"error": "Too many requests, retry later",
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+// identity returns who a request is counted against: the api key for the api_key rule when it is one of the
+// issued keys, the client ip otherwise. Clients can't pick their bucket by making up keys, and keys are hashed so
+// they are never stored.
+func (l *rateLimiter) identity(c echo.Context, by ratelimit.Identity) string {
+	if by == ratelimit.ByAPIKey {
+		if key := c.Request().Header.Get(headerAPIKey); key != "" {
+			if hash := hashAPIKey(key); l.apiKeys[hash] {
+				return "key:" + hash
+			}
+		}
+	}
+	return "ip:" + c.RealIP()
+}
+
+// hashAPIKey returns the short hash api keys are known by.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/redact"
+)
+
+// Replay modes, a dry run only executes requests that can't change anything.
+const (
+	replayModeDryRun = "dry-run"
+	replayModeLive   = "live"
+)
+
+// ReplayRequest is the request rebuilt from a log entry.
+type ReplayRequest struct {
+	Method  string      `json:"method"`
+	URI     string      `json:"uri"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// ReplayResponse is the status and (redacted) body of the original or the replayed response.
+type ReplayResponse struct {
+	RequestID string `json:"request_id,omitempty"`
+	Status    int    `json:"status"`
+	Body      string `json:"body,omitempty"`
+}
+
+// ReplayDifference is a value that changed between the original and the replayed response.
+// Path is "status", "body" or a JSON path into the body like "body.user.email" or "body[0].id".
+type ReplayDifference struct {
+	Path     string `json:"path"`
+	Original any    `json:"original"`
+	Replayed any    `json:"replayed"`
+}
+
+// ReplayResult is returned by the replay endpoint.
+type ReplayResult struct {
+	LogID    int64              `json:"log_id"`
+	Mode     string             `json:"mode"`
+	Executed bool               `json:"executed"`
+	Request  ReplayRequest      `json:"request"`
+	Original ReplayResponse     `json:"original"`
+	Replayed *ReplayResponse    `json:"replayed,omitempty"`
+	Diff     []ReplayDifference `json:"diff"`
+	Warnings []string           `json:"warnings,omitempty"`
+}
+
+// replayHandler re-issues a logged request against this server and diffs the responses.
+// @Summary Replay a logged request
+// @Description Rebuilds a request from its log entry (and captured payload, see LOG_PAYLOADS) and runs it through the API in process.
+// @Description In dry-run mode (the default) only GET, HEAD and OPTIONS requests are executed, others just return the rebuilt request.
+// @Description Live mode executes any method and refuses requests whose body was not fully captured.
+// @Description Redacted values are replayed as "[REDACTED]" and redacted headers are dropped. Requires the admin token.
+// @Tags admin
+// @Produce json
+// @Security AdminToken
+// @Param id path int true "Log ID"
+// @Param mode query string false "dry-run or live" Enums(dry-run, live) default(dry-run)
+// @Success 200 {object} ReplayResult "Rebuilt request, both responses and their differences"
+// @Failure 400 {object} map[string]string "Bad request - invalid ID or mode"
+// @Failure 401 {object} map[string]string "Invalid admin token"
+// @Failure 404 {object} map[string]string "Log not found"
+// @Failure 422 {object} map[string]string "The request body was not captured completely"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router /admin/logs/{id}/replay [post]
+func (s *Server) replayHandler(c echo.Context) error {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid log ID format",
+		})
+	}
+
+	mode := c.QueryParam("mode")
+	if mode == "" {
+		mode = replayModeDryRun
+	}
+	if mode != replayModeDryRun && mode != replayModeLive {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"error": "Invalid mode, expected dry-run or live",
+		})
+	}
+
+	ctx := c.Request().Context()
+	repo := s.db.GetRepositoryRO()
+	entry, err := repo.LogsGetByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return c.JSON(http.StatusNotFound, map[string]string{
+				"error": "Log not found",
+			})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch log",
+		})
+	}
+
+	result := &ReplayResult{
+		LogID: entry.ID,
+		Mode:  mode,
+		Request: ReplayRequest{
+			Method:  entry.Method.String,
+			URI:     entry.Uri.String,
+			Headers: http.Header{},
+		},
+		Original: ReplayResponse{
+			RequestID: entry.RequestID.String,
+			Status:    int(entry.Status.Int64),
+		},
+		Diff: []ReplayDifference{},
+	}
+
+	bodyComplete := entry.BytesIn.Int64 <= 0
+	payload, err := s.db.GetRepositoryRO().LogPayloadsGetByLogID(ctx, id)
+	switch {
+	case err == nil:
+		var headers http.Header
+		if payload.RequestHeaders.Valid {
+			json.Unmarshal([]byte(payload.RequestHeaders.String), &headers)
+		}
+		result.Request.Headers = replayHeaders(headers)
+		result.Request.Body = payload.RequestBody.String
+		result.Original.Body = payload.ResponseBody.String
+		bodyComplete = bodyComplete || (payload.RequestBody.Valid && !payload.RequestBodyTruncated)
+		if payload.ResponseBodyTruncated {
+			result.Warnings = append(result.Warnings, "the original response body was truncated")
+		}
+	case err == sql.ErrNoRows:
+		result.Warnings = append(result.Warnings, "no payload was captured for this request, only the method and uri are replayed")
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to fetch log payload",
+		})
+	}
+
+	if !bodyComplete {
+		result.Warnings = append(result.Warnings, "the request body was not captured completely")
+	}
+	if strings.Contains(result.Request.URI, redact.Mask) || strings.Contains(result.Request.Body, redact.Mask) {
+		result.Warnings = append(result.Warnings, "the request contains redacted values, they are replayed as "+redact.Mask)
+	}
+
+	if mode == replayModeDryRun && !isSafeMethod(result.Request.Method) {
+		return c.JSON(http.StatusOK, result)
+	}
+	if mode == replayModeLive && !bodyComplete {
+		return c.JSON(http.StatusUnprocessableEntity, map[string]string{
+			"error": "The request body was not captured completely, enable LOG_PAYLOADS or raise LOG_PAYLOADS_MAX_BYTES",
+		})
+	}
+
+	replayed, err := s.replay(c, entry.Host.String, entry.ID, result.Request)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{
+			"error": "Failed to replay request",
+		})
+	}
+	result.Executed = true
+	result.Replayed = replayed
+
+	if replayed.Status != result.Original.Status {
+		result.Diff = append(result.Diff, ReplayDifference{Path: "status", Original: result.Original.Status, Replayed: replayed.Status})
+	}
+	if result.Original.Body == "" && replayed.Body != "" {
+		result.Warnings = append(result.Warnings, "the original response body was not captured, only the status is compared")
+	} else {
+		result.Diff = diffBodies(result.Original.Body, replayed.Body, result.Diff)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// replay runs the request through the whole Echo stack (middlewares included, so the replay is logged too).
+func (s *Server) replay(c echo.Context, host string, logID int64, r ReplayRequest) (*ReplayResponse, error) {
+	req, err := http.NewRequestWithContext(c.Request().Context(), r.Method, r.URI, strings.NewReader(r.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.RequestURI = r.URI
+	req.Host = host
+	req.RemoteAddr = c.Request().RemoteAddr
+	req.Header = r.Headers.Clone()
+	req.Header.Set("X-Replay-Of", strconv.FormatInt(logID, 10))
+
+	rec := httptest.NewRecorder()
+	c.Echo().ServeHTTP(rec, req)
+
+	return &ReplayResponse{
+		RequestID: rec.Header().Get(echo.HeaderXRequestID),
+		Status:    rec.Code,
+		Body:      string(s.redactor().Body(bytes.TrimSpace(rec.Body.Bytes()))),
+	}, nil
+}
+
+// replayHeaders drops the headers that can't or shouldn't be replayed:
+// redacted ones, a new request id is generated and the body length is recomputed.
+func replayHeaders(h http.Header) http.Header {
+	out := http.Header{}
+	for name, values := range h {
+		switch http.CanonicalHeaderKey(name) {
+		case echo.HeaderXRequestID, echo.HeaderContentLength:
+			continue
+		}
+		if len(values) > 0 && values[0] == redact.Mask {
+			continue
+		}
+		out[name] = values
+	}
+	return out
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// diffBodies compares JSON bodies field by field, anything else as a whole.
+func diffBodies(original, replayed string, out []ReplayDifference) []ReplayDifference {
+	var a, b any
+	if json.Unmarshal([]byte(original), &a) == nil && json.Unmarshal([]byte(replayed), &b) == nil {
+		return diffJSON("body", a, b, out)
+	}
+	if original != replayed {
+		out = append(out, ReplayDifference{Path: "body", Original: original, Replayed: replayed})
+	}
+	return out
+}
+
+func diffJSON(path string, a, b any, out []ReplayDifference) []ReplayDifference {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, seen := av[k]; !seen {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			out = diffJSON(path+"."+k, av[k], bv[k], out)
+		}
+		return out
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < max(len(av), len(bv)); i++ {
+			var ai, bi any
+			if i < len(av) {
+				ai = av[i]
+			}
+			if i < len(bv) {
+				bi = bv[i]
+			}
+			out = diffJSON(fmt.Sprintf("%s[%d]", path, i), ai, bi, out)
+		}
+		return out
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		out = append(out, ReplayDifference{Path: path, Original: a, Replayed: b})
+	}
+	return out
+}
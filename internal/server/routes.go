@@ -1,26 +1,21 @@
 package server
 
 import (
-	"bytes"
-	"database/sql"
-	"io"
 	"net/http"
-	"os"
 
-	"fmt"
-	"log"
 	"math/rand"
-	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 
-	"github.com/Treblle/treblle-go/v2"
-
 	echoSwagger "github.com/swaggo/echo-swagger"
 
-	"backendT/internal/database/repository"
+	"backendT/internal/httpcache"
+	"backendT/internal/server/api"
 	"backendT/internal/server/handlers"
+	"backendT/internal/server/handlers/jobs"
+	"backendT/internal/server/handlers/profiles"
+	"backendT/internal/server/handlers/webhooks"
 
 	_ "backendT/docs"
 )
@@ -30,10 +25,21 @@ import (
 // @description Your API Description
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description Admin token as "Bearer <ADMIN_TOKEN>"
 func (s *Server) RegisterRoutes() http.Handler {
 	e := echo.New()
 
-	e.Use(s.LoggingMiddleware())
+	// Give every request an X-Request-ID (kept when the client sends one) so logs can be looked up by it
+	e.Use(middleware.RequestID())
+
+	// Compress responses for clients accepting br or gzip, see compress.go and COMPRESS_* to configure it
+	e.Use(s.CompressionMiddleware())
+
+	// Record every request into the configured analytics sinks (logs table, Treblle, file, webhook)
+	e.Use(s.AnalyticsMiddleware(s.analyticsSinksFromEnv()...))
 
 	e.Use(middleware.Recover())
 
@@ -42,56 +48,228 @@ func (s *Server) RegisterRoutes() http.Handler {
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     []string{"https://*", "http://*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", headerAPIKey, api.HeaderUserID, httpcache.HeaderIfMatch, httpcache.HeaderIfNoneMatch, echo.HeaderIfModifiedSince},
+		ExposeHeaders:    []string{echo.HeaderXRequestID, httpcache.HeaderETag, echo.HeaderLastModified, echo.HeaderRetryAfter, "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
 
-	treblle.Configure(treblle.Configuration{
-		SDK_TOKEN: os.Getenv("TREBLLE_SDK_TOKEN"),
-		API_KEY:   os.Getenv("TREBLLE_API_KEY"),
-		Debug:     false,
-	})
+	// Per client rate limits, see ratelimit.go for the groups and RATE_LIMIT_* to configure them. Every route
+	// but /health and /swagger may answer 429 Too Many Requests with Retry-After
+	e.Use(s.RateLimit("default"))
 
-	// Wrap treblle's net/http middleware
-	e.Use(s.TLoggingMiddleware())
+	// Create the readiness checks up front so background workers can register theirs
+	s.healthRegistry()
 
 	e.GET("/health", s.healthHandler)
+	e.GET("/health/live", s.liveHandler)
+	e.GET("/health/ready", s.readyHandler)
 
 	e.GET("/failure", s.simulateHorribleFailureRandomly)
 
-	handlersRW := handlers.New(s.db.GetRepositoryRW())
+	// Avatar files, uploaded with PUT /users/id/:id/avatar
+	s.registerAvatarRoutes(e)
+
+	// Versioned API, see versioning.go. /v1 and /v2 have the same routes and handlers, the group
+	// records its version on the request and the handlers answer v1 with the repository rows and
+	// v2 with the models of the api package (api.Legacy, api.Render).
+	e.Pre(s.APIVersionMiddleware())
+	s.registerV1Routes(s.apiGroup(e, "v1"))
+	s.registerV2Routes(s.apiGroup(e, "v2"))
+
+	// Admin endpoints, require ADMIN_TOKEN as a bearer token
+	admin := e.Group("/admin", s.AdminMiddleware())
+	admin.POST("/backup", s.backupHandler)
+	// curl example command: curl -X POST http://localhost:8080/admin/backup -H "Authorization: Bearer $ADMIN_TOKEN"
+	// Captured payloads are redacted, but still only for admins
+	admin.GET("/logs/:id", handlers.New(s.db.GetReadOnlyDB(), nil, nil).Logs.GetLogDetail)
+	// curl example command: curl http://localhost:8080/admin/logs/1 -H "Authorization: Bearer $ADMIN_TOKEN"
+	admin.POST("/logs/:id/replay", s.replayHandler)
+	// curl example command: curl -X POST 'http://localhost:8080/admin/logs/1/replay?mode=live' -H "Authorization: Bearer $ADMIN_TOKEN"
+
+	// Comment moderation, hiding and showing comments changes the cached post responses
+	moderation := handlers.New(s.db.GetReadWriteDB(), s.db, nil).Posts
+	postsWrite := httpcache.Invalidate(s.httpCache(), "posts")
+	admin.GET("/comments/reports", moderation.GetCommentReports)
+	// curl example command: curl http://localhost:8080/admin/comments/reports -H "Authorization: Bearer $ADMIN_TOKEN"
+	admin.POST("/comments/:id/hide", moderation.HideComment, postsWrite)
+	// curl example command: curl -X POST http://localhost:8080/admin/comments/1/hide -H "Authorization: Bearer $ADMIN_TOKEN"
+	admin.POST("/comments/:id/unhide", moderation.UnhideComment, postsWrite)
+	// curl example command: curl -X POST http://localhost:8080/admin/comments/1/unhide -H "Authorization: Bearer $ADMIN_TOKEN"
+	admin.POST("/comments/:id/dismiss", moderation.DismissCommentReports)
+	// curl example command: curl -X POST http://localhost:8080/admin/comments/1/dismiss -H "Authorization: Bearer $ADMIN_TOKEN"
+
+	// Webhook subscriptions of other services, see the webhook package for the deliveries
+	webhooksHandler := webhooks.NewWebhooksHandler(s.db.GetRepositoryRW())
+	admin.POST("/webhooks", webhooksHandler.CreateWebhook)
+	// curl example command: curl -X POST http://localhost:8080/admin/webhooks -H "Authorization: Bearer $ADMIN_TOKEN" -H "Content-Type: application/json" -d '{"url":"https://example.com/hooks","events":["post.created","post.updated"]}'
+	admin.GET("/webhooks", webhooksHandler.GetWebhooks)
+	// curl example command: curl http://localhost:8080/admin/webhooks -H "Authorization: Bearer $ADMIN_TOKEN"
+	admin.PUT("/webhooks/:id", webhooksHandler.UpdateWebhook)
+	// curl example command: curl -X PUT http://localhost:8080/admin/webhooks/1 -H "Authorization: Bearer $ADMIN_TOKEN" -H "Content-Type: application/json" -d '{"active":false}'
+	admin.DELETE("/webhooks/:id", webhooksHandler.DeleteWebhook)
+	// curl example command: curl -X DELETE http://localhost:8080/admin/webhooks/1 -H "Authorization: Bearer $ADMIN_TOKEN"
+	admin.GET("/webhooks/:id/deliveries", webhooksHandler.GetDeliveries)
+	// curl example command: curl 'http://localhost:8080/admin/webhooks/1/deliveries?limit=10' -H "Authorization: Bearer $ADMIN_TOKEN"
+	admin.POST("/webhooks/deliveries/:id/redeliver", webhooksHandler.Redeliver)
+	// curl example command: curl -X POST http://localhost:8080/admin/webhooks/deliveries/1/redeliver -H "Authorization: Bearer $ADMIN_TOKEN"
+
+	// Outbox events the subscribers gave up on, see the outbox package
+	admin.GET("/outbox/dead-letters", s.deadLettersHandler)
+	// curl example command: curl 'http://localhost:8080/admin/outbox/dead-letters?limit=10' -H "Authorization: Bearer $ADMIN_TOKEN"
+
+	// Background jobs, see the jobs package for the queue
+	jobsHandler := jobs.NewJobsHandler(s.db.GetRepositoryRW())
+	admin.GET("/jobs", jobsHandler.GetJobs)
+	// curl example command: curl 'http://localhost:8080/admin/jobs?status=failed' -H "Authorization: Bearer $ADMIN_TOKEN"
+	admin.POST("/jobs/:id/retry", jobsHandler.RetryJob)
+	// curl example command: curl -X POST http://localhost:8080/admin/jobs/1/retry -H "Authorization: Bearer $ADMIN_TOKEN"
+	admin.POST("/jobs/:id/cancel", jobsHandler.CancelJob)
+	// curl example command: curl -X POST http://localhost:8080/admin/jobs/1/cancel -H "Authorization: Bearer $ADMIN_TOKEN"
+
+	return e
+}
+
+// registerV1Routes registers the v1 API, also served on the paths without a version.
+func (s *Server) registerV1Routes(g *echo.Group) {
+	logsLimit := s.RateLimit("logs")
+	writesLimit := s.RateLimit("writes")
+
+	// Read responses get ETags and are cached until a write to the same resource
+	usersCache := httpcache.Read(s.httpCache(), "users")
+	// Drafts are only shown to their author, so post responses depend on who asks
+	postsCache := httpcache.Read(s.httpCache(), "posts", api.HeaderUserID)
+	usersWrite := httpcache.Invalidate(s.httpCache(), "users")
+	postsWrite := httpcache.Invalidate(s.httpCache(), "posts")
+
+	handlersRW := handlers.New(s.db.GetReadWriteDB(), s.db, s.notifications())
 	//e.GET("/users", handlersRW.Users.GetAllUsers)
-	e.POST("/users", handlersRW.Users.CreateUser)
+	g.POST("/users", handlersRW.Users.CreateUser, writesLimit, usersWrite)
 	// curl example command: curl -X POST http://localhost:8080/users -H "Content-Type: application/json" -d '{"username":"testuser","email":"test@aaaa.bbbb"}'
-	e.GET("/users/id/:id", handlersRW.Users.GetUserByID)
-	e.GET("/users/username/:username", handlersRW.Users.GetUserByUsername)
-	e.GET("/users/email/:email", handlersRW.Users.GetUserByEmail)
-
-	e.POST("/posts", handlersRW.Posts.CreatePost)
+	g.GET("/users/id/:id", handlersRW.Users.GetUserByID, usersCache)
+	g.PUT("/users/id/:id", handlersRW.Users.UpdateUser, writesLimit, usersWrite)
+	// curl example command: curl -X PUT http://localhost:8080/users/id/1 -H "Content-Type: application/json" -H 'If-Match: "<ETag of GET /users/id/1>"' -d '{"email":"new@aaaa.bbbb"}'
+	g.GET("/users/username/:username", handlersRW.Users.GetUserByUsername, usersCache)
+	g.GET("/users/email/:email", handlersRW.Users.GetUserByEmail, usersCache)
+
+	profilesHandler := profiles.NewProfilesHandler(s.db, s.db.GetRepositoryRW(), s.avatars())
+	g.PUT("/users/id/:id/profile", profilesHandler.UpdateProfile, writesLimit, usersWrite)
+	// curl example command: curl -X PUT http://localhost:8080/users/id/1/profile -H "Content-Type: application/json" -d '{"display_name":"Test User","bio":"Hello!"}'
+	g.PUT("/users/id/:id/avatar", profilesHandler.UploadAvatar, writesLimit, usersWrite)
+	// curl example command: curl -X PUT http://localhost:8080/users/id/1/avatar -F "avatar=@me.png"
+	g.DELETE("/users/id/:id/avatar", profilesHandler.DeleteAvatar, writesLimit, usersWrite)
+	// Follows change the feed, which is cached with the posts
+	g.PUT("/users/id/:id/follow", handlersRW.Users.FollowUser, writesLimit, usersWrite, postsWrite)
+	// curl example command: curl -X PUT http://localhost:8080/users/id/2/follow -H "X-User-ID: 1"
+	g.DELETE("/users/id/:id/follow", handlersRW.Users.UnfollowUser, writesLimit, usersWrite, postsWrite)
+	// curl example command: curl -X DELETE http://localhost:8080/users/id/2/follow -H "X-User-ID: 1"
+	g.GET("/users/id/:id/followers", handlersRW.Users.GetFollowers, usersCache)
+	// curl example command: curl 'http://localhost:8080/users/id/2/followers?limit=10'
+	g.GET("/users/id/:id/following", handlersRW.Users.GetFollowing, usersCache)
+	// curl example command: curl 'http://localhost:8080/users/id/1/following?limit=10&cursor=<cursor of the Link header>'
+	// Also deletes the posts of the user
+	g.DELETE("/users/id/:id", profilesHandler.DeleteUser, writesLimit, usersWrite, postsWrite)
+	// curl example command: curl -X DELETE http://localhost:8080/users/id/1
+
+	g.POST("/posts", handlersRW.Posts.CreatePost, writesLimit, postsWrite)
 	// curl example command: curl -X POST http://localhost:8080/posts -H "Content-Type: application/json" -d '{"title":"Test Post","content":"This is a test post.", "user_id":1}'
 
-	e.GET("/posts/id/:id", handlersRW.Posts.GetPostByID)
+	g.GET("/posts/id/:id", handlersRW.Posts.GetPostByID, postsCache)
 	// curl example command: curl http://localhost:8080/posts/id/1
-
-	e.GET("/posts/userid/:userid", handlersRW.Posts.GetPostByUserID)
+	g.PUT("/posts/id/:id", handlersRW.Posts.UpdatePost, writesLimit, postsWrite)
+	// curl example command: curl -X PUT http://localhost:8080/posts/id/1 -H "Content-Type: application/json" -H 'If-Match: "<ETag of GET /posts/id/1>"' -d '{"title":"New title"}'
+
+	g.POST("/posts/id/:id/publish", handlersRW.Posts.PublishPost, writesLimit, postsWrite)
+	// curl example command: curl -X POST http://localhost:8080/posts/id/1/publish -H "X-User-ID: 1" -H "Content-Type: application/json" -d '{"publish_at":"2030-01-31T12:00:00Z"}'
+	g.POST("/posts/id/:id/unpublish", handlersRW.Posts.UnpublishPost, writesLimit, postsWrite)
+	// curl example command: curl -X POST http://localhost:8080/posts/id/1/unpublish -H "X-User-ID: 1"
+	g.POST("/posts/id/:id/archive", handlersRW.Posts.ArchivePost, writesLimit, postsWrite)
+	// curl example command: curl -X POST http://localhost:8080/posts/id/1/archive -H "X-User-ID: 1"
+
+	g.GET("/posts/id/:id/revisions", handlersRW.Posts.GetPostRevisions, postsCache)
+	// curl example command: curl http://localhost:8080/posts/id/1/revisions
+	g.GET("/posts/id/:id/revisions/diff", handlersRW.Posts.DiffPostRevisions, postsCache)
+	// curl example command: curl 'http://localhost:8080/posts/id/1/revisions/diff?from=1&to=2&mode=word'
+	g.GET("/posts/id/:id/revisions/:revision", handlersRW.Posts.GetPostRevision, postsCache)
+	// curl example command: curl http://localhost:8080/posts/id/1/revisions/1
+	g.POST("/posts/id/:id/revisions/:revision/restore", handlersRW.Posts.RestorePostRevision, writesLimit, postsWrite)
+	// curl example command: curl -X POST http://localhost:8080/posts/id/1/revisions/1/restore
+
+	g.GET("/posts/id/:id/tags", handlersRW.Posts.GetPostTags, postsCache)
+	// curl example command: curl http://localhost:8080/posts/id/1/tags
+	g.PUT("/posts/id/:id/tags/:tag", handlersRW.Posts.AddPostTag, writesLimit, postsWrite)
+	// curl example command: curl -X PUT http://localhost:8080/posts/id/1/tags/golang
+	g.DELETE("/posts/id/:id/tags/:tag", handlersRW.Posts.RemovePostTag, writesLimit, postsWrite)
+	// curl example command: curl -X DELETE http://localhost:8080/posts/id/1/tags/golang
+
+	// Comments are cached with the posts they belong to
+	g.GET("/posts/id/:id/comments", handlersRW.Posts.GetPostComments, postsCache)
+	// curl example command: curl http://localhost:8080/posts/id/1/comments
+	g.POST("/posts/id/:id/comments", handlersRW.Posts.CreateComment, writesLimit, postsWrite)
+	// curl example command: curl -X POST http://localhost:8080/posts/id/1/comments -H "X-User-ID: 1" -H "Content-Type: application/json" -d '{"comment":"Me too","parent_id":1}'
+	g.POST("/comments/id/:id/report", handlersRW.Posts.ReportComment, writesLimit)
+	// curl example command: curl -X POST http://localhost:8080/comments/id/1/report -H "X-User-ID: 1" -H "Content-Type: application/json" -d '{"reason":"spam"}'
+
+	// Reaction counts are part of the post responses, so reactions are cached with the posts
+	g.GET("/posts/id/:id/reactions", handlersRW.Posts.GetPostReactions, postsCache)
+	// curl example command: curl http://localhost:8080/posts/id/1/reactions -H "X-User-ID: 1"
+	g.PUT("/posts/id/:id/reactions/:type", handlersRW.Posts.AddPostReaction, writesLimit, postsWrite)
+	// curl example command: curl -X PUT http://localhost:8080/posts/id/1/reactions/like -H "X-User-ID: 1"
+	g.DELETE("/posts/id/:id/reactions/:type", handlersRW.Posts.RemovePostReaction, writesLimit, postsWrite)
+	// curl example command: curl -X DELETE http://localhost:8080/posts/id/1/reactions/like -H "X-User-ID: 1"
+	g.GET("/comments/id/:id/reactions", handlersRW.Posts.GetCommentReactions, postsCache)
+	// curl example command: curl http://localhost:8080/comments/id/1/reactions -H "X-User-ID: 1"
+	g.PUT("/comments/id/:id/reactions/:type", handlersRW.Posts.AddCommentReaction, writesLimit, postsWrite)
+	// curl example command: curl -X PUT http://localhost:8080/comments/id/1/reactions/love -H "X-User-ID: 1"
+	g.DELETE("/comments/id/:id/reactions/:type", handlersRW.Posts.RemoveCommentReaction, writesLimit, postsWrite)
+	// curl example command: curl -X DELETE http://localhost:8080/comments/id/1/reactions/love -H "X-User-ID: 1"
+
+	g.GET("/posts/userid/:userid", handlersRW.Posts.GetPostByUserID, postsCache)
 	// curl example command: curl http://localhost:8080/posts/userid/1
 
-	// Read-only handlers for greater speed where big data is read
-	handlerRO := handlers.New(s.db.GetRepositoryRO())
-	e.GET("/users", handlerRO.Users.GetAllUsers)
-	e.GET("/posts", handlerRO.Posts.GetAllPosts)
-	e.GET("/logs", handlerRO.Logs.GetAllLogs)
+	// Notifications are created by the writes above, they are per user and never cached
+	g.GET("/notifications", handlersRW.Notifications.GetNotifications)
+	// curl example command: curl 'http://localhost:8080/notifications?unread=true&limit=10' -H "X-User-ID: 1"
+	g.GET("/notifications/unread", handlersRW.Notifications.GetUnreadCount)
+	// curl example command: curl http://localhost:8080/notifications/unread -H "X-User-ID: 1"
+	g.POST("/notifications/id/:id/read", handlersRW.Notifications.MarkRead, writesLimit)
+	// curl example command: curl -X POST http://localhost:8080/notifications/id/1/read -H "X-User-ID: 1"
+	g.POST("/notifications/read", handlersRW.Notifications.MarkAllRead, writesLimit)
+	// curl example command: curl -X POST http://localhost:8080/notifications/read -H "X-User-ID: 1"
+	g.GET("/notifications/stream", handlersRW.Notifications.StreamNotifications)
+	// curl example command: curl -N http://localhost:8080/notifications/stream -H "X-User-ID: 1"
 
-	e.GET("/logs/paginated", handlerRO.Logs.GetLogsWithPagination)
-	e.GET("/logs/filtered", handlerRO.Logs.GetLogsAdvanced)
+	// Read-only handlers for greater speed where big data is read
+	handlerRO := handlers.New(s.db.GetReadOnlyDB(), nil, nil)
+	// Streamed, caching would buffer the whole list
+	g.GET("/users", handlerRO.Users.GetAllUsers)
+	g.GET("/posts", handlerRO.Posts.GetAllPosts, postsCache)
+	// curl example command: curl 'http://localhost:8080/posts?tags=golang,sqlite&match=all'
+	// Not cached, the scores decay with time and no write would drop a stale ranking
+	g.GET("/posts/trending", handlerRO.Posts.GetTrendingPosts)
+	// curl example command: curl 'http://localhost:8080/posts/trending?window=24h&limit=10'
+	g.GET("/feed", handlerRO.Posts.GetFeed, postsCache)
+	// curl example command: curl 'http://localhost:8080/feed?limit=10' -H "X-User-ID: 1"
+	// Tag counts change with the posts, so they are cached with them
+	g.GET("/tags", handlerRO.Posts.GetTags, postsCache)
+	// curl example command: curl 'http://localhost:8080/tags?limit=10'
+	g.GET("/tags/autocomplete", handlerRO.Posts.AutocompleteTags, postsCache)
+	// curl example command: curl 'http://localhost:8080/tags/autocomplete?q=go'
+	g.GET("/logs", handlerRO.Logs.GetAllLogs, logsLimit)
+
+	g.GET("/logs/paginated", handlerRO.Logs.GetLogsWithPagination, logsLimit)
+	g.GET("/logs/filtered", handlerRO.Logs.GetLogsAdvanced, logsLimit)
 	// curl example command: curl -X 'GET' 'http://localhost:8080/logs/filtered?method=GET&response=200&timeRange=-18%20hour&offset=0&limit=10' -H 'accept: application/json'
-
-	return e
+	g.GET("/logs/:id", handlerRO.Logs.GetLogByID, logsLimit)
+	// curl example command: curl http://localhost:8080/logs/1
+	g.GET("/logs/request/:request_id", handlerRO.Logs.GetLogByRequestID, logsLimit)
+	// curl example command: curl http://localhost:8080/logs/request/<X-Request-ID of the response>
 }
 
-func (s *Server) healthHandler(c echo.Context) error {
-	return c.JSON(http.StatusOK, s.db.Health())
+// registerV2Routes registers the v2 API, the same routes as v1. The responses differ because the handlers
+// check the version of the request, they render the api models for v2 (see api.Render and api.Legacy).
+func (s *Server) registerV2Routes(g *echo.Group) {
+	s.registerV1Routes(g)
 }
 
 // Added it but never used it for hackathon, tho can still be used to simulate random failures
@@ -106,93 +284,3 @@ func (s *Server) simulateHorribleFailureRandomly(c echo.Context) error {
 		})
 	}
 }
-
-func (s *Server) LoggingMiddleware() echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			start := time.Now()
-
-			// Process the request
-			err := next(c)
-			if err != nil {
-				c.Error(err)
-			}
-
-			// Create log entry after request is processed
-			entry := repository.LogsCreateParams{
-				RequestID:    sql.NullString{String: c.Response().Header().Get(echo.HeaderXRequestID), Valid: true},
-				RemoteIp:     sql.NullString{String: c.RealIP(), Valid: true},
-				Host:         sql.NullString{String: c.Request().Host, Valid: true},
-				Method:       sql.NullString{String: c.Request().Method, Valid: true},
-				Uri:          sql.NullString{String: c.Request().RequestURI, Valid: true},
-				UserAgent:    sql.NullString{String: c.Request().UserAgent(), Valid: true},
-				Status:       sql.NullInt64{Int64: int64(c.Response().Status), Valid: true},
-				Error:        sql.NullString{String: fmt.Sprintf("%v", err), Valid: true},
-				Latency:      sql.NullInt64{Int64: time.Since(start).Microseconds(), Valid: true},
-				LatencyHuman: sql.NullString{String: time.Since(start).String(), Valid: true},
-				BytesIn:      sql.NullInt64{Int64: c.Request().ContentLength, Valid: true},
-				BytesOut:     sql.NullInt64{Int64: int64(c.Response().Size), Valid: true},
-			}
-
-			// Log to console
-			//logLine, _ := json.Marshal(entry)
-			//fmt.Fprintln(os.Stdout, string(logLine))
-
-			// Save to database
-			_, dbErr := s.db.GetRepositoryRW().LogsCreate(c.Request().Context(), entry)
-			if dbErr != nil {
-				log.Printf("Error saving log entry: %v", dbErr)
-			}
-
-			return err
-		}
-	}
-}
-
-func (s *Server) TLoggingMiddleware() echo.MiddlewareFunc {
-	return func(next echo.HandlerFunc) echo.HandlerFunc {
-		return func(c echo.Context) error {
-			// Read and buffer the request body so both Treblle and Echo handlers can consume it.
-			var bodyBytes []byte
-			if c.Request().Body != nil {
-				var err error
-				bodyBytes, err = io.ReadAll(c.Request().Body)
-				if err != nil {
-					return c.JSON(http.StatusInternalServerError, map[string]string{
-						"error": "failed to read request body",
-					})
-				}
-			}
-			// restore body for Echo handlers
-			c.Request().Body = io.NopCloser(bytes.NewReader(bodyBytes))
-
-			h := treblle.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// give Treblle a copy of the request body
-				if len(bodyBytes) > 0 {
-					r.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-				}
-
-				// Replace Echo's underlying response writer with Treblle's wrapped writer
-				originalWriter := c.Response().Writer
-				c.Response().Writer = w
-
-				if err := next(c); err != nil {
-					// let Echo handle the error
-					c.Error(err)
-				}
-
-				// restore original writer after the handler completes
-				c.Response().Writer = originalWriter
-			}))
-
-			// Use Echo's underlying response writer and a request copy for Treblle
-			reqForTreblle := c.Request().Clone(c.Request().Context())
-			if len(bodyBytes) > 0 {
-				reqForTreblle.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-			}
-			h.ServeHTTP(c.Response().Writer, reqForTreblle)
-
-			return nil
-		}
-	}
-}
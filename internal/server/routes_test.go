@@ -1,24 +1,51 @@
 package server
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"backendT/internal/database"
 	"backendT/internal/database/repository"
+	"backendT/internal/database/seed"
+	"backendT/internal/health"
+	"backendT/internal/jobs"
+	"backendT/internal/outbox"
+	"backendT/internal/server/api"
 	"backendT/internal/server/handlers"
+	"backendT/internal/server/handlers/posts"
+	"backendT/internal/server/handlers/stream"
+	"backendT/internal/webhook"
 
+	"github.com/andybalholm/brotli"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"github.com/stretchr/testify/assert"
 )
 
 func setupTestDb() database.Service {
 	dbService := database.New("file:memory:?mode=memory&cache=shared")
-	database.FillWithData(dbService)
+	if _, err := seed.RunIfEmpty(context.Background(), dbService, "demo", seed.DefaultSeed); err != nil {
+		panic(err)
+	}
 	return dbService
 }
 
@@ -27,14 +54,14 @@ func setupPostsTestServer() (*echo.Echo, *repository.Queries) {
 	dbService := setupTestDb()
 	repo := dbService.GetRepositoryRW()
 
-	postsHandler := handlers.New(repo).Posts
+	postsHandler := handlers.New(dbService.GetReadWriteDB(), dbService, nil).Posts
 
 	e.GET("/posts", postsHandler.GetAllPosts)
 	e.POST("/posts", postsHandler.CreatePost)
 	e.GET("/posts/id/:id", postsHandler.GetPostByID)
 	e.GET("/posts/userid/:userid", postsHandler.GetPostByUserID)
 
-	usersHandler := handlers.New(repo).Users
+	usersHandler := handlers.New(dbService.GetReadWriteDB(), dbService, nil).Users
 
 	e.GET("/users", usersHandler.GetAllUsers)
 	e.GET("/users/username/:username", usersHandler.GetUserByUsername)
@@ -46,7 +73,7 @@ func setupUsersTestServer() (*echo.Echo, *repository.Queries) {
 	dbService := setupTestDb()
 	repo := dbService.GetRepositoryRW()
 
-	usersHandler := handlers.New(repo).Users
+	usersHandler := handlers.New(dbService.GetReadWriteDB(), dbService, nil).Users
 
 	e.GET("/users", usersHandler.GetAllUsers)
 	e.POST("/users", usersHandler.CreateUser)
@@ -69,12 +96,12 @@ func setupLogsTestServer() (*echo.Echo, *repository.Queries) {
 	}
 	e.Use(s.LoggingMiddleware())
 
-	logsHandler := handlers.New(repo).Logs
+	logsHandler := handlers.New(dbService.GetReadWriteDB(), nil, nil).Logs
 	e.GET("/logs", logsHandler.GetAllLogs)
 	e.GET("/logs/paginated", logsHandler.GetLogsWithPagination)
 	e.GET("/logs/filtered", logsHandler.GetLogsAdvanced)
 
-	userHandler := handlers.New(repo).Users
+	userHandler := handlers.New(dbService.GetReadWriteDB(), nil, nil).Users
 
 	e.GET("/users", userHandler.GetAllUsers)
 
@@ -239,3 +266,1659 @@ func TestLogsEndpoints(t *testing.T) {
 	})
 
 }
+
+func TestHealthEndpoints(t *testing.T) {
+	e := echo.New()
+	s := &Server{db: setupTestDb()}
+
+	e.GET("/health", s.healthHandler)
+	e.GET("/health/live", s.liveHandler)
+	e.GET("/health/ready", s.readyHandler)
+
+	t.Run("Live", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/health/live", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("Ready", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var report health.Report
+		err := json.NewDecoder(rec.Body).Decode(&report)
+		assert.NoError(t, err)
+		for _, name := range []string{"db_ro", "db_rw", "migrations", "wal", "disk", "log_writer"} {
+			assert.Contains(t, report.Checks, name)
+		}
+		assert.Equal(t, health.StatusUp, report.Checks["migrations"].Status)
+	})
+
+	t.Run("Log writer", func(t *testing.T) {
+		ready := func() health.Result {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+			var report health.Report
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+			return report.Checks["log_writer"]
+		}
+
+		// Degraded from a failed write until the next one succeeds
+		s.logWriter.record(errors.New("disk I/O error"))
+		check := ready()
+		assert.Equal(t, health.StatusDegraded, check.Status)
+		assert.Equal(t, "disk I/O error", check.Details["last_error"])
+		assert.Contains(t, check.Error, "disk I/O error")
+
+		s.logWriter.record(nil)
+		check = ready()
+		assert.Equal(t, health.StatusUp, check.Status)
+		assert.NotEmpty(t, check.Details["last_write"])
+	})
+
+	t.Run("Ready fails on critical check", func(t *testing.T) {
+		s.healthRegistry().Register("broken", true, func(ctx context.Context) health.Result {
+			return health.Down(nil, fmt.Errorf("broken on purpose"))
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("Database health", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestAnalyticsSinks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{db: setupTestDb(), shutdownCtx: ctx}
+	// The http server starts shutting down before the last requests are done
+	cancel()
+
+	received := make(chan []RequestRecord, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []RequestRecord
+		if err := json.NewDecoder(r.Body).Decode(&batch); err == nil {
+			received <- batch
+		}
+	}))
+	defer receiver.Close()
+
+	path := filepath.Join(t.TempDir(), "analytics.jsonl")
+	t.Setenv("ANALYTICS_SINKS", "file,webhook")
+	t.Setenv("ANALYTICS_FILE", path)
+	t.Setenv("ANALYTICS_WEBHOOK_URL", receiver.URL)
+
+	sinks := s.analyticsSinksFromEnv()
+	assert.Len(t, sinks, 2)
+
+	e := echo.New()
+	e.Use(s.AnalyticsMiddleware(sinks...))
+	e.GET("/users", handlers.New(s.db.GetReadOnlyDB(), nil, nil).Users.GetAllUsers)
+
+	req := httptest.NewRequest(http.MethodGet, "/users?limit=1", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	t.Run("File sink", func(t *testing.T) {
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+
+		var record RequestRecord
+		assert.NoError(t, json.Unmarshal(data, &record))
+		assert.Equal(t, "/users?limit=1", record.URI)
+		assert.Equal(t, http.StatusOK, record.Status)
+		assert.Empty(t, record.Error)
+	})
+
+	t.Run("Sinks flush once the workers are waited for", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users?limit=2", nil)
+		e.ServeHTTP(httptest.NewRecorder(), req)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		assert.NoError(t, s.waitWorkers(ctx))
+
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "/users?limit=2")
+		select {
+		case batch := <-received:
+			if assert.Len(t, batch, 2) {
+				assert.Equal(t, "/users?limit=2", batch[1].URI)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook did not receive the records")
+		}
+	})
+}
+
+// A stream failing after it started breaks the connection instead of ending the array as if it were
+// complete, and the request is still recorded.
+func TestStreamAbort(t *testing.T) {
+	s := &Server{db: setupTestDb()}
+	path := filepath.Join(t.TempDir(), "analytics.jsonl")
+	t.Setenv("ANALYTICS_SINKS", "file")
+	t.Setenv("ANALYTICS_FILE", path)
+
+	e := echo.New()
+	e.Use(s.AnalyticsMiddleware(s.analyticsSinksFromEnv()...))
+	e.Use(middleware.Recover())
+	e.GET("/numbers", func(c echo.Context) error {
+		return stream.JSONArray(c, func(ctx context.Context, fn func(int) error) error {
+			for i := range 3 {
+				if err := fn(i); err != nil {
+					return err
+				}
+			}
+			return errors.New("connection lost")
+		})
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	// Depending on what was flushed already the request or the body fails, never both succeed
+	resp, err := http.Get(srv.URL + "/numbers")
+	if err == nil {
+		defer resp.Body.Close()
+		_, err = io.ReadAll(resp.Body)
+	}
+	assert.Error(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, s.waitWorkers(ctx))
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var record RequestRecord
+	assert.NoError(t, json.Unmarshal(data, &record))
+	assert.Equal(t, "/numbers", record.URI)
+	assert.Equal(t, http.ErrAbortHandler.Error(), record.Error)
+}
+
+func TestLogsAreRedacted(t *testing.T) {
+	dbService := setupTestDb()
+	s := &Server{db: dbService}
+
+	e := echo.New()
+	e.Use(s.LoggingMiddleware())
+	e.GET("/users/email/:email", handlers.New(dbService.GetReadWriteDB(), nil, nil).Users.GetUserByEmail)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/email/leak@example.com?token=s3cr3t&page=2", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer s3cr3t")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	logs, err := dbService.GetRepositoryRW().LogsGetAll(context.Background())
+	assert.NoError(t, err)
+
+	found := false
+	for _, l := range logs {
+		assert.NotContains(t, l.Path.String, "leak@example.com")
+		assert.NotContains(t, l.Path.String, "s3cr3t")
+		if l.Path.String == "/users/email/[REDACTED]?token=[REDACTED]&page=2" {
+			found = true
+		}
+	}
+	assert.True(t, found, "the redacted request should be logged")
+}
+
+func TestLogPayloads(t *testing.T) {
+	t.Setenv("LOG_PAYLOADS", "true")
+	t.Setenv("ADMIN_TOKEN", "admin")
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	req := httptest.NewRequest(http.MethodPost, "/v2/users", strings.NewReader(`{"username":"payload","email":"payload@example.com","password":"hunter2"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer s3cr3t")
+	req.Header.Set("X-Trace", "kept")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	created, err := s.db.GetRepositoryRO().LogsGetByRequestID(context.Background(), sql.NullString{String: rec.Header().Get(echo.HeaderXRequestID), Valid: true})
+	assert.NoError(t, err)
+
+	// Everyone gets the log entry, without the payload
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v2/logs/%d", created.ID), nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "payload")
+	assert.NotContains(t, rec.Body.String(), "X-Trace")
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/admin/logs/%d", created.ID), nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Admins get the captured headers and bodies, redacted
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/admin/logs/%d", created.ID), nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer admin")
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "hunter2")
+	assert.NotContains(t, rec.Body.String(), "s3cr3t")
+	assert.NotContains(t, rec.Body.String(), "payload@example.com")
+
+	var detail api.LogDetail
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&detail))
+	assert.Equal(t, created.ID, detail.ID)
+	if assert.NotNil(t, detail.Payload) && assert.NotNil(t, detail.Payload.RequestBody) && assert.NotNil(t, detail.Payload.ResponseBody) {
+		var requestBody map[string]string
+		assert.NoError(t, json.Unmarshal([]byte(*detail.Payload.RequestBody), &requestBody))
+		assert.Equal(t, map[string]string{"username": "payload", "email": "[REDACTED]", "password": "[REDACTED]"}, requestBody)
+		assert.False(t, detail.Payload.RequestBodyTruncated)
+		assert.Equal(t, "[REDACTED]", detail.Payload.RequestHeaders.Get(echo.HeaderAuthorization))
+		assert.Equal(t, "kept", detail.Payload.RequestHeaders.Get("X-Trace"))
+
+		var user api.User
+		assert.NoError(t, json.Unmarshal([]byte(*detail.Payload.ResponseBody), &user))
+		assert.Equal(t, "payload", user.Username)
+		assert.Equal(t, "[REDACTED]", user.Email)
+		assert.Contains(t, detail.Payload.ResponseHeaders.Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/admin/logs/999999999", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer admin")
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestLogReplay(t *testing.T) {
+	t.Setenv("LOG_PAYLOADS", "true")
+	t.Setenv("ADMIN_TOKEN", "admin")
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if strings.HasPrefix(target, "/admin") {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer admin")
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	logIDOf := func(rec *httptest.ResponseRecorder) int64 {
+		requestID := rec.Header().Get(echo.HeaderXRequestID)
+		assert.NotEmpty(t, requestID)
+
+		detail := do(http.MethodGet, "/logs/request/"+requestID, "")
+		assert.Equal(t, http.StatusOK, detail.Code)
+		var entry repository.Log
+		assert.NoError(t, json.NewDecoder(detail.Body).Decode(&entry))
+		assert.Equal(t, requestID, entry.RequestID.String)
+		return entry.ID
+	}
+
+	t.Run("Unknown request id", func(t *testing.T) {
+		rec := do(http.MethodGet, "/logs/request/does-not-exist", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Dry run executes safe requests", func(t *testing.T) {
+		id := logIDOf(do(http.MethodGet, "/users/id/1", ""))
+
+		rec := do(http.MethodPost, fmt.Sprintf("/admin/logs/%d/replay", id), "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var result ReplayResult
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+		assert.True(t, result.Executed)
+		assert.Equal(t, http.StatusOK, result.Replayed.Status)
+		assert.Empty(t, result.Diff)
+	})
+
+	t.Run("Dry run skips unsafe requests", func(t *testing.T) {
+		id := logIDOf(do(http.MethodPost, "/users", `{"username":"replayed","email":"replayed@example.com"}`))
+
+		rec := do(http.MethodPost, fmt.Sprintf("/admin/logs/%d/replay?mode=dry-run", id), "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var result ReplayResult
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+		assert.False(t, result.Executed)
+		assert.Nil(t, result.Replayed)
+		assert.Equal(t, "/users", result.Request.URI)
+		assert.Contains(t, result.Request.Body, `"username":"replayed"`)
+
+		rec = do(http.MethodPost, fmt.Sprintf("/admin/logs/%d/replay?mode=live", id), "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&result))
+		assert.True(t, result.Executed)
+		assert.NotNil(t, result.Replayed)
+		assert.Contains(t, result.Warnings, "the request contains redacted values, they are replayed as [REDACTED]")
+	})
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		rec := do(http.MethodPost, "/admin/logs/1/replay?mode=maybe", "")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestBackupEndpoint(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	t.Setenv("ADMIN_TOKEN", "admin")
+	dir := t.TempDir()
+	t.Setenv("BACKUP_DIR", dir)
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/backup", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer admin")
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	// Only the name of the snapshot is given away, not where the server keeps it
+	var body map[string]string
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.NotContains(t, body, "path")
+	assert.Equal(t, filepath.Base(body["name"]), body["name"])
+	_, err := os.Stat(filepath.Join(dir, body["name"]))
+	assert.NoError(t, err)
+}
+
+func TestDiffBodies(t *testing.T) {
+	diff := diffBodies(`{"id":1,"tags":["a","b"],"user":{"name":"x"}}`, `{"id":2,"tags":["a"],"user":{"name":"x","bio":"y"}}`, nil)
+	paths := make([]string, 0, len(diff))
+	for _, d := range diff {
+		paths = append(paths, d.Path)
+	}
+	assert.Equal(t, []string{"body.id", "body.tags[1]", "body.user.bio"}, paths)
+
+	assert.Equal(t, []ReplayDifference{{Path: "body", Original: "a", Replayed: "b"}}, diffBodies("a", "b", nil))
+}
+
+func TestRateLimit(t *testing.T) {
+	t.Setenv("RATE_LIMIT_LOGS", "2/m")
+	t.Setenv("RATE_LIMIT_WRITES", "1/m by=api_key")
+	t.Setenv("RATE_LIMIT_API_KEYS", "key-a,key-b")
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	do := func(method, target, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(`{"username":"limited","email":"limited@example.com"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if apiKey != "" {
+			req.Header.Set("X-Api-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Per group limit", func(t *testing.T) {
+		rec := do(http.MethodGet, "/logs/paginated", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "2;w=60", rec.Header().Get("RateLimit-Policy"))
+
+		// the group is shared by every /logs route
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/logs", "").Code)
+
+		rec = do(http.MethodGet, "/logs/paginated", "")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "30", rec.Header().Get(echo.HeaderRetryAfter))
+		assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+		// other groups and exempt paths are not affected
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/users/id/1", "").Code)
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/health/live", "").Code)
+	})
+
+	t.Run("Per api key limit", func(t *testing.T) {
+		assert.NotEqual(t, http.StatusTooManyRequests, do(http.MethodPost, "/users", "key-a").Code)
+		assert.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, "/users", "key-a").Code)
+		assert.NotEqual(t, http.StatusTooManyRequests, do(http.MethodPost, "/users", "key-b").Code)
+
+		// Made up keys share the bucket of the client ip
+		assert.NotEqual(t, http.StatusTooManyRequests, do(http.MethodPost, "/users", "made-up-1").Code)
+		assert.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, "/users", "made-up-2").Code)
+		assert.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, "/users", "").Code)
+	})
+}
+
+func TestConditionalRequests(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	do := func(method, target, body string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		for name, values := range header {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodGet, "/posts/id/1", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderLastModified))
+
+	rec = do(http.MethodGet, "/posts/id/1", "", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+
+	rec = do(http.MethodPut, "/posts/id/1", `{"title":"Changed"}`, http.Header{"If-Match": {`"stale"`}})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = do(http.MethodPut, "/posts/id/1", `{"title":"Changed"}`, http.Header{"If-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	newETag := rec.Header().Get("ETag")
+	assert.NotEqual(t, etag, newETag)
+
+	// The write dropped the cached post, the new version is served
+	rec = do(http.MethodGet, "/posts/id/1", "", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+	assert.Equal(t, newETag, rec.Header().Get("ETag"))
+	assert.Contains(t, rec.Body.String(), `"title":"Changed"`)
+
+	// The old ETag can't be used for another update
+	rec = do(http.MethodPut, "/posts/id/1", `{"title":"Again"}`, http.Header{"If-Match": {etag}})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = do(http.MethodPut, "/posts/id/999999", `{"title":"Missing"}`, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// Reactions are part of the v2 ETag, but reacting to a post doesn't fail its updates
+	etag = do(http.MethodGet, "/v2/posts/id/1", "", nil).Header().Get("ETag")
+	rec = do(http.MethodPut, "/v2/posts/id/1/reactions/like", "", http.Header{api.HeaderUserID: {"2"}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = do(http.MethodGet, "/v2/posts/id/1", "", http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+	rec = do(http.MethodPut, "/v2/posts/id/1", `{"title":"Reacted"}`, http.Header{"If-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = do(http.MethodPost, "/v2/posts/id/1/revisions/1/restore", "", http.Header{"If-Match": {etag}})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code, "the title changed since")
+
+	// Users are checked against If-Match and updated in one go
+	rec = do(http.MethodPost, "/v2/users", `{"username":"conditional","email":"conditional@example.com"}`, nil)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var user api.User
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&user))
+	target := fmt.Sprintf("/v2/users/id/%d", user.ID)
+	etag = do(http.MethodGet, target, "", nil).Header().Get("ETag")
+
+	rec = do(http.MethodPut, target, `{"email":"stale@example.com"}`, http.Header{"If-Match": {`"stale"`}})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	rec = do(http.MethodPut, target, `{"email":"changed@example.com"}`, http.Header{"If-Match": {etag}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"email":"changed@example.com"`)
+	rec = do(http.MethodPut, target, `{"email":"again@example.com"}`, http.Header{"If-Match": {etag}})
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	// Without If-Match the email is simply changed
+	rec = do(http.MethodPut, target, `{"email":"unconditional@example.com"}`, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	rec = do(http.MethodPut, "/v2/users/id/999999", `{"email":"missing@example.com"}`, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCompression(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	dbService := setupTestDb()
+	s := &Server{db: dbService}
+	e := s.RegisterRoutes()
+
+	get := func(target, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if acceptEncoding != "" {
+			req.Header.Set(echo.HeaderAcceptEncoding, acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	decodeArray := func(r io.Reader) []map[string]any {
+		var items []map[string]any
+		assert.NoError(t, json.NewDecoder(r).Decode(&items))
+		return items
+	}
+
+	t.Run("Gzip", func(t *testing.T) {
+		rec := get("/logs", "gzip")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+		assert.Contains(t, rec.Header().Values(echo.HeaderVary), echo.HeaderAcceptEncoding)
+
+		r, err := gzip.NewReader(rec.Body)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, decodeArray(r))
+	})
+
+	t.Run("Brotli preferred", func(t *testing.T) {
+		rec := get("/logs", "gzip, deflate, br")
+		assert.Equal(t, "br", rec.Header().Get(echo.HeaderContentEncoding))
+		assert.NotEmpty(t, decodeArray(brotli.NewReader(rec.Body)))
+
+		rec = get("/logs", "br;q=0.5, gzip")
+		assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+	})
+
+	t.Run("Small responses are not compressed", func(t *testing.T) {
+		rec := get("/health/live", "gzip")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+	})
+
+	t.Run("Streamed users", func(t *testing.T) {
+		rec := get("/users", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+
+		count, err := dbService.GetRepositoryRO().UsersCount(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, decodeArray(rec.Body), int(count))
+	})
+}
+
+func TestAPIVersioning(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	t.Setenv("API_V1_DEPRECATED", "2025-01-01")
+
+	get := func(e http.Handler, target, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	t.Run("Version in path", func(t *testing.T) {
+		rec := get(e, "/v2/users/id/1", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "v2", rec.Header().Get("X-API-Version"))
+		assert.Empty(t, rec.Header().Get("Deprecation"))
+	})
+
+	t.Run("Unversioned paths default to v1", func(t *testing.T) {
+		rec := get(e, "/users/id/1", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "v1", rec.Header().Get("X-API-Version"))
+		assert.Contains(t, rec.Header().Values(echo.HeaderVary), "Accept")
+	})
+
+	t.Run("Version in Accept", func(t *testing.T) {
+		rec := get(e, "/users/id/1", "application/vnd.backendt.v2+json")
+		assert.Equal(t, "v2", rec.Header().Get("X-API-Version"))
+
+		rec = get(e, "/users/id/1", "application/json; version=2")
+		assert.Equal(t, "v2", rec.Header().Get("X-API-Version"))
+
+		rec = get(e, "/users/id/1", "application/vnd.backendt.v9+json")
+		assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	})
+
+	t.Run("Deprecated version", func(t *testing.T) {
+		rec := get(e, "/v1/users/id/1", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "@1735689600", rec.Header().Get("Deprecation"))
+		assert.Equal(t, `</v2/users/id/1>; rel="successor-version"`, rec.Header().Get("Link"))
+	})
+
+	t.Run("Sunset version", func(t *testing.T) {
+		t.Setenv("API_V1_SUNSET", "2025-06-30")
+		e := (&Server{db: setupTestDb()}).RegisterRoutes()
+
+		rec := get(e, "/users/id/1", "")
+		assert.Equal(t, http.StatusGone, rec.Code)
+		assert.Equal(t, "Mon, 30 Jun 2025 00:00:00 GMT", rec.Header().Get("Sunset"))
+		assert.Equal(t, http.StatusOK, get(e, "/v2/users/id/1", "").Code)
+		assert.Equal(t, http.StatusOK, get(e, "/health/live", "").Code)
+	})
+}
+
+func TestAPIModels(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	get := func(target string) map[string]any {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+		return body
+	}
+
+	t.Run("v2 serves plain values", func(t *testing.T) {
+		user := get("/v2/users/id/1")
+		createdAt, ok := user["created_at"].(string)
+		assert.True(t, ok, "created_at should be a string")
+		_, err := time.Parse(time.RFC3339, createdAt)
+		assert.NoError(t, err)
+
+		log := get("/v2/logs/1")
+		_, ok = log["status"].(float64)
+		assert.True(t, ok, "status should be a number")
+		// Payloads are only served to admins
+		assert.NotContains(t, log, "payload")
+	})
+
+	t.Run("v1 keeps the rows", func(t *testing.T) {
+		user := get("/v1/users/id/1")
+		assert.Equal(t, true, user["created_at"].(map[string]any)["Valid"])
+
+		log := get("/v1/logs/1")
+		assert.Equal(t, true, log["status"].(map[string]any)["Valid"])
+	})
+}
+
+func TestUserProfiles(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	t.Setenv("AVATAR_DIR", t.TempDir())
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	do := func(method, target string, body io.Reader, contentType string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, body)
+		if contentType != "" {
+			req.Header.Set(echo.HeaderContentType, contentType)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	upload := func(target string, data []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		part, err := w.CreateFormFile("avatar", "avatar.png")
+		assert.NoError(t, err)
+		part.Write(data)
+		w.Close()
+		return do(http.MethodPut, target, &body, w.FormDataContentType())
+	}
+
+	rec := do(http.MethodPost, "/v2/users", strings.NewReader(`{"username":"profile","email":"profile@example.com"}`), echo.MIMEApplicationJSON)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var user map[string]any
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&user))
+	id := fmt.Sprint(user["id"])
+	assert.Nil(t, user["display_name"])
+	assert.Nil(t, user["avatar_url"])
+
+	rec = do(http.MethodPut, "/v2/users/id/"+id+"/profile", strings.NewReader(`{"display_name":"Pro File","bio":"Hi"}`), echo.MIMEApplicationJSON)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"display_name":"Pro File"`)
+
+	rec = do(http.MethodPut, "/v2/users/id/"+id+"/profile", strings.NewReader(`{"bio":"`+strings.Repeat("a", 501)+`"}`), echo.MIMEApplicationJSON)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	var pngData bytes.Buffer
+	assert.NoError(t, png.Encode(&pngData, img))
+
+	rec = upload("/v2/users/id/"+id+"/avatar", []byte("not an image"))
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	rec = upload("/v2/users/id/"+id+"/avatar", bytes.Repeat([]byte{0}, 3<<20))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	rec = upload("/v2/users/id/999999/avatar", pngData.Bytes())
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = upload("/v2/users/id/"+id+"/avatar", pngData.Bytes())
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&user))
+	avatarURL, thumbnailURL := user["avatar_url"].(string), user["avatar_thumbnail_url"].(string)
+	assert.True(t, strings.HasPrefix(avatarURL, "/avatars/"))
+
+	rec = do(http.MethodGet, avatarURL, nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, pngData.Bytes(), rec.Body.Bytes())
+	assert.Contains(t, rec.Header().Get(echo.HeaderCacheControl), "immutable")
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, thumbnailURL, nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/avatars/missing.png", nil, "").Code)
+
+	// A new avatar replaces the files of the previous one
+	rec = upload("/v2/users/id/"+id+"/avatar", pngData.Bytes())
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, avatarURL, nil, "").Code)
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&user))
+	avatarURL = user["avatar_url"].(string)
+
+	rec = do(http.MethodDelete, "/v2/users/id/"+id, nil, "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, avatarURL, nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v2/users/id/"+id, nil, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/v2/users/id/"+id, nil, "").Code)
+}
+
+func TestPostStatus(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	do := func(method, target, body, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if userID != "" {
+			req.Header.Set("X-User-ID", userID)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	decode := func(rec *httptest.ResponseRecorder) map[string]any {
+		var post map[string]any
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&post))
+		return post
+	}
+
+	rec := do(http.MethodPost, "/v2/users", `{"username":"author","email":"author@example.com"}`, "")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	author := fmt.Sprint(decode(rec)["id"])
+
+	// The list is cached per viewer, load it before the draft exists
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/v2/posts", "", "").Code)
+
+	rec = do(http.MethodPost, "/v2/posts", `{"user_id":`+author+`,"title":"Secret","content":"Draft","status":"draft"}`, "")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	draft := decode(rec)
+	id := fmt.Sprint(draft["id"])
+	assert.Equal(t, "draft", draft["status"])
+	assert.Nil(t, draft["published_at"])
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v2/posts/id/"+id, "", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v2/posts/id/"+id, "", "1").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/v2/posts/id/"+id, "", author).Code)
+	assert.NotContains(t, do(http.MethodGet, "/v2/posts", "", "").Body.String(), `"Secret"`)
+	rec = do(http.MethodGet, "/v2/posts", "", author)
+	assert.Contains(t, rec.Body.String(), `"Secret"`)
+	assert.Contains(t, rec.Header().Values(echo.HeaderVary), "X-User-ID")
+	assert.NotContains(t, do(http.MethodGet, "/v2/posts/userid/"+author, "", "").Body.String(), `"Secret"`)
+	assert.Contains(t, do(http.MethodGet, "/v2/posts/userid/"+author, "", author).Body.String(), `"Secret"`)
+
+	// Only the author changes the status
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/v2/posts/id/"+id+"/publish", "", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/v2/posts/id/"+id+"/publish", "", "1").Code)
+
+	// Scheduling needs a time in the future
+	rec = do(http.MethodPost, "/v2/posts", `{"user_id":`+author+`,"title":"Late","content":"x","status":"scheduled"}`, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = do(http.MethodPost, "/v2/posts", `{"user_id":`+author+`,"title":"Bad","content":"x","status":"archived"}`, "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	publishAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	rec = do(http.MethodPost, "/v2/posts/id/"+id+"/publish", `{"publish_at":"`+publishAt.Format(time.RFC3339)+`"}`, author)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	post := decode(rec)
+	assert.Equal(t, "scheduled", post["status"])
+	assert.Equal(t, publishAt.Format(time.RFC3339), post["published_at"])
+
+	published, err := s.publishDuePosts(context.Background(), time.Now())
+	assert.NoError(t, err)
+	assert.Zero(t, published)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v2/posts/id/"+id, "", "").Code)
+	assert.NotContains(t, do(http.MethodGet, "/v2/posts", "", "").Body.String(), `"Secret"`)
+
+	published, err = s.publishDuePosts(context.Background(), publishAt.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	// Its outbox event drops the cached responses still leaving it out
+	_, err = s.events().DispatchDue(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	rec = do(http.MethodGet, "/v2/posts/id/"+id, "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "published", decode(rec)["status"])
+	assert.Contains(t, do(http.MethodGet, "/v2/posts", "", "").Body.String(), `"Secret"`)
+
+	// Other users may see the post now, but still not change it
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, "/v2/posts/id/"+id+"/unpublish", "", "1").Code)
+
+	rec = do(http.MethodPost, "/v2/posts/id/"+id+"/unpublish", "", author)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	post = decode(rec)
+	assert.Equal(t, "draft", post["status"])
+	assert.Nil(t, post["published_at"])
+	assert.NotContains(t, do(http.MethodGet, "/v2/posts", "", "").Body.String(), `"Secret"`)
+
+	rec = do(http.MethodPost, "/v2/posts/id/"+id+"/publish", "", author)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "published", decode(rec)["status"])
+
+	rec = do(http.MethodPost, "/v2/posts/id/"+id+"/archive", "", author)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	post = decode(rec)
+	assert.Equal(t, "archived", post["status"])
+	assert.NotNil(t, post["published_at"])
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v2/posts/id/"+id, "", "").Code)
+}
+
+func TestPostRevisions(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	do := func(method, target, body, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/v2/posts", `{"user_id":1,"title":"Revised","content":"one\ntwo\nthree"}`, "")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var post map[string]any
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&post))
+	base := fmt.Sprintf("/v2/posts/id/%v", post["id"])
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, base, `{"content":"one\n2\nthree"}`, "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, base, `{"title":"Revised again"}`, "").Code)
+
+	rec = do(http.MethodGet, base+"/revisions", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var revisions []map[string]any
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&revisions))
+	if assert.Len(t, revisions, 3) {
+		assert.EqualValues(t, 3, revisions[2]["revision"])
+		assert.Equal(t, "Revised again", revisions[2]["title"])
+	}
+
+	rec = do(http.MethodGet, base+"/revisions/1", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"content":"one\ntwo\nthree"`)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, base+"/revisions/9", "", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, base+"/revisions/first", "", "").Code)
+
+	rec = do(http.MethodGet, base+"/revisions/diff?from=1&to=2", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var diff struct {
+		From, To int64
+		Mode     string
+		Title    []map[string]string
+		Content  []map[string]string
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&diff))
+	assert.Equal(t, "line", diff.Mode)
+	assert.Equal(t, []map[string]string{{"op": "equal", "text": "Revised"}}, diff.Title)
+	assert.Equal(t, []map[string]string{
+		{"op": "equal", "text": "one\n"},
+		{"op": "delete", "text": "two\n"},
+		{"op": "insert", "text": "2\n"},
+		{"op": "equal", "text": "three"},
+	}, diff.Content)
+
+	// Defaults to the latest revision against the one before
+	rec = do(http.MethodGet, base+"/revisions/diff?mode=word", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&diff))
+	assert.Equal(t, int64(2), diff.From)
+	assert.Equal(t, int64(3), diff.To)
+	assert.Equal(t, []map[string]string{{"op": "equal", "text": "Revised"}, {"op": "insert", "text": " again"}}, diff.Title)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, base+"/revisions/diff?mode=char", "", "").Code)
+
+	assert.Equal(t, http.StatusPreconditionFailed, do(http.MethodPost, base+"/revisions/1/restore", "", `"stale"`).Code)
+	rec = do(http.MethodPost, base+"/revisions/1/restore", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&post))
+	assert.Equal(t, "Revised", post["title"])
+	assert.Equal(t, "one\ntwo\nthree", post["content"])
+
+	// The restore is a revision of its own, and the cached list knows about it
+	rec = do(http.MethodGet, base+"/revisions", "", "")
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&revisions))
+	assert.Len(t, revisions, 4)
+}
+
+func TestPostTags(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+	titles := func(rec *httptest.ResponseRecorder) []string {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var posts []map[string]any
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&posts))
+		titles := []string{}
+		for _, p := range posts {
+			titles = append(titles, p["title"].(string))
+		}
+		return titles
+	}
+
+	var ids []string
+	for _, title := range []string{"Tagged both", "Tagged one"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v2/posts", strings.NewReader(`{"user_id":1,"title":"`+title+`","content":"x"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		e.ServeHTTP(rec, req)
+		var post map[string]any
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&post))
+		ids = append(ids, fmt.Sprint(post["id"]))
+	}
+
+	// Loaded before the tags exist, the cache has to drop it
+	assert.Empty(t, titles(do(http.MethodGet, "/v2/posts?tags=route-a")))
+
+	rec := do(http.MethodPut, "/v2/posts/id/"+ids[0]+"/tags/Route-A")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name":"route-a"`)
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, "/v2/posts/id/"+ids[0]+"/tags/route-a").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, "/v2/posts/id/"+ids[0]+"/tags/route-b").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, "/v2/posts/id/"+ids[1]+"/tags/route-a").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/v2/posts/id/"+ids[1]+"/tags/no_underscores").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/v2/posts/id/999999/tags/route-a").Code)
+
+	rec = do(http.MethodGet, "/v2/posts/id/"+ids[0]+"/tags")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var tags []map[string]any
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&tags))
+	assert.Len(t, tags, 2)
+
+	assert.Equal(t, []string{"Tagged both", "Tagged one"}, titles(do(http.MethodGet, "/v2/posts?tags=route-a")))
+	assert.Equal(t, []string{"Tagged both", "Tagged one"}, titles(do(http.MethodGet, "/v2/posts?tags=route-a,route-b&match=any")))
+	assert.Equal(t, []string{"Tagged both"}, titles(do(http.MethodGet, "/v2/posts?tags=route-a,route-b,route-a&match=all")))
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v2/posts?tags=route-a&match=most").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v2/posts?tags=a%25").Code)
+
+	rec = do(http.MethodGet, "/v2/tags/autocomplete?q=route")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"id":`+fmt.Sprint(tags[0]["id"])+`,"name":"route-a","post_count":2},{"id":`+fmt.Sprint(tags[1]["id"])+`,"name":"route-b","post_count":1}]`, rec.Body.String())
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v2/tags/autocomplete").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v2/tags?limit=0").Code)
+	rec = do(http.MethodGet, "/v2/tags?limit=100")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"name":"route-a","post_count":2`)
+
+	rec = do(http.MethodDelete, "/v2/posts/id/"+ids[0]+"/tags/route-b")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "route-b")
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/v2/posts/id/"+ids[0]+"/tags/route-b").Code)
+	assert.Empty(t, titles(do(http.MethodGet, "/v2/posts?tags=route-b")))
+
+	// Concurrent adds can't go past MaxPostTags
+	var wg sync.WaitGroup
+	for i := range 15 {
+		wg.Go(func() {
+			do(http.MethodPut, "/v2/posts/id/"+ids[1]+"/tags/burst-"+strconv.Itoa(i))
+		})
+	}
+	wg.Wait()
+	rec = do(http.MethodGet, "/v2/posts/id/"+ids[1]+"/tags")
+	tags = nil
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&tags))
+	assert.Len(t, tags, posts.MaxPostTags)
+}
+
+func TestPostReactions(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	do := func(method, target, userID string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, nil)
+		if userID != "" {
+			req.Header.Set("X-User-ID", userID)
+		}
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v2/posts", strings.NewReader(`{"user_id":1,"title":"Reacted","content":"x"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	e.ServeHTTP(rec, req)
+	var post map[string]any
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&post))
+	id := fmt.Sprint(post["id"])
+	assert.Equal(t, map[string]any{}, post["reactions"])
+
+	// Loaded before the reactions exist, the cache has to drop it
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/v2/posts/id/"+id, "").Code)
+
+	rec = do(http.MethodPut, "/v2/posts/id/"+id+"/reactions/like", "1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"type":"like","count":1,"mine":true}]`, rec.Body.String())
+	rec = do(http.MethodPut, "/v2/posts/id/"+id+"/reactions/like", "1")
+	assert.JSONEq(t, `[{"type":"like","count":1,"mine":true}]`, rec.Body.String())
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, "/v2/posts/id/"+id+"/reactions/wow", "2").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/v2/posts/id/"+id+"/reactions/meh", "1").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPut, "/v2/posts/id/"+id+"/reactions/like", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPut, "/v2/posts/id/"+id+"/reactions/like", "999999").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/v2/posts/id/999999/reactions/like", "1").Code)
+
+	rec = do(http.MethodGet, "/v2/posts/id/"+id+"/reactions", "2")
+	assert.JSONEq(t, `[{"type":"like","count":1,"mine":false},{"type":"wow","count":1,"mine":true}]`, rec.Body.String())
+	rec = do(http.MethodGet, "/v2/posts/id/"+id, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"reactions":{"like":1,"wow":1}`)
+
+	rec = do(http.MethodDelete, "/v2/posts/id/"+id+"/reactions/wow", "2")
+	assert.JSONEq(t, `[{"type":"like","count":1,"mine":false}]`, rec.Body.String())
+	assert.Equal(t, http.StatusOK, do(http.MethodDelete, "/v2/posts/id/"+id+"/reactions/wow", "2").Code)
+
+	rec = do(http.MethodGet, "/v2/posts/trending?window=1h&limit=100", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("X-Cache"), "the scores decay with time, trending isn't cached")
+	var trending []map[string]any
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&trending))
+	var found map[string]any
+	for i, p := range trending {
+		if i > 0 {
+			assert.LessOrEqual(t, p["score"], trending[i-1]["score"])
+		}
+		if p["id"] == post["id"] {
+			found = p
+		}
+	}
+	if assert.NotNil(t, found) {
+		assert.Equal(t, float64(1), found["reaction_count"])
+		assert.Equal(t, map[string]any{"like": float64(1)}, found["reactions"])
+	}
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v2/posts/trending?window=yesterday", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v2/posts/trending?window=1000h", "").Code)
+
+	rec = do(http.MethodPut, "/v2/comments/id/1/reactions/love", "1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `{"type":"love","count":1,"mine":true}`)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v2/comments/id/999999/reactions", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v2/comments/id/first/reactions", "").Code)
+}
+
+func TestFollowsAndFeed(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	do := func(method, target, userID string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, nil)
+		if userID != "" {
+			req.Header.Set("X-User-ID", userID)
+		}
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	create := func(path, body string) string {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		e.ServeHTTP(rec, req)
+		var created map[string]any
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+		return fmt.Sprint(created["id"])
+	}
+	page := func(rec *httptest.ResponseRecorder, field string) (values []string, next string) {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var items []map[string]any
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&items))
+		for _, item := range items {
+			values = append(values, fmt.Sprint(item[field]))
+		}
+		if link := rec.Header().Get("Link"); link != "" {
+			next = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		}
+		return values, next
+	}
+
+	reader := create("/v2/users", `{"username":"feed_reader","email":"feed_reader@test.com"}`)
+	var authors []string
+	for _, name := range []string{"feed_a", "feed_b", "feed_c"} {
+		authors = append(authors, create("/v2/users", `{"username":"`+name+`","email":"`+name+`@test.com"}`))
+	}
+
+	// Loaded before the follows exist, the cache has to drop it
+	feed, _ := page(do(http.MethodGet, "/v2/feed", reader), "title")
+	assert.Empty(t, feed)
+
+	for _, author := range authors[:2] {
+		assert.Equal(t, http.StatusNoContent, do(http.MethodPut, "/v2/users/id/"+author+"/follow", reader).Code)
+	}
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPut, "/v2/users/id/"+authors[0]+"/follow", reader).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/v2/users/id/"+reader+"/follow", reader).Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPut, "/v2/users/id/"+authors[0]+"/follow", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/v2/users/id/999999/follow", reader).Code)
+
+	following, next := page(do(http.MethodGet, "/v2/users/id/"+reader+"/following?limit=1", ""), "username")
+	assert.Equal(t, []string{"feed_a"}, following)
+	following, next = page(do(http.MethodGet, next, ""), "username")
+	assert.Equal(t, []string{"feed_b"}, following)
+	assert.Empty(t, next)
+	followers, _ := page(do(http.MethodGet, "/v2/users/id/"+authors[0]+"/followers", ""), "username")
+	assert.Equal(t, []string{"feed_reader"}, followers)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v2/users/id/"+reader+"/following?cursor=nope", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v2/users/id/999999/followers", "").Code)
+
+	for i, author := range []string{authors[0], authors[1], authors[2], authors[0]} {
+		create("/v2/posts", fmt.Sprintf(`{"user_id":%s,"title":"Post %d","content":"x"}`, author, i))
+	}
+	create("/v2/posts", `{"user_id":`+authors[1]+`,"title":"Draft","content":"x","status":"draft"}`)
+
+	// Published within the same second, the newest id comes first
+	feed, next = page(do(http.MethodGet, "/v2/feed?limit=2", reader), "title")
+	assert.Equal(t, []string{"Post 3", "Post 1"}, feed)
+	feed, next = page(do(http.MethodGet, next, reader), "title")
+	assert.Equal(t, []string{"Post 0"}, feed)
+	assert.Empty(t, next)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/v2/feed", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v2/feed?cursor=nope", reader).Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v2/users/id/"+authors[0]+"/follow", reader).Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v2/users/id/"+authors[0]+"/follow", reader).Code)
+	feed, _ = page(do(http.MethodGet, "/v2/feed", reader), "title")
+	assert.Equal(t, []string{"Post 1"}, feed)
+}
+
+func TestCommentThreads(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	t.Setenv("ADMIN_TOKEN", "admin")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	do := func(method, target, userID, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if userID != "" {
+			req.Header.Set("X-User-ID", userID)
+		}
+		if strings.HasPrefix(target, "/admin") {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer admin")
+		}
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	id := func(rec *httptest.ResponseRecorder) string {
+		assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var created map[string]any
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+		return fmt.Sprint(created["id"])
+	}
+
+	post := id(do(http.MethodPost, "/v2/posts", "", `{"user_id":1,"title":"Threads","content":"x"}`))
+	comments := "/v2/posts/id/" + post + "/comments"
+	root := id(do(http.MethodPost, comments, "1", `{"comment":"Root"}`))
+	reply := id(do(http.MethodPost, comments, "2", `{"comment":"Reply","parent_id":`+root+`}`))
+	id(do(http.MethodPost, comments, "1", `{"comment":"Other"}`))
+
+	// Loaded before the hidden comment is hidden, the cache has to drop it
+	rec := do(http.MethodGet, comments, "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var tree []map[string]any
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&tree))
+	if assert.Len(t, tree, 2) {
+		assert.Equal(t, "Root", tree[0]["comment"])
+		replies := tree[0]["replies"].([]any)
+		if assert.Len(t, replies, 1) {
+			assert.Equal(t, "Reply", replies[0].(map[string]any)["comment"])
+		}
+	}
+
+	// Replies nest MaxCommentDepth levels deep
+	parent := reply
+	for depth := 2; depth <= 5; depth++ {
+		parent = id(do(http.MethodPost, comments, "1", `{"comment":"Deeper","parent_id":`+parent+`}`))
+	}
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, comments, "1", `{"comment":"Too deep","parent_id":`+parent+`}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, comments, "1", `{"comment":"Lost","parent_id":999999}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, comments, "1", `{"comment":" "}`).Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, comments, "", `{"comment":"Anonymous"}`).Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/v2/comments/id/"+root+"/report", "2", `{"reason":"spam"}`).Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/v2/comments/id/"+root+"/report", "2", `{"reason":"spam again"}`).Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/v2/comments/id/"+root+"/report", "3", `{"reason":"rude"}`).Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/v2/comments/id/"+reply+"/report", "3", `{"reason":"off topic"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v2/comments/id/"+root+"/report", "3", `{}`).Code)
+
+	rec = do(http.MethodGet, "/admin/comments/reports", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var queue []map[string]any
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&queue))
+	if assert.Len(t, queue, 2) {
+		assert.Equal(t, root, fmt.Sprint(queue[0]["id"]))
+		assert.Equal(t, float64(2), queue[0]["report_count"])
+		assert.ElementsMatch(t, []any{"spam", "rude"}, queue[0]["reasons"])
+	}
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/comments/reports", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Hiding the root takes its whole thread out and settles its reports
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/admin/comments/"+root+"/hide", "", "").Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/admin/comments/"+reply+"/dismiss", "", "").Code)
+	assert.Equal(t, "[]\n", do(http.MethodGet, "/admin/comments/reports", "", "").Body.String())
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/admin/comments/999999/hide", "", "").Code)
+
+	rec = do(http.MethodGet, comments, "", "")
+	assert.NotContains(t, rec.Body.String(), "Root")
+	assert.NotContains(t, rec.Body.String(), "Reply")
+	assert.Contains(t, rec.Body.String(), `"comment":"Other"`)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v2/comments/id/"+root+"/reactions", "", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/v2/comments/id/"+root+"/report", "3", `{"reason":"spam"}`).Code)
+	// Nothing can be added anywhere under the hidden root
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, comments, "1", `{"comment":"Hidden","parent_id":`+root+`}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, comments, "1", `{"comment":"Hidden too","parent_id":`+reply+`}`).Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/admin/comments/"+root+"/unhide", "", "").Code)
+	assert.Contains(t, do(http.MethodGet, comments, "", "").Body.String(), `"comment":"Reply"`)
+	id(do(http.MethodPost, comments, "1", `{"comment":"Visible again","parent_id":`+reply+`}`))
+}
+
+func TestNotifications(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	do := func(method, target, userID, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if userID != "" {
+			req.Header.Set("X-User-ID", userID)
+		}
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	create := func(target, userID, body string) string {
+		rec := do(http.MethodPost, target, userID, body)
+		assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+		var created map[string]any
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+		return fmt.Sprint(created["id"])
+	}
+	list := func(target, userID string) (notifications []map[string]any, next string) {
+		rec := do(http.MethodGet, target, userID, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.NewDecoder(rec.Body).Decode(&notifications))
+		if link := rec.Header().Get("Link"); link != "" {
+			next = strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		}
+		return notifications, next
+	}
+	// Notifications are sent once the outbox events of the writes are dispatched
+	dispatch := func() {
+		_, err := s.events().DispatchDue(context.Background(), time.Now().Add(time.Second))
+		assert.NoError(t, err)
+	}
+
+	author := create("/v2/users", "", `{"username":"notified","email":"notified@test.com"}`)
+	actor := create("/v2/users", "", `{"username":"notifier","email":"notifier@test.com"}`)
+
+	// Follow the notifications live
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/v2/notifications/stream", nil)
+	req.Header.Set("X-User-ID", author)
+	resp, err := http.DefaultClient.Do(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get(echo.HeaderContentType))
+	events := bufio.NewReader(resp.Body)
+	nextEvent := func() (lines []string) {
+		for {
+			line, err := events.ReadString('\n')
+			if err != nil || line == "\n" {
+				return lines
+			}
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+	assert.Equal(t, []string{": connected"}, nextEvent())
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPut, "/v2/users/id/"+author+"/follow", actor, "").Code)
+	dispatch()
+	if event := nextEvent(); assert.Len(t, event, 3) {
+		assert.Equal(t, "event: notification", event[1])
+		var notification map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(event[2], "data: ")), &notification))
+		assert.Equal(t, "follow", notification["type"])
+		assert.Equal(t, actor, fmt.Sprint(notification["actor_id"]))
+		assert.Equal(t, "id: "+fmt.Sprint(notification["id"]), event[0])
+	}
+
+	// Following again, reacting twice and acting on your own things notify nobody
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPut, "/v2/users/id/"+author+"/follow", actor, "").Code)
+	post := create("/v2/posts", "", `{"user_id":`+author+`,"title":"Notify me","content":"x"}`)
+	create("/v2/posts/id/"+post+"/comments", actor, `{"comment":"First"}`)
+	root := create("/v2/posts/id/"+post+"/comments", author, `{"comment":"Mine"}`)
+	reply := create("/v2/posts/id/"+post+"/comments", actor, `{"comment":"Reply","parent_id":`+root+`}`)
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, "/v2/posts/id/"+post+"/reactions/like", author, "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, "/v2/posts/id/"+post+"/reactions/like", actor, "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodPut, "/v2/posts/id/"+post+"/reactions/love", actor, "").Code)
+	dispatch()
+
+	notifications, next := list("/v2/notifications", author)
+	var types []string
+	for _, n := range notifications {
+		types = append(types, fmt.Sprint(n["type"]))
+	}
+	assert.Equal(t, []string{"reaction", "reply", "comment", "follow"}, types)
+	assert.Empty(t, next)
+	assert.Equal(t, reply, fmt.Sprint(notifications[1]["comment_id"]))
+	assert.Nil(t, notifications[1]["read_at"])
+	none, _ := list("/v2/notifications", actor)
+	assert.Empty(t, none)
+
+	page, next := list("/v2/notifications?limit=3", author)
+	assert.Len(t, page, 3)
+	page, next = list(next, author)
+	if assert.Len(t, page, 1) {
+		assert.Equal(t, "follow", page[0]["type"])
+	}
+	assert.Empty(t, next)
+
+	rec := do(http.MethodGet, "/v2/notifications/unread", author, "")
+	assert.JSONEq(t, `{"total":4,"by_type":{"comment":1,"reply":1,"follow":1,"reaction":1}}`, rec.Body.String())
+
+	// Users only read their own notifications
+	first := fmt.Sprint(notifications[0]["id"])
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/v2/notifications/id/"+first+"/read", actor, "").Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/v2/notifications/id/"+first+"/read", author, "").Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/v2/notifications/id/"+first+"/read", author, "").Code)
+	unread, _ := list("/v2/notifications?unread=true", author)
+	assert.Len(t, unread, 3)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/v2/notifications/read", author, "").Code)
+	rec = do(http.MethodGet, "/v2/notifications/unread", author, "")
+	assert.JSONEq(t, `{"total":0,"by_type":{"comment":0,"reply":0,"follow":0,"reaction":0}}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/v2/notifications", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/v2/notifications", "999999", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/v2/notifications?unread=maybe", author, "").Code)
+
+	// Shutting down ends the stream
+	s.notifications().Close()
+	_, err = io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+}
+
+func TestWebhooks(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	t.Setenv("ADMIN_TOKEN", "admin")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	received := make(chan delivery, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{header: r.Header, body: body}
+	}))
+	defer receiver.Close()
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if strings.HasPrefix(target, "/admin") {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer admin")
+		}
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/webhooks", `{"url":"ftp://example.com","events":["post.created"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/webhooks", `{"url":"https://example.com","events":["post.liked"]}`).Code)
+
+	rec := do(http.MethodPost, "/admin/webhooks", `{"url":"`+receiver.URL+`","events":["post.created"]}`)
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var created api.Webhook
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+	assert.True(t, strings.HasPrefix(created.Secret, "whsec_"))
+	assert.Equal(t, []string{"post.created"}, created.Events)
+	hook := fmt.Sprint(created.ID)
+	assert.NotContains(t, do(http.MethodGet, "/admin/webhooks", "").Body.String(), created.Secret)
+
+	// Only the subscribed event is queued
+	rec = do(http.MethodPost, "/v2/users", `{"username":"webhooks_user","email":"webhooks_user@test.com"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec = do(http.MethodPost, "/v2/posts", `{"user_id":1,"title":"Hooked","content":"x"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	_, err := s.events().DispatchDue(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+
+	deliverer := webhook.NewDeliverer(s.db.GetRepositoryRW(), webhook.Config{MaxAttempts: 3, RetryBase: time.Minute, Timeout: time.Second})
+	attempted, err := deliverer.DeliverDue(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	var eventID string
+	select {
+	case d := <-received:
+		assert.Equal(t, webhook.EventPostCreated, d.header.Get(webhook.HeaderEvent))
+		eventID = d.header.Get(webhook.HeaderEventID)
+		assert.NotEmpty(t, eventID)
+		timestamp, err := strconv.ParseInt(d.header.Get(webhook.HeaderTimestamp), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, webhook.Sign(created.Secret, timestamp, d.body), d.header.Get(webhook.HeaderSignature))
+		var payload map[string]any
+		assert.NoError(t, json.Unmarshal(d.body, &payload))
+		assert.Equal(t, "post.created", payload["event"])
+		assert.Equal(t, "Hooked", payload["data"].(map[string]any)["title"])
+	default:
+		t.Fatal("nothing delivered")
+	}
+
+	rec = do(http.MethodGet, "/admin/webhooks/"+hook+"/deliveries", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var deliveries []api.WebhookDelivery
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&deliveries))
+	if assert.Len(t, deliveries, 1) {
+		assert.Equal(t, webhook.StatusSucceeded, deliveries[0].Status)
+		assert.Equal(t, int64(1), deliveries[0].Attempts)
+		assert.Nil(t, deliveries[0].NextAttemptAt)
+		if assert.NotNil(t, deliveries[0].EventID) {
+			assert.Equal(t, eventID, strconv.FormatInt(*deliveries[0].EventID, 10))
+		}
+
+		rec = do(http.MethodPost, fmt.Sprintf("/admin/webhooks/deliveries/%d/redeliver", deliveries[0].ID), "")
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"status":"pending"`)
+		assert.Contains(t, rec.Body.String(), `"event_id":`+eventID+`,`)
+		assert.Contains(t, rec.Body.String(), fmt.Sprintf(`"redelivery_of":%d`, deliveries[0].ID))
+	}
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/admin/webhooks/deliveries/999999/redeliver", "").Code)
+
+	// Paused webhooks keep their queue until they are active again
+	rec = do(http.MethodPut, "/admin/webhooks/"+hook, `{"active":false}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"active":false`)
+	attempted, err = deliverer.DeliverDue(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Zero(t, attempted)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/admin/webhooks/"+hook, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/admin/webhooks/"+hook, "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/admin/webhooks/"+hook+"/deliveries", "").Code)
+}
+
+func TestOutboxDeadLetters(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	t.Setenv("ADMIN_TOKEN", "admin")
+	t.Setenv("OUTBOX_MAX_ATTEMPTS", "1")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+	s.events().Subscribe("failing", func(ctx context.Context, event outbox.Event) error {
+		if bytes.Contains(event.Payload, []byte("Undeliverable")) {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v2/posts", strings.NewReader(`{"user_id":1,"title":"Undeliverable","content":"x"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	var post api.Post
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&post))
+	_, err := s.events().DispatchDue(context.Background(), time.Now().Add(time.Second))
+	assert.NoError(t, err)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/outbox/dead-letters", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/admin/outbox/dead-letters?limit=1", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer admin")
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var deadLetters []api.DeadLetter
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&deadLetters))
+	if assert.Len(t, deadLetters, 1) {
+		assert.Equal(t, "failing", deadLetters[0].Subscriber)
+		assert.Equal(t, outbox.EventPostCreated, deadLetters[0].Type)
+		assert.Equal(t, post.ID, deadLetters[0].AggregateID)
+		assert.Equal(t, "boom", deadLetters[0].Error)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	t.Setenv("ADMIN_TOKEN", "admin")
+	s := &Server{db: setupTestDb()}
+	e := s.RegisterRoutes()
+	ctx := context.Background()
+	repo := s.db.GetRepositoryRW()
+
+	do := func(method, target string) (int, []api.Job, string) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer admin")
+		e.ServeHTTP(rec, req)
+		var body []api.Job
+		if strings.HasPrefix(rec.Body.String(), "[") {
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		} else if rec.Code < 300 {
+			var job api.Job
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+			body = []api.Job{job}
+		}
+		return rec.Code, body, rec.Body.String()
+	}
+
+	// The recurring jobs run once due
+	queue := s.jobQueue()
+	now := time.Now().UTC()
+	assert.NoError(t, queue.EnqueueScheduled(ctx, now))
+	next := now.Truncate(time.Hour).Add(time.Hour)
+	ran, err := queue.RunDue(ctx, next.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, ran)
+	code, list, _ := do(http.MethodGet, "/admin/jobs?kind=outbox.cleanup")
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, jobs.StatusSucceeded, list[0].Status)
+		assert.Equal(t, next, list[0].RunAt.UTC())
+		assert.NotNil(t, list[0].FinishedAt)
+	}
+
+	queue.Handle("test.failing", func(ctx context.Context, job jobs.Job) error {
+		return errors.New("boom")
+	})
+	failing, _, err := jobs.Enqueue(ctx, repo, "test.failing", nil, jobs.Options{MaxAttempts: 1})
+	assert.NoError(t, err)
+	later, _, err := jobs.Enqueue(ctx, repo, "test.later", map[string]int{"n": 1}, jobs.Options{RunAt: next.Add(time.Hour), UniqueKey: "test.later"})
+	assert.NoError(t, err)
+	ran, err = queue.RunDue(ctx, next.Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ran)
+
+	code, _, _ = do(http.MethodGet, "/admin/jobs?status=lost")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, list, _ = do(http.MethodGet, "/admin/jobs?status=failed&limit=1")
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, failing, list[0].ID)
+		assert.Equal(t, "boom", *list[0].LastError)
+		assert.Equal(t, int64(1), list[0].Attempts)
+	}
+
+	// Retried from scratch, once
+	code, list, _ = do(http.MethodPost, fmt.Sprintf("/admin/jobs/%d/retry", failing))
+	assert.Equal(t, http.StatusAccepted, code)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, jobs.StatusPending, list[0].Status)
+		assert.Zero(t, list[0].Attempts)
+	}
+	code, _, body := do(http.MethodPost, fmt.Sprintf("/admin/jobs/%d/retry", failing))
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Contains(t, body, "Only failed or cancelled jobs")
+
+	code, list, _ = do(http.MethodPost, fmt.Sprintf("/admin/jobs/%d/cancel", later))
+	assert.Equal(t, http.StatusOK, code)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, jobs.StatusCancelled, list[0].Status)
+		assert.JSONEq(t, `{"n":1}`, string(list[0].Payload))
+	}
+	code, _, _ = do(http.MethodPost, fmt.Sprintf("/admin/jobs/%d/cancel", later))
+	assert.Equal(t, http.StatusConflict, code)
+
+	// The unique key is free once cancelled, and taken again by the new job
+	_, enqueued, err := jobs.Enqueue(ctx, repo, "test.later", nil, jobs.Options{RunAt: next.Add(time.Hour), UniqueKey: "test.later"})
+	assert.NoError(t, err)
+	assert.True(t, enqueued)
+	code, _, body = do(http.MethodPost, fmt.Sprintf("/admin/jobs/%d/retry", later))
+	assert.Equal(t, http.StatusConflict, code)
+	assert.Contains(t, body, "same unique key")
+
+	code, _, _ = do(http.MethodPost, "/admin/jobs/999999/cancel")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _, _ = do(http.MethodPost, "/admin/jobs/abc/retry")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestBackgroundWorkersStop(t *testing.T) {
+	t.Setenv("ANALYTICS_SINKS", "logs")
+	t.Setenv("JOBS_SHUTDOWN_TIMEOUT", "10ms")
+	s := &Server{db: setupTestDb()}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.startBackgroundWorkers(ctx)
+	cancel()
+
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, s.waitWorkers(ctx))
+}
@@ -1,40 +1,83 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
 
+	"backendT/internal/avatar"
 	"backendT/internal/database"
+	"backendT/internal/database/seed"
+	"backendT/internal/health"
+	"backendT/internal/httpcache"
+	"backendT/internal/jobs"
+	"backendT/internal/notify"
+	"backendT/internal/outbox"
+	"backendT/internal/redact"
+	"backendT/internal/webhook"
 )
 
 type Server struct {
 	port int
 
 	db database.Service
+
+	health           *health.Registry
+	healthOnce       sync.Once
+	pendingLogWrites atomic.Int64
+	logWriter        logWriterStatus
+	redaction        *redact.Redactor
+	limiter          *rateLimiter
+	cache            *httpcache.LRU
+	avatarStore      *avatar.Store
+	notifier         *notify.Notifier
+	dispatcher       *outbox.Dispatcher
+	queue            *jobs.Queue
+
+	// Cancelled when the http server shuts down, background goroutines stop on it
+	shutdownCtx context.Context
+	// Cancelled by waitWorkers once the http server served its last request, the analytics sinks flush on it
+	sinksCtx  context.Context
+	stopSinks context.CancelFunc
+	// The background workers and analytics sinks, waited for before the database closes
+	workers sync.WaitGroup
 }
 
 /*func (s *Server) GetServer() (*http.Server, database.Service) {
 	return NewServer()
 }*/
 
-func NewServer(databaseNameOverride ...string) (*http.Server, database.Service) {
+// NewServer creates the http server on PORT and starts the background workers. Once the http server shut
+// down, waitWorkers blocks until the workers stopped (running jobs finished or released) and the analytics
+// sinks flushed, or ctx is done.
+func NewServer(databaseNameOverride ...string) (server *http.Server, db database.Service, waitWorkers func(ctx context.Context) error) {
 	port, _ := strconv.Atoi(os.Getenv("PORT"))
 	if port == 0 {
 		port = 8080
 	}
+	// Background workers stop when the http server shuts down
+	ctx, cancel := context.WithCancel(context.Background())
+
 	NewServer := &Server{
 		port: port,
 
 		db: database.New(databaseNameOverride...),
+
+		shutdownCtx: ctx,
 	}
 
+	NewServer.seedIfEmpty()
+
 	// Declare Server config
-	server := &http.Server{
+	server = &http.Server{
 		Addr:         fmt.Sprintf(":%d", NewServer.port),
 		Handler:      NewServer.RegisterRoutes(),
 		IdleTimeout:  time.Minute,
@@ -42,5 +85,88 @@ func NewServer(databaseNameOverride ...string) (*http.Server, database.Service)
 		WriteTimeout: 30 * time.Second,
 	}
 
-	return server, NewServer.db
+	server.RegisterOnShutdown(cancel)
+	// Ends the notification streams, which would hold up the shutdown otherwise
+	server.RegisterOnShutdown(NewServer.notifications().Close)
+	NewServer.startBackgroundWorkers(ctx)
+
+	return server, NewServer.db, NewServer.waitWorkers
+}
+
+// httpCache returns the cache of read responses, HTTP_CACHE_SIZE responses at most (1000 by default, 0 disables it).
+func (s *Server) httpCache() *httpcache.LRU {
+	if s.cache == nil {
+		size, err := strconv.Atoi(os.Getenv("HTTP_CACHE_SIZE"))
+		if err != nil || size < 0 {
+			size = 1000
+		}
+		s.cache = httpcache.NewLRU(size)
+	}
+	return s.cache
+}
+
+// notifications returns the notifier telling users about what others do to them, see events.
+func (s *Server) notifications() *notify.Notifier {
+	if s.notifier == nil {
+		s.notifier = notify.New(s.db.GetRepositoryRW())
+	}
+	return s.notifier
+}
+
+// startBackgroundWorkers starts the goroutines that run next to the http server until ctx is cancelled.
+func (s *Server) startBackgroundWorkers(ctx context.Context) {
+	s.workers.Go(func() { database.RunBackupScheduler(ctx, s.db, database.BackupConfigFromEnv()) })
+	s.workers.Go(func() { s.rateLimiter().runSweeper(ctx) })
+	s.workers.Go(func() { s.runAvatarSweeper(ctx) })
+	s.workers.Go(func() { s.runPostPublisher(ctx) })
+	s.workers.Go(func() { s.events().Run(ctx) })
+	s.workers.Go(func() { webhook.NewDeliverer(s.db.GetRepositoryRW(), webhook.ConfigFromEnv()).Run(ctx) })
+	s.workers.Go(func() { s.jobQueue().Run(ctx) })
+}
+
+// sinksContext returns the context the analytics sinks flush and close on, see waitWorkers.
+func (s *Server) sinksContext() context.Context {
+	if s.sinksCtx == nil {
+		s.sinksCtx, s.stopSinks = context.WithCancel(context.Background())
+	}
+	return s.sinksCtx
+}
+
+// waitWorkers closes the analytics sinks and waits until they flushed and the background workers stopped,
+// or ctx is done. The http server must have shut down already, requests record to the sinks until then.
+func (s *Server) waitWorkers(ctx context.Context) error {
+	if s.stopSinks != nil {
+		s.stopSinks()
+	}
+	stopped := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// seedIfEmpty fills a fresh database with the SEED_PROFILE data set (demo by default, empty in production).
+func (s *Server) seedIfEmpty() {
+	profile := os.Getenv("SEED_PROFILE")
+	if profile == "" {
+		profile = "demo"
+		if os.Getenv("APP_ENV") == "production" {
+			profile = "empty"
+		}
+	}
+
+	seeded, err := seed.RunIfEmpty(context.Background(), s.db, profile, seed.DefaultSeed)
+	if err != nil {
+		log.Printf("Error seeding database: %v", err)
+		return
+	}
+	if seeded {
+		log.Printf("Seeded empty database with the %s profile", profile)
+	}
 }
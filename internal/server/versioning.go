@@ -0,0 +1,153 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"backendT/internal/server/api"
+)
+
+// API versions, each is an Echo group (/v1, /v2...) registering its routes side by side.
+// Paths without a version are served by the version asked for in Accept, or API_DEFAULT_VERSION.
+var apiVersions = []string{"v1", "v2"}
+
+const defaultAPIVersion = "v1"
+
+// Paths outside the versioned API, they are never rewritten.
+var unversionedPrefixes = []string{"/health", "/swagger", "/admin", "/failure", "/avatars"}
+
+const headerAPIVersion = "X-API-Version"
+
+// Accept: application/vnd.backendt.v2+json or application/json; version=2
+var (
+	acceptVendorVersion = regexp.MustCompile(`application/vnd\.backendt\.v(\d+)\+json`)
+	acceptParamVersion  = regexp.MustCompile(`version=v?(\d+)`)
+)
+
+// apiLifecycle is when a version was deprecated and when it stops being served,
+// from API_<VERSION>_DEPRECATED and API_<VERSION>_SUNSET (dates like 2025-01-31 or RFC 3339 times).
+type apiLifecycle struct {
+	deprecated time.Time
+	sunset     time.Time
+	successor  string
+}
+
+func apiLifecycleFromEnv(version string) apiLifecycle {
+	var l apiLifecycle
+	prefix := "API_" + strings.ToUpper(version)
+	l.deprecated = parseLifecycleDate(prefix+"_DEPRECATED", os.Getenv(prefix+"_DEPRECATED"))
+	l.sunset = parseLifecycleDate(prefix+"_SUNSET", os.Getenv(prefix+"_SUNSET"))
+	if i := slices.Index(apiVersions, version); i >= 0 && i < len(apiVersions)-1 {
+		l.successor = apiVersions[i+1]
+	}
+	return l
+}
+
+func parseLifecycleDate(name, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	log.Printf("Invalid %s %q, expected a date like 2025-01-31", name, value)
+	return time.Time{}
+}
+
+// APIVersionMiddleware routes paths without a version to the version negotiated from the Accept header.
+// It has to run before routing (e.Pre).
+func (s *Server) APIVersionMiddleware() echo.MiddlewareFunc {
+	fallback := os.Getenv("API_DEFAULT_VERSION")
+	if !slices.Contains(apiVersions, fallback) {
+		fallback = defaultAPIVersion
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			path := req.URL.Path
+			first, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+			if path == "/" || slices.Contains(apiVersions, first) {
+				return next(c)
+			}
+			for _, prefix := range unversionedPrefixes {
+				if strings.HasPrefix(path, prefix) {
+					return next(c)
+				}
+			}
+
+			// The same path answers differently depending on Accept
+			c.Response().Header().Add(echo.HeaderVary, "Accept")
+			version := versionFromAccept(req.Header.Get("Accept"))
+			if version == "" {
+				version = fallback
+			} else if !slices.Contains(apiVersions, version) {
+				return c.JSON(http.StatusNotAcceptable, map[string]string{
+					"error": "Unknown API version " + version + ", supported: " + strings.Join(apiVersions, ", "),
+				})
+			}
+
+			req.URL.Path = "/" + version + path
+			if req.URL.RawPath != "" {
+				req.URL.RawPath = "/" + version + req.URL.RawPath
+			}
+			return next(c)
+		}
+	}
+}
+
+func versionFromAccept(accept string) string {
+	if m := acceptVendorVersion.FindStringSubmatch(accept); m != nil {
+		return "v" + m[1]
+	}
+	if m := acceptParamVersion.FindStringSubmatch(accept); m != nil {
+		return "v" + m[1]
+	}
+	return ""
+}
+
+// apiGroup creates the group of an API version. Its responses say which version served them and,
+// once the version is deprecated, when it goes away and what replaces it. After the sunset it answers 410.
+func (s *Server) apiGroup(e *echo.Echo, version string) *echo.Group {
+	lifecycle := apiLifecycleFromEnv(version)
+
+	return e.Group("/"+version, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			api.SetVersion(c, version)
+			h := c.Response().Header()
+			h.Set(headerAPIVersion, version)
+
+			now := time.Now()
+			if !lifecycle.deprecated.IsZero() && !now.Before(lifecycle.deprecated) {
+				h.Set("Deprecation", "@"+strconv.FormatInt(lifecycle.deprecated.Unix(), 10))
+				if lifecycle.successor != "" {
+					successor := "/" + lifecycle.successor + strings.TrimPrefix(c.Request().URL.Path, "/"+version)
+					h.Add("Link", "<"+successor+`>; rel="successor-version"`)
+				}
+			}
+			if !lifecycle.sunset.IsZero() {
+				h.Set("Sunset", lifecycle.sunset.UTC().Format(http.TimeFormat))
+				if !now.Before(lifecycle.sunset) {
+					msg := "API " + version + " is no longer available"
+					if lifecycle.successor != "" {
+						msg += ", use " + lifecycle.successor
+					}
+					return c.JSON(http.StatusGone, map[string]string{
+						"error": msg,
+					})
+				}
+			}
+			return next(c)
+		}
+	})
+}
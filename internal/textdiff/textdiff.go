@@ -0,0 +1,133 @@
+// Package textdiff compares two texts line by line or word by word.
+package textdiff
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Op says what happened to a chunk of text going from the old text to the new one.
+type Op string
+
+const (
+	Equal  Op = "equal"
+	Insert Op = "insert"
+	Delete Op = "delete"
+)
+
+// Chunk is a run of text with the same Op. Joining the Equal and Delete chunks gives
+// back the old text, joining the Equal and Insert chunks the new one.
+type Chunk struct {
+	Op   Op     `json:"op" enums:"equal,insert,delete"`
+	Text string `json:"text"`
+}
+
+// maxCells caps the table of the longest common subsequence (old tokens * new tokens left after
+// trimming the common prefix and suffix) to 4 MB, bigger differences are reported as one replacement.
+const maxCells = 1 << 20
+
+// Lines compares a and b line by line, the lines keep their trailing newline.
+func Lines(a, b string) []Chunk {
+	return Diff(splitLines(a), splitLines(b))
+}
+
+// Words compares a and b word by word, whitespace runs count as words of their own.
+func Words(a, b string) []Chunk {
+	return Diff(splitWords(a), splitWords(b))
+}
+
+// Diff compares two token lists and returns the chunks turning a into b, adjacent tokens
+// with the same Op are merged into one chunk.
+func Diff(a, b []string) []Chunk {
+	chunks := []Chunk{}
+	add := func(op Op, text string) {
+		if text == "" {
+			return
+		}
+		if n := len(chunks); n > 0 && chunks[n-1].Op == op {
+			chunks[n-1].Text += text
+			return
+		}
+		chunks = append(chunks, Chunk{Op: op, Text: text})
+	}
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	add(Equal, strings.Join(a[:prefix], ""))
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	if len(middleA)*len(middleB) > maxCells {
+		add(Delete, strings.Join(middleA, ""))
+		add(Insert, strings.Join(middleB, ""))
+	} else {
+		// lcs[i*w+j] is the length of the longest common subsequence of middleA[i:] and middleB[j:]
+		n, m := len(middleA), len(middleB)
+		w := m + 1
+		lcs := make([]int32, (n+1)*w)
+		for i := n - 1; i >= 0; i-- {
+			for j := m - 1; j >= 0; j-- {
+				if middleA[i] == middleB[j] {
+					lcs[i*w+j] = lcs[(i+1)*w+j+1] + 1
+				} else {
+					lcs[i*w+j] = max(lcs[(i+1)*w+j], lcs[i*w+j+1])
+				}
+			}
+		}
+
+		i, j := 0, 0
+		for i < n && j < m {
+			switch {
+			case middleA[i] == middleB[j]:
+				add(Equal, middleA[i])
+				i++
+				j++
+			case lcs[(i+1)*w+j] >= lcs[i*w+j+1]:
+				add(Delete, middleA[i])
+				i++
+			default:
+				add(Insert, middleB[j])
+				j++
+			}
+		}
+		add(Delete, strings.Join(middleA[i:], ""))
+		add(Insert, strings.Join(middleB[j:], ""))
+	}
+
+	add(Equal, strings.Join(a[len(a)-suffix:], ""))
+	return chunks
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func splitWords(s string) []string {
+	var words []string
+	start := 0
+	for i, r := range s {
+		if i > start && unicode.IsSpace(r) != isSpaceAt(s, start) {
+			words = append(words, s[start:i])
+			start = i
+		}
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+func isSpaceAt(s string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return unicode.IsSpace(r)
+}
@@ -0,0 +1,74 @@
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func join(chunks []Chunk, skip Op) string {
+	var sb strings.Builder
+	for _, c := range chunks {
+		if c.Op != skip {
+			sb.WriteString(c.Text)
+		}
+	}
+	return sb.String()
+}
+
+func TestLines(t *testing.T) {
+	a := "one\ntwo\nthree\n"
+	b := "one\n2\nthree\nfour"
+	chunks := Lines(a, b)
+	assert.Equal(t, []Chunk{
+		{Op: Equal, Text: "one\n"},
+		{Op: Delete, Text: "two\n"},
+		{Op: Insert, Text: "2\n"},
+		{Op: Equal, Text: "three\n"},
+		{Op: Insert, Text: "four"},
+	}, chunks)
+	assert.Equal(t, a, join(chunks, Insert))
+	assert.Equal(t, b, join(chunks, Delete))
+}
+
+func TestWords(t *testing.T) {
+	chunks := Words("the quick brown fox", "the slow brown  dog")
+	assert.Equal(t, []Chunk{
+		{Op: Equal, Text: "the "},
+		{Op: Delete, Text: "quick"},
+		{Op: Insert, Text: "slow"},
+		{Op: Equal, Text: " brown"},
+		{Op: Delete, Text: " fox"},
+		{Op: Insert, Text: "  dog"},
+	}, chunks)
+
+	assert.Equal(t, []Chunk{{Op: Equal, Text: "héllo wörld"}}, Words("héllo wörld", "héllo wörld"))
+	assert.Equal(t, []Chunk{}, Words("", ""))
+	assert.Equal(t, []Chunk{{Op: Insert, Text: "new"}}, Words("", "new"))
+}
+
+func TestDiffRoundTrip(t *testing.T) {
+	pairs := [][2]string{
+		{"a b c d e", "a c e f"},
+		{"x", "y"},
+		{"same", "same"},
+		{strings.Repeat("a ", 100), strings.Repeat("b a ", 60)},
+	}
+	for _, p := range pairs {
+		for _, chunks := range [][]Chunk{Words(p[0], p[1]), Lines(p[0], p[1])} {
+			assert.Equal(t, p[0], join(chunks, Insert))
+			assert.Equal(t, p[1], join(chunks, Delete))
+		}
+	}
+}
+
+func TestDiffTooBig(t *testing.T) {
+	var a, b strings.Builder
+	for i := range 2000 {
+		fmt.Fprintf(&a, "old %d\n", i)
+		fmt.Fprintf(&b, "new %d\n", i)
+	}
+	assert.Equal(t, []Chunk{{Op: Delete, Text: a.String()}, {Op: Insert, Text: b.String()}}, Lines(a.String(), b.String()))
+}
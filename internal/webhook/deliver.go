@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"backendT/internal/database/repository"
+)
+
+// Delivery statuses, a pending delivery is retried until it succeeds or runs out of attempts.
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+const (
+	// batchSize is how many due deliveries are sent per run
+	batchSize = 50
+	// maxResponseBody is how much of a response body is kept in the delivery log
+	maxResponseBody = 4 << 10
+)
+
+// Config describes how deliveries are sent and retried.
+type Config struct {
+	// How often due deliveries are looked for (0 disables the worker)
+	Interval time.Duration
+	// Attempts before a delivery fails for good
+	MaxAttempts int
+	// Wait before the first retry, doubled for every further one
+	RetryBase time.Duration
+	// Longest a receiver may take to answer
+	Timeout time.Duration
+}
+
+// ConfigFromEnv reads the delivery configuration from WEBHOOK_DELIVERY_INTERVAL (5s), WEBHOOK_MAX_ATTEMPTS (8),
+// WEBHOOK_RETRY_BASE (30s) and WEBHOOK_TIMEOUT (10s).
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Interval:    5 * time.Second,
+		MaxAttempts: 8,
+		RetryBase:   30 * time.Second,
+		Timeout:     10 * time.Second,
+	}
+	if interval, err := time.ParseDuration(os.Getenv("WEBHOOK_DELIVERY_INTERVAL")); err == nil {
+		cfg.Interval = interval
+	}
+	if attempts, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		cfg.MaxAttempts = attempts
+	}
+	if base, err := time.ParseDuration(os.Getenv("WEBHOOK_RETRY_BASE")); err == nil && base > 0 {
+		cfg.RetryBase = base
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("WEBHOOK_TIMEOUT")); err == nil && timeout > 0 {
+		cfg.Timeout = timeout
+	}
+	return cfg
+}
+
+// Backoff is the wait before the next attempt of a delivery that failed attempts times: RetryBase, then
+// twice as long after every further failure.
+func (cfg Config) Backoff(attempts int64) time.Duration {
+	wait := cfg.RetryBase
+	for i := int64(1); i < attempts && wait < 24*time.Hour; i++ {
+		wait *= 2
+	}
+	return wait
+}
+
+// DeliveryRepo reads the due deliveries and records their attempts.
+type DeliveryRepo interface {
+	WebhookDeliveriesGetDue(ctx context.Context, params repository.WebhookDeliveriesGetDueParams) ([]repository.WebhookDeliveriesGetDueRow, error)
+	WebhookDeliveriesRecordAttempt(ctx context.Context, params repository.WebhookDeliveriesRecordAttemptParams) error
+}
+
+// Deliverer sends the queued deliveries. A single one runs at a time, so a delivery is never sent twice at once.
+type Deliverer struct {
+	repo   DeliveryRepo
+	cfg    Config
+	client *http.Client
+}
+
+// NewDeliverer creates a deliverer sending the deliveries queued in repo.
+func NewDeliverer(repo DeliveryRepo, cfg Config) *Deliverer {
+	return &Deliverer{
+		repo:   repo,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Run sends the due deliveries every Interval until ctx is cancelled.
+func (d *Deliverer) Run(ctx context.Context) {
+	if d.cfg.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.DeliverDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("Error delivering webhooks: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue sends the deliveries due at now and returns how many were attempted.
+func (d *Deliverer) DeliverDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := d.repo.WebhookDeliveriesGetDue(ctx, repository.WebhookDeliveriesGetDueParams{
+		Now:   sql.NullTime{Time: now.UTC(), Valid: true},
+		Limit: batchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, delivery := range due {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+		if err := d.repo.WebhookDeliveriesRecordAttempt(ctx, d.attempt(ctx, delivery, now)); err != nil {
+			return i, fmt.Errorf("record attempt of delivery %d: %w", delivery.ID, err)
+		}
+	}
+	return len(due), nil
+}
+
+// attempt sends the delivery once and returns how it went. Any 2xx answer is a success.
+func (d *Deliverer) attempt(ctx context.Context, delivery repository.WebhookDeliveriesGetDueRow, now time.Time) repository.WebhookDeliveriesRecordAttemptParams {
+	result := repository.WebhookDeliveriesRecordAttemptParams{
+		ID:            delivery.ID,
+		Status:        StatusPending,
+		Attempts:      delivery.Attempts + 1,
+		NextAttemptAt: delivery.NextAttemptAt,
+		LastAttemptAt: sql.NullTime{Time: now.UTC(), Valid: true},
+	}
+
+	status, body, err := d.send(ctx, delivery, now)
+	if status != 0 {
+		result.ResponseStatus = sql.NullInt64{Int64: int64(status), Valid: true}
+		result.ResponseBody = sql.NullString{String: body, Valid: true}
+	}
+	if err == nil && (status < 200 || status > 299) {
+		err = fmt.Errorf("receiver answered %d", status)
+	}
+
+	switch {
+	case err == nil:
+		result.Status = StatusSucceeded
+	case result.Attempts >= int64(d.cfg.MaxAttempts):
+		result.Status = StatusFailed
+		result.Error = sql.NullString{String: err.Error(), Valid: true}
+	default:
+		result.Error = sql.NullString{String: err.Error(), Valid: true}
+		result.NextAttemptAt = sql.NullTime{Time: now.UTC().Add(d.cfg.Backoff(result.Attempts)), Valid: true}
+	}
+	return result
+}
+
+// send POSTs the delivery to its webhook and returns the status and (start of the) body of the answer.
+func (d *Deliverer) send(ctx context.Context, delivery repository.WebhookDeliveriesGetDueRow, now time.Time) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "backendT-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	if delivery.EventID.Valid {
+		req.Header.Set(HeaderEventID, strconv.FormatInt(delivery.EventID.Int64, 10))
+	}
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	return resp.StatusCode, string(respBody), err
+}
@@ -0,0 +1,133 @@
+// Package webhook tells other services about the users and posts created or changed here. Every outbox
+// event about them is queued as one delivery per webhook subscribed to it, which a background worker POSTs to the webhook
+// URL signed with its secret, retrying failed deliveries with exponential backoff.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/outbox"
+)
+
+// Event types, the data of user events is the user and the data of post events the post, as served by API v2.
+const (
+	EventUserCreated = outbox.EventUserCreated
+	EventUserUpdated = outbox.EventUserUpdated
+	EventUserDeleted = outbox.EventUserDeleted
+	EventPostCreated = outbox.EventPostCreated
+	EventPostUpdated = outbox.EventPostUpdated
+)
+
+// Events are all the event types webhooks can subscribe to.
+var Events = []string{EventUserCreated, EventUserUpdated, EventUserDeleted, EventPostCreated, EventPostUpdated}
+
+// AllEvents subscribes a webhook to every event type, including the ones added later.
+const AllEvents = "*"
+
+// Headers sent with every delivery. HeaderEventID stays the same when an event is delivered again, receivers
+// dedupe on it.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderEventID   = "X-Webhook-Event-ID"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	Event      string    `json:"event"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Data       any       `json:"data"`
+}
+
+// Sign returns the signature of a delivery body sent at timestamp (Unix seconds, the X-Webhook-Timestamp header):
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+// Receivers compute it again, compare it in constant time and reject old timestamps.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates a random secret for a webhook created without one.
+func NewSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// JoinEvents checks the event types of a subscription and joins them the way they are stored, sorted and
+// comma separated. ok is false when there are none or one of them is unknown.
+func JoinEvents(events []string) (joined string, ok bool) {
+	if len(events) == 0 {
+		return "", false
+	}
+	for _, event := range events {
+		if event != AllEvents && !slices.Contains(Events, event) {
+			return "", false
+		}
+	}
+	if slices.Contains(events, AllEvents) {
+		return AllEvents, true
+	}
+	events = slices.Clone(events)
+	slices.Sort(events)
+	return strings.Join(slices.Compact(events), ","), true
+}
+
+// SplitEvents is the inverse of JoinEvents.
+func SplitEvents(joined string) []string {
+	return strings.Split(joined, ",")
+}
+
+// EmitRepo queues the deliveries of events.
+type EmitRepo interface {
+	WebhookDeliveriesEnqueue(ctx context.Context, params repository.WebhookDeliveriesEnqueueParams) (int64, error)
+}
+
+// Emitter queues events for the webhooks subscribed to them.
+type Emitter struct {
+	repo EmitRepo
+}
+
+// NewEmitter creates an emitter queuing the deliveries in repo.
+func NewEmitter(repo EmitRepo) *Emitter {
+	return &Emitter{repo: repo}
+}
+
+// HandleEvent is the outbox subscriber of the emitter, it queues a delivery of the user and post events
+// to every active webhook subscribed to them. The outbox event types are the webhook event types, an event
+// handed over again is not queued twice for the same webhook.
+func (e *Emitter) HandleEvent(ctx context.Context, event outbox.Event) error {
+	if !slices.Contains(Events, event.Type) {
+		return nil
+	}
+
+	payload, err := json.Marshal(Payload{Event: event.Type, OccurredAt: event.CreatedAt.UTC(), Data: event.Payload})
+	if err != nil {
+		return fmt.Errorf("encode %s webhook payload: %w", event.Type, err)
+	}
+	_, err = e.repo.WebhookDeliveriesEnqueue(ctx, repository.WebhookDeliveriesEnqueueParams{
+		EventID: sql.NullInt64{Int64: event.ID, Valid: true},
+		Event:   event.Type,
+		Payload: string(payload),
+	})
+	return err
+}
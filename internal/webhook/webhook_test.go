@@ -0,0 +1,148 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"backendT/internal/database/repository"
+	"backendT/internal/outbox"
+)
+
+func TestJoinEvents(t *testing.T) {
+	joined, ok := JoinEvents([]string{EventPostUpdated, EventPostCreated, EventPostUpdated})
+	assert.True(t, ok)
+	assert.Equal(t, "post.created,post.updated", joined)
+	assert.Equal(t, []string{EventPostCreated, EventPostUpdated}, SplitEvents(joined))
+
+	joined, ok = JoinEvents([]string{EventUserCreated, AllEvents})
+	assert.True(t, ok)
+	assert.Equal(t, AllEvents, joined)
+
+	_, ok = JoinEvents(nil)
+	assert.False(t, ok)
+	_, ok = JoinEvents([]string{"post.liked"})
+	assert.False(t, ok)
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := Config{RetryBase: 30 * time.Second}
+	assert.Equal(t, 30*time.Second, cfg.Backoff(1))
+	assert.Equal(t, time.Minute, cfg.Backoff(2))
+	assert.Equal(t, 4*time.Minute, cfg.Backoff(4))
+	assert.LessOrEqual(t, cfg.Backoff(1000), 48*time.Hour)
+}
+
+type fakeEmitRepo struct {
+	queued []repository.WebhookDeliveriesEnqueueParams
+}
+
+func (r *fakeEmitRepo) WebhookDeliveriesEnqueue(ctx context.Context, params repository.WebhookDeliveriesEnqueueParams) (int64, error) {
+	r.queued = append(r.queued, params)
+	return 1, nil
+}
+
+func TestHandleEvent(t *testing.T) {
+	repo := &fakeEmitRepo{}
+	e := NewEmitter(repo)
+	createdAt := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, e.HandleEvent(context.Background(), outbox.Event{ID: 3, Type: outbox.EventPostCreated, Payload: json.RawMessage(`{"id":1}`), CreatedAt: createdAt}))
+	assert.NoError(t, e.HandleEvent(context.Background(), outbox.Event{Type: outbox.EventUserFollowed, Payload: json.RawMessage(`{}`), CreatedAt: createdAt}))
+	if assert.Len(t, repo.queued, 1) {
+		assert.Equal(t, EventPostCreated, repo.queued[0].Event)
+		assert.Equal(t, sql.NullInt64{Int64: 3, Valid: true}, repo.queued[0].EventID)
+		assert.JSONEq(t, `{"event":"post.created","occurred_at":"2025-01-31T12:00:00Z","data":{"id":1}}`, repo.queued[0].Payload)
+	}
+}
+
+type fakeDeliveryRepo struct {
+	due      []repository.WebhookDeliveriesGetDueRow
+	attempts []repository.WebhookDeliveriesRecordAttemptParams
+}
+
+func (r *fakeDeliveryRepo) WebhookDeliveriesGetDue(ctx context.Context, params repository.WebhookDeliveriesGetDueParams) ([]repository.WebhookDeliveriesGetDueRow, error) {
+	return r.due, nil
+}
+
+func (r *fakeDeliveryRepo) WebhookDeliveriesRecordAttempt(ctx context.Context, params repository.WebhookDeliveriesRecordAttemptParams) error {
+	r.attempts = append(r.attempts, params)
+	return nil
+}
+
+func TestDeliverer(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	var received *http.Request
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		w.Write([]byte("busy"))
+	}))
+	defer receiver.Close()
+
+	repo := &fakeDeliveryRepo{due: []repository.WebhookDeliveriesGetDueRow{{
+		ID:       7,
+		EventID:  sql.NullInt64{Int64: 3, Valid: true},
+		Event:    EventPostCreated,
+		Payload:  `{"event":"post.created"}`,
+		Attempts: 0,
+		Url:      receiver.URL,
+		Secret:   "secret",
+	}}}
+	d := NewDeliverer(repo, Config{MaxAttempts: 2, RetryBase: time.Minute, Timeout: time.Second})
+	now := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	attempted, err := d.DeliverDue(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	if assert.NotNil(t, received) {
+		assert.Equal(t, `{"event":"post.created"}`, string(body))
+		assert.Equal(t, EventPostCreated, received.Header.Get(HeaderEvent))
+		assert.Equal(t, "3", received.Header.Get(HeaderEventID))
+		assert.Equal(t, "7", received.Header.Get(HeaderDelivery))
+		assert.Equal(t, strconv.FormatInt(now.Unix(), 10), received.Header.Get(HeaderTimestamp))
+		assert.Equal(t, Sign("secret", now.Unix(), body), received.Header.Get(HeaderSignature))
+	}
+
+	// Retried a minute later
+	if assert.Len(t, repo.attempts, 1) {
+		attempt := repo.attempts[0]
+		assert.Equal(t, StatusPending, attempt.Status)
+		assert.Equal(t, int64(1), attempt.Attempts)
+		assert.Equal(t, now.Add(time.Minute), attempt.NextAttemptAt.Time)
+		assert.Equal(t, int64(http.StatusServiceUnavailable), attempt.ResponseStatus.Int64)
+		assert.Equal(t, "busy", attempt.ResponseBody.String)
+		assert.Equal(t, "receiver answered 503", attempt.Error.String)
+	}
+
+	// Out of attempts
+	repo.due[0].Attempts = 1
+	_, err = d.DeliverDue(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusFailed, repo.attempts[1].Status)
+
+	status = http.StatusNoContent
+	repo.due[0].Attempts = 0
+	_, err = d.DeliverDue(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusSucceeded, repo.attempts[2].Status)
+	assert.False(t, repo.attempts[2].Error.Valid)
+
+	// Nobody listening
+	repo.due[0].Url = "http://127.0.0.1:1"
+	_, err = d.DeliverDue(context.Background(), now)
+	assert.NoError(t, err)
+	assert.Equal(t, StatusPending, repo.attempts[3].Status)
+	assert.False(t, repo.attempts[3].ResponseStatus.Valid)
+	assert.True(t, repo.attempts[3].Error.Valid)
+}